@@ -0,0 +1,525 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/approval"
+	"github.com/moltgo/moltgo/pkg/audit"
+	"github.com/moltgo/moltgo/pkg/compose"
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/render"
+	"github.com/spf13/cobra"
+)
+
+var (
+	approveAll      bool
+	approveReviewer string
+	approveNote     string
+	approveAllowDup bool
+)
+
+var approveCmd = &cobra.Command{
+	Use:   "approve",
+	Short: "Review posts, comments and votes held for approval",
+	Long: `Review outbound content held for approval. When approval is required
+([approval] required = true in config.toml), posts, comments and votes made by
+unattended runs (heartbeat, the daemon and --non-interactive commands) are
+queued locally instead of sent.
+
+Accepting a request sends it; rejecting it discards it. Every decision and edit
+is kept in the queue with who made it and when.
+
+A request is marked sending while it is sent, so no other run can send, edit or
+accept it again. One left sending by an interrupted run is never sent again;
+check Moltbook for it and reject it if it did not arrive.`,
+}
+
+var approveListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List requests awaiting approval",
+	Args:  cobra.NoArgs,
+	RunE:  runApproveList,
+}
+
+var approveShowCmd = &cobra.Command{
+	Use:   "show <id>",
+	Short: "Show a request and its history",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runApproveShow,
+}
+
+var approveAcceptCmd = &cobra.Command{
+	Use:   "accept <id>...",
+	Short: "Send held requests",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runApproveAccept,
+}
+
+var approveRejectCmd = &cobra.Command{
+	Use:   "reject <id>...",
+	Short: "Discard held requests",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runApproveReject,
+}
+
+var approveEditCmd = &cobra.Command{
+	Use:   "edit <id>",
+	Short: "Edit a held post or comment in $EDITOR",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runApproveEdit,
+}
+
+func init() {
+	rootCmd.AddCommand(approveCmd)
+	approveCmd.AddCommand(approveListCmd, approveShowCmd, approveAcceptCmd, approveRejectCmd, approveEditCmd)
+
+	approveCmd.PersistentFlags().StringVar(&approveReviewer, "as", "", "Reviewer name for the audit trail (default: current user)")
+	approveListCmd.Flags().BoolVarP(&approveAll, "all", "a", false, "Include accepted and rejected requests")
+	approveAcceptCmd.Flags().BoolVar(&approveAllowDup, "allow-duplicate", false, "Send posts even if they look like duplicates")
+	approveRejectCmd.Flags().StringVar(&approveNote, "note", "", "Reason for rejecting")
+}
+
+func loadApprovals() (*approval.Queue, string, error) {
+	path, err := config.GetApprovalsPath()
+	if err != nil {
+		return nil, "", err
+	}
+	queue, err := approval.Load(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return queue, path, nil
+}
+
+// getRequest looks up a request by its ID argument
+func getRequest(queue *approval.Queue, arg string) (*approval.Request, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return nil, usageError{fmt.Errorf("invalid request ID %q", arg)}
+	}
+	r := queue.Get(id)
+	if r == nil {
+		return nil, fmt.Errorf("request %d not found", id)
+	}
+	return r, nil
+}
+
+// getPending looks up a request that is still awaiting a decision
+func getPending(queue *approval.Queue, arg string) (*approval.Request, error) {
+	r, err := getRequest(queue, arg)
+	if err != nil {
+		return nil, err
+	}
+	if r.Status != approval.StatusPending {
+		return nil, fmt.Errorf("request %d is already %s", r.ID, r.Status)
+	}
+	return r, nil
+}
+
+// reviewer names the person making a decision
+func reviewer() string {
+	if approveReviewer != "" {
+		return approveReviewer
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	return "unknown"
+}
+
+// summarize describes a request on one line
+func summarize(r *approval.Request) string {
+	switch r.Kind {
+	case approval.KindPost:
+		if req, err := r.Post(); err == nil {
+			return fmt.Sprintf("%q in /%s", req.Title, req.Submolt)
+		}
+	case approval.KindComment:
+		if req, err := r.Comment(); err == nil {
+			return fmt.Sprintf("on post %s: %s", r.PostID, render.Preview(req.Content, 60))
+		}
+	case approval.KindVote:
+		if req, err := r.Vote(); err == nil {
+			return fmt.Sprintf("%svote %s %s", req.Direction, req.TargetType, req.TargetID)
+		}
+	}
+	return "(unreadable request)"
+}
+
+func runApproveList(cmd *cobra.Command, args []string) error {
+	queue, _, err := loadApprovals()
+	if err != nil {
+		return err
+	}
+
+	requests := queue.Pending()
+	if approveAll {
+		requests = queue.Requests
+	}
+	if len(requests) == 0 {
+		fmt.Fprintln(deps.Out, "No requests awaiting approval.")
+		return nil
+	}
+
+	for i := range requests {
+		r := &requests[i]
+		fmt.Fprintf(deps.Out, "[%d] %s %s\n", r.ID, r.Kind, summarize(r))
+		fmt.Fprintf(deps.Out, "    %s | from %s | %s\n", r.Status, r.Source, localTime(r.CreatedAt))
+	}
+
+	fmt.Fprintf(deps.Out, "\nPending: %d\n", len(queue.Pending()))
+	return nil
+}
+
+func runApproveShow(cmd *cobra.Command, args []string) error {
+	queue, _, err := loadApprovals()
+	if err != nil {
+		return err
+	}
+	r, err := getRequest(queue, args[0])
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(deps.Out, "Request %d: %s (%s)\n", r.ID, r.Kind, r.Status)
+	fmt.Fprintf(deps.Out, "  From: %s\n", r.Source)
+	fmt.Fprintf(deps.Out, "  Queued: %s\n", localTime(r.CreatedAt))
+	if r.ResultID != "" {
+		fmt.Fprintf(deps.Out, "  Sent as: %s\n", r.ResultID)
+	}
+	fmt.Fprintln(deps.Out)
+
+	rend := render.New(deps.Out)
+	switch r.Kind {
+	case approval.KindPost:
+		req, err := r.Post()
+		if err != nil {
+			return err
+		}
+		previewPost(deps.Out, rend, &compose.PostDraft{Submolt: req.Submolt, Title: req.Title, URL: req.URL, Content: req.Content})
+	case approval.KindComment:
+		req, err := r.Comment()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(deps.Out, "On post %s", r.PostID)
+		if req.ParentID != "" {
+			fmt.Fprintf(deps.Out, ", in reply to %s", req.ParentID)
+		}
+		fmt.Fprintln(deps.Out)
+		previewComment(deps.Out, rend, req.Content)
+	default:
+		fmt.Fprintln(deps.Out, summarize(r))
+	}
+
+	fmt.Fprintln(deps.Out, "\nHistory:")
+	for _, e := range r.History {
+		line := fmt.Sprintf("  %s  %-8s by %s", localTime(e.At), e.Action, e.By)
+		if e.Note != "" {
+			line += ": " + e.Note
+		}
+		fmt.Fprintln(deps.Out, line)
+	}
+	return nil
+}
+
+// updateRequest applies change to request id in the queue at path, under
+// the queue's lock, unless this is a dry run
+func updateRequest(path string, id int, change func(r *approval.Request) error) error {
+	if dryRun {
+		return nil
+	}
+	return approval.Update(path, func(queue *approval.Queue) error {
+		r := queue.Get(id)
+		if r == nil {
+			return fmt.Errorf("request %d not found", id)
+		}
+		return change(r)
+	})
+}
+
+// claimRequests marks the requests named by args as being sent, under the
+// queue's lock, and returns copies of them as claimed. Every request must
+// still be pending, so a request is only ever claimed by one run, and a
+// request rejected or edited since it was listed is sent as it now stands.
+// If any ID is bad, nothing is claimed. A dry run claims nothing.
+func claimRequests(path string, args []string, by string, now time.Time) ([]approval.Request, error) {
+	var claimed []approval.Request
+	claim := func(queue *approval.Queue) error {
+		claimed = nil
+		var seen []int
+		for _, arg := range args {
+			r, err := getRequest(queue, arg)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(seen, r.ID) {
+				continue
+			}
+			if r.Status != approval.StatusPending {
+				return fmt.Errorf("request %d is already %s", r.ID, r.Status)
+			}
+			seen = append(seen, r.ID)
+			r.Status = approval.StatusSending
+			r.Record(approval.StatusSending, by, "", now)
+			claimed = append(claimed, *r)
+		}
+		return nil
+	}
+
+	if dryRun {
+		queue, err := approval.Load(path)
+		if err != nil {
+			return nil, err
+		}
+		return claimed, claim(queue)
+	}
+	return claimed, approval.Update(path, claim)
+}
+
+func runApproveAccept(cmd *cobra.Command, args []string) error {
+	path, err := config.GetApprovalsPath()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := deps.Config.LoadCredentials()
+	if err != nil {
+		return err
+	}
+
+	state, err := deps.State.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	// Accepted requests are sent directly, never held again
+	client, err := deps.NewClient(cfg, false)
+	if err != nil {
+		return err
+	}
+	by := reviewer()
+
+	requests, err := claimRequests(path, args, by, deps.Now())
+	if err != nil {
+		return err
+	}
+
+	// Each outcome is saved as soon as it is known, so a request that was
+	// sent is never put back to be sent again
+	var failed error
+	for i := range requests {
+		r := &requests[i]
+		now := deps.Now()
+		ctx := audit.WithTrigger(cmd.Context(), fmt.Sprintf("approval:%d", r.ID))
+		resultID, err := sendRequest(ctx, client, cfg, state, r, now)
+		if err != nil {
+			fmt.Fprintf(deps.Out, "Request %d not sent: %v\n", r.ID, err)
+			failed = fmt.Errorf("some requests were not sent")
+			note := err.Error()
+			if err := updateRequest(path, r.ID, func(r *approval.Request) error {
+				r.Status = approval.StatusPending
+				r.Record("failed", by, note, now)
+				return nil
+			}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		fmt.Fprintf(deps.Out, "Request %d accepted and sent: %s\n", r.ID, summarize(r))
+		if err := updateRequest(path, r.ID, func(r *approval.Request) error {
+			r.Status = approval.StatusAccepted
+			r.ResultID = resultID
+			r.Record(approval.StatusAccepted, by, "", now)
+			return nil
+		}); err != nil {
+			return fmt.Errorf("request %d was sent as %s but could not be marked accepted: %w", r.ID, resultID, err)
+		}
+		if err := saveState(state); err != nil {
+			fmt.Fprintf(deps.Out, "Warning: failed to save state: %v\n", err)
+		}
+	}
+	return failed
+}
+
+// sendRequest sends a held request within the local rate limits, recording
+// it in state, and returns the ID of the created post or comment. Posts that
+// duplicate an existing post are not sent without --allow-duplicate.
+func sendRequest(ctx context.Context, client moltbook.API, cfg *config.Config, state *config.State, r *approval.Request, now time.Time) (string, error) {
+	switch r.Kind {
+	case approval.KindPost:
+		req, err := r.Post()
+		if err != nil {
+			return "", err
+		}
+		if err := state.CheckPost(now); err != nil {
+			return "", err
+		}
+		draft := &compose.PostDraft{Submolt: req.Submolt, Title: req.Title, URL: req.URL, Content: req.Content}
+		if err := checkDuplicate(ctx, deps.Out, client, cfg, state, draft, approveAllowDup); err != nil {
+			return "", err
+		}
+		post, err := client.CreatePost(ctx, req)
+		if err != nil {
+			return "", err
+		}
+		state.RecordPost(post.ID, now)
+		state.RememberPost(sentPost(draft, "", post.ID), now)
+		return post.ID, nil
+
+	case approval.KindComment:
+		req, err := r.Comment()
+		if err != nil {
+			return "", err
+		}
+		if err := state.CheckComment(now); err != nil {
+			return "", err
+		}
+		comment, err := client.CreateReply(ctx, r.PostID, req.ParentID, req.Content)
+		if err != nil {
+			return "", err
+		}
+		state.RecordComment(now)
+		return comment.ID, nil
+
+	case approval.KindVote:
+		req, err := r.Vote()
+		if err != nil {
+			return "", err
+		}
+		return "", client.Vote(ctx, req.TargetType, req.TargetID, req.Direction)
+	}
+	return "", fmt.Errorf("unknown request kind %q", r.Kind)
+}
+
+func runApproveReject(cmd *cobra.Command, args []string) error {
+	path, err := config.GetApprovalsPath()
+	if err != nil {
+		return err
+	}
+
+	by := reviewer()
+	var rejected []approval.Request
+	reject := func(queue *approval.Queue) error {
+		rejected = nil
+		for _, arg := range args {
+			r, err := getRequest(queue, arg)
+			if err != nil {
+				return err
+			}
+			// A request left sending by an interrupted accept may be
+			// rejected once it is known not to have reached Moltbook
+			if r.Status != approval.StatusPending && r.Status != approval.StatusSending {
+				return fmt.Errorf("request %d is already %s", r.ID, r.Status)
+			}
+			r.Status = approval.StatusRejected
+			r.Record(approval.StatusRejected, by, approveNote, deps.Now())
+			rejected = append(rejected, *r)
+		}
+		return nil
+	}
+
+	if dryRun {
+		queue, err := approval.Load(path)
+		if err == nil {
+			err = reject(queue)
+		}
+		if err != nil {
+			return err
+		}
+	} else if err := approval.Update(path, reject); err != nil {
+		return err
+	}
+
+	for _, r := range rejected {
+		fmt.Fprintf(deps.Out, "Request %d rejected: %s\n", r.ID, summarize(&r))
+	}
+	return nil
+}
+
+func runApproveEdit(cmd *cobra.Command, args []string) error {
+	queue, path, err := loadApprovals()
+	if err != nil {
+		return err
+	}
+	r, err := getPending(queue, args[0])
+	if err != nil {
+		return err
+	}
+
+	switch r.Kind {
+	case approval.KindPost:
+		req, err := r.Post()
+		if err != nil {
+			return err
+		}
+		current := &compose.PostDraft{Submolt: req.Submolt, Title: req.Title, URL: req.URL, Content: req.Content}
+		edited, err := compose.Edit(current.Template())
+		if err != nil {
+			return err
+		}
+		post, err := compose.ParsePostDraft(edited)
+		if err != nil {
+			return err
+		}
+		if err := post.Validate(); err != nil {
+			return err
+		}
+		req.Submolt, req.Title, req.URL, req.Content = post.Submolt, post.Title, post.URL, post.Content
+		if err := r.SetBody(req); err != nil {
+			return err
+		}
+
+	case approval.KindComment:
+		req, err := r.Comment()
+		if err != nil {
+			return err
+		}
+		edited, err := compose.Edit(compose.CommentTemplate(r.PostID, req.Content))
+		if err != nil {
+			return err
+		}
+		if req.Content, err = compose.ParseComment(edited); err != nil {
+			return err
+		}
+		if req.Content == "" {
+			return fmt.Errorf("comment is empty; request %d not changed", r.ID)
+		}
+		if err := r.SetBody(req); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("%s requests cannot be edited; accept or reject them", r.Kind)
+	}
+
+	// The request may have been decided while it was being edited
+	body, by, now := r.Body, reviewer(), deps.Now()
+	if err := updateRequest(path, r.ID, func(r *approval.Request) error {
+		if r.Status != approval.StatusPending {
+			return fmt.Errorf("request %d was %s while editing; edit discarded", r.ID, r.Status)
+		}
+		r.Body = body
+		r.Record("edited", by, "", now)
+		return nil
+	}); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+
+	fmt.Fprintf(deps.Out, "Request %d updated.\n", r.ID)
+	return nil
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/moltgo/moltgo/pkg/approval"
+)
+
+func TestClaimRequestsOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "approvals.toml")
+	err := approval.Update(path, func(q *approval.Queue) error {
+		q.Add(approval.Request{Kind: approval.KindVote, Body: `{"target_type":"post","target_id":"post_1","direction":"up"}`, Source: "test"}, testNow)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Concurrent accepts claim the request once between them
+	var wg sync.WaitGroup
+	claims := make(chan int, 8)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			requests, err := claimRequests(path, []string{"1", "1"}, "tester", testNow)
+			if err == nil {
+				claims <- len(requests)
+			} else if !strings.Contains(err.Error(), "already sending") {
+				t.Errorf("claim failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(claims)
+
+	var total int
+	for n := range claims {
+		total += n
+	}
+	if total != 1 {
+		t.Errorf("request claimed %d times, want once", total)
+	}
+
+	q, err := approval.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := q.Get(1); r.Status != approval.StatusSending {
+		t.Errorf("status = %q, want sending", r.Status)
+	}
+}
+
+func TestClaimRequestsAllOrNothing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "approvals.toml")
+	err := approval.Update(path, func(q *approval.Queue) error {
+		q.Add(approval.Request{Kind: approval.KindVote, Body: `{}`, Source: "test"}, testNow)
+		r := q.Add(approval.Request{Kind: approval.KindVote, Body: `{}`, Source: "test"}, testNow)
+		r.Status = approval.StatusRejected
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := claimRequests(path, []string{"1", "2"}, "tester", testNow); err == nil || err.Error() != "request 2 is already rejected" {
+		t.Fatalf("err = %v, want request 2 refused", err)
+	}
+	q, err := approval.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := q.Get(1); r.Status != approval.StatusPending {
+		t.Errorf("request 1 is %s, want it left pending", r.Status)
+	}
+}
@@ -0,0 +1,305 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/archive"
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/render"
+	"github.com/spf13/cobra"
+)
+
+var (
+	archiveSubmolts []string
+	archivePages    int
+	archiveFull     bool
+	archiveRate     int
+)
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Keep a local archive of Moltbook content",
+	Long: `Mirror submolts into a local database (archive.db in the config directory)
+for offline analysis. Posts and their comment trees are kept even if they are
+deleted from Moltbook, and changes to scores and content are recorded.`,
+}
+
+var archiveSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Archive new posts and comments from submolts",
+	Long: `Crawl submolts newest first and archive their posts and comment trees.
+Submolts are given with --submolt or set in config.toml:
+
+  [archive]
+  submolts = ["general", "golang"]
+
+A sync stops once it reaches posts that are already archived (use --full to
+crawl everything again and record score changes on older posts). Requests are
+paced to stay within the API rate limit. An interrupted sync resumes where it
+stopped the next time it is run.`,
+	Args: cobra.NoArgs,
+	RunE: runArchiveSync,
+}
+
+var archiveEmbedCmd = &cobra.Command{
+	Use:   "embed",
+	Short: "Embed archived posts that have no vector yet",
+	Long: `Compute the vectors of archived posts that have none from the configured
+[embedding] provider, such as posts indexed by browse and search or archived
+before the provider was changed. Run it after changing providers, or from cron,
+so that similar and search --semantic do not have to embed posts first.`,
+	Args: cobra.NoArgs,
+	RunE: runArchiveEmbed,
+}
+
+var archiveStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show what the archive holds",
+	Args:  cobra.NoArgs,
+	RunE:  runArchiveStatus,
+}
+
+var archiveShowCmd = &cobra.Command{
+	Use:   "show <post-id>",
+	Short: "Show an archived post with its comments and change history",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runArchiveShow,
+}
+
+func init() {
+	rootCmd.AddCommand(archiveCmd)
+	archiveCmd.AddCommand(archiveSyncCmd, archiveEmbedCmd, archiveStatusCmd, archiveShowCmd)
+
+	archiveSyncCmd.Flags().StringSliceVarP(&archiveSubmolts, "submolt", "s", nil, "Submolt to archive (repeatable)")
+	archiveSyncCmd.Flags().IntVar(&archivePages, "pages", 0, "Maximum pages of posts per submolt in this run (0 for no limit)")
+	archiveSyncCmd.Flags().BoolVar(&archiveFull, "full", false, "Crawl to the end of each submolt instead of stopping at archived posts")
+	archiveSyncCmd.Flags().IntVar(&archiveRate, "rate", 60, "Maximum requests per minute")
+}
+
+func openArchive() (*archive.DB, error) {
+	path, err := config.GetArchivePath()
+	if err != nil {
+		return nil, err
+	}
+	return archive.Open(path)
+}
+
+// indexPosts adds fetched posts to the archive so local search can find
+// them. They are embedded later, by archive sync or archive embed or when
+// semantic search needs them, so that commands never wait on the embedding
+// provider. Indexing is skipped while another process, such as a running
+// sync, has the archive open.
+func indexPosts(posts []moltbook.Post) {
+	if len(posts) == 0 {
+		return
+	}
+	db, err := openArchive()
+	if errors.Is(err, archive.ErrInUse) {
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(deps.Err, "Warning: failed to index posts: %v\n", err)
+		return
+	}
+	defer db.Close()
+
+	now := deps.Now()
+	for _, post := range posts {
+		if _, err := db.PutPost(post, now); err != nil {
+			fmt.Fprintf(deps.Err, "Warning: failed to index posts: %v\n", err)
+			return
+		}
+	}
+}
+
+func runArchiveSync(cmd *cobra.Command, args []string) error {
+	cfg, err := deps.Config.LoadCredentials()
+	if err != nil {
+		return err
+	}
+
+	submolts := archiveSubmolts
+	if len(submolts) == 0 {
+		submolts = cfg.Archive.Submolts
+	}
+	if len(submolts) == 0 {
+		return usageError{fmt.Errorf("no submolts to archive; use --submolt or set submolts in the [archive] section of config.toml")}
+	}
+	if archiveRate <= 0 {
+		return usageError{fmt.Errorf("--rate must be positive")}
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	provider, err := newEmbedder(cfg)
+	if err != nil {
+		return err
+	}
+
+	db, err := openArchive()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	syncer := &archive.Syncer{
+		DB:       db,
+		Source:   client,
+		MaxPages: archivePages,
+		Full:     archiveFull,
+		Embedder: provider,
+		Interval: time.Minute / time.Duration(archiveRate),
+		Logf: func(format string, args ...interface{}) {
+			fmt.Fprintf(deps.Out, "  "+format+"\n", args...)
+		},
+		Now: deps.Now,
+	}
+
+	for _, submolt := range submolts {
+		submolt = strings.TrimPrefix(submolt, "/")
+		fmt.Fprintf(deps.Out, "Syncing /%s...\n", submolt)
+
+		res, err := syncer.Sync(ctx, submolt)
+		if errors.Is(err, context.Canceled) {
+			fmt.Fprintln(deps.Out, "\nInterrupted; run `moltgo archive sync` again to resume.")
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to sync /%s: %w", submolt, err)
+		}
+		fmt.Fprintf(deps.Out, "  %d posts seen, %d new, %d comment threads archived\n\n", res.Posts, res.NewPosts, res.Threads)
+	}
+	return nil
+}
+
+func runArchiveEmbed(cmd *cobra.Command, args []string) error {
+	cfg, err := deps.Config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	provider, err := newEmbedder(cfg)
+	if err != nil {
+		return err
+	}
+
+	db, err := openArchive()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	n, err := db.EmbedMissing(ctx, provider, func(done, total int) {
+		fmt.Fprintf(deps.Err, "Embedding archived posts with %s: %d/%d\n", provider.Name(), done, total)
+	})
+	if err != nil {
+		return fmt.Errorf("embedded %d posts before failing: %w", n, err)
+	}
+	fmt.Fprintf(deps.Out, "Embedded %d posts with %s.\n", n, provider.Name())
+	return nil
+}
+
+func runArchiveStatus(cmd *cobra.Command, args []string) error {
+	db, err := openArchive()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	stats, err := db.Stats()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(deps.Out, "Posts: %d\n", stats.Posts)
+	fmt.Fprintf(deps.Out, "Comments: %d\n", stats.Comments)
+	fmt.Fprintf(deps.Out, "Recorded changes: %d\n", stats.Changes)
+	fmt.Fprintf(deps.Out, "Indexed for search: %d\n", stats.Indexed)
+
+	cursors, err := db.Cursors()
+	if err != nil {
+		return err
+	}
+	if len(cursors) > 0 {
+		fmt.Fprintln(deps.Out, "\nSubmolts:")
+	}
+	for _, cur := range cursors {
+		if cur.Done {
+			fmt.Fprintf(deps.Out, "  /%s: synced %s\n", cur.Submolt, localTime(cur.LastCompleted))
+		} else {
+			fmt.Fprintf(deps.Out, "  /%s: interrupted at offset %d with %d comment thread(s) pending\n", cur.Submolt, cur.Offset, len(cur.Pending))
+		}
+	}
+	return nil
+}
+
+func runArchiveShow(cmd *cobra.Command, args []string) error {
+	db, err := openArchive()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rec, err := db.Post(args[0])
+	if err != nil {
+		return err
+	}
+	if rec == nil {
+		return fmt.Errorf("post %s is not archived", args[0])
+	}
+
+	r := render.New(deps.Out)
+	printPost(deps.Out, r, 1, rec.Post, true)
+	fmt.Fprintf(deps.Out, "    First seen: %s | Last seen: %s\n", localTime(rec.FirstSeen), localTime(rec.LastSeen))
+
+	comments, err := db.Comments(rec.Post.ID)
+	if err != nil {
+		return err
+	}
+	if len(comments) > 0 {
+		fmt.Fprintf(deps.Out, "\nComments (%d):\n", len(comments))
+	}
+	for _, c := range comments {
+		fmt.Fprintf(deps.Out, "  %s (%d): %s\n", render.Sanitize(c.Comment.Author), c.Comment.Score, render.Preview(c.Comment.Content, previewWidth))
+	}
+
+	changes, err := db.History(archive.KindPost, rec.Post.ID)
+	if err != nil {
+		return err
+	}
+	for _, c := range comments {
+		history, err := db.History(archive.KindComment, c.Comment.ID)
+		if err != nil {
+			return err
+		}
+		changes = append(changes, history...)
+	}
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].At.Before(changes[j].At.Time) })
+	if len(changes) > 0 {
+		fmt.Fprintln(deps.Out, "\nChanges:")
+	}
+	for _, ch := range changes {
+		target := "post"
+		if ch.Kind == archive.KindComment {
+			target = "comment " + ch.ID
+		}
+		fmt.Fprintf(deps.Out, "  %s  %s %s: %s -> %s\n", localTime(ch.At), target, ch.Field,
+			render.Preview(ch.Old, 40), render.Preview(ch.New, 40))
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/moltgo/moltgo/pkg/audit"
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	auditLimit   int
+	auditAction  string
+	auditTrigger string
+)
+
+var auditCmd = &cobra.Command{
+	Use:   "audit",
+	Short: "Inspect the log of actions taken on Moltbook",
+	Long: `Every request that changes something on Moltbook (registering, updating
+the profile, posting, commenting, voting and deleting) is appended to
+audit.jsonl in the config directory, with the agent, a hash of the request,
+the ID in the response and what triggered it: manual, heartbeat,
+policy:<rule> or approval:<id>.
+
+Each entry holds the hash of the one before, so editing, removing or
+reordering entries is detected by 'moltgo audit verify'. Removing entries from
+the end is only detected against a head hash recorded earlier.`,
+}
+
+var auditVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Check the audit log's hash chain",
+	Args:  cobra.NoArgs,
+	RunE:  runAuditVerify,
+}
+
+var auditShowCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show recent audit log entries",
+	Args:  cobra.NoArgs,
+	RunE:  runAuditShow,
+}
+
+func init() {
+	rootCmd.AddCommand(auditCmd)
+	auditCmd.AddCommand(auditVerifyCmd, auditShowCmd)
+
+	auditShowCmd.Flags().IntVarP(&auditLimit, "limit", "l", 20, "Number of entries to show, newest last (0 for all)")
+	auditShowCmd.Flags().StringVar(&auditAction, "action", "", "Only show this action, such as post or vote")
+	auditShowCmd.Flags().StringVar(&auditTrigger, "trigger", "", "Only show entries with this trigger, such as heartbeat")
+}
+
+// auditLog opens the audit log in the config directory for appending
+func auditLog() (*audit.Log, error) {
+	path, err := config.GetAuditPath()
+	if err != nil {
+		return nil, err
+	}
+	return audit.Open(path)
+}
+
+func runAuditVerify(cmd *cobra.Command, args []string) error {
+	path, err := config.GetAuditPath()
+	if err != nil {
+		return err
+	}
+
+	n, err := audit.Verify(path)
+	if err != nil {
+		return fmt.Errorf("audit log is not intact after %d good entries: %w", n, err)
+	}
+	if n == 0 {
+		fmt.Fprintln(deps.Out, "Audit log is empty.")
+		return nil
+	}
+
+	entries, err := audit.Read(path)
+	if err != nil {
+		return err
+	}
+	head := entries[len(entries)-1]
+	fmt.Fprintf(deps.Out, "Audit log intact: %d entries\n", n)
+	fmt.Fprintf(deps.Out, "  Head: %s (entry %d, %s)\n", head.Hash, head.Seq, localTime(head.Time))
+	return nil
+}
+
+func runAuditShow(cmd *cobra.Command, args []string) error {
+	path, err := config.GetAuditPath()
+	if err != nil {
+		return err
+	}
+	entries, err := audit.Read(path)
+	if err != nil {
+		return err
+	}
+
+	var shown []audit.Entry
+	for _, e := range entries {
+		if (auditAction == "" || e.Action == auditAction) && (auditTrigger == "" || e.Trigger == auditTrigger) {
+			shown = append(shown, e)
+		}
+	}
+	if len(shown) == 0 {
+		fmt.Fprintln(deps.Out, "No audit log entries.")
+		return nil
+	}
+	if auditLimit > 0 && len(shown) > auditLimit {
+		shown = shown[len(shown)-auditLimit:]
+	}
+
+	for _, e := range shown {
+		fmt.Fprintf(deps.Out, "[%d] %s %s", e.Seq, e.Action, e.Endpoint)
+		if e.ResponseID != "" {
+			fmt.Fprintf(deps.Out, " -> %s", e.ResponseID)
+		}
+		fmt.Fprintln(deps.Out)
+		fmt.Fprintf(deps.Out, "    %s | %s | %s | status %d\n", localTime(e.Time), e.Profile, e.Trigger, e.Status)
+		if e.Error != "" {
+			fmt.Fprintf(deps.Out, "    error: %s\n", e.Error)
+		}
+	}
+	return nil
+}
@@ -1,16 +1,19 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 
-	"github.com/moltgo/moltgo/pkg/config"
 	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/render"
 	"github.com/spf13/cobra"
 )
 
 var (
 	browseSubmolt string
 	browseLimit   int
+	browseFull    bool
 )
 
 var browseCmd = &cobra.Command{
@@ -25,15 +28,26 @@ func init() {
 
 	browseCmd.Flags().StringVarP(&browseSubmolt, "submolt", "s", "", "Filter by submolt (community)")
 	browseCmd.Flags().IntVarP(&browseLimit, "limit", "l", 10, "Number of posts to retrieve")
+	browseCmd.Flags().BoolVarP(&browseFull, "full", "f", false, "Show whole posts through $PAGER")
 }
 
 func runBrowse(cmd *cobra.Command, args []string) error {
-	cfg, err := config.LoadCredentials()
+	cfg, err := deps.Config.LoadCredentials()
 	if err != nil {
 		return err
 	}
 
-	client := moltbook.NewClient(cfg.APIKey)
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	r := render.New(deps.Out)
+
+	var out io.Writer = deps.Out
+	var buf bytes.Buffer
+	if browseFull {
+		out = &buf
+	}
 
 	req := &moltbook.BrowsePostsRequest{
 		Submolt: browseSubmolt,
@@ -41,40 +55,32 @@ func runBrowse(cmd *cobra.Command, args []string) error {
 	}
 
 	if browseSubmolt != "" {
-		fmt.Printf("Browsing posts from /%s...\n\n", browseSubmolt)
+		fmt.Fprintf(deps.Out, "Browsing posts from /%s...\n\n", browseSubmolt)
 	} else {
-		fmt.Println("Browsing recent posts...")
+		fmt.Fprintln(deps.Out, "Browsing recent posts...")
 	}
 
-	posts, err := client.BrowsePosts(req)
+	posts, err := client.BrowsePosts(cmd.Context(), req)
 	if err != nil {
 		return fmt.Errorf("failed to browse posts: %w", err)
 	}
+	indexPosts(posts)
 
 	if len(posts) == 0 {
-		fmt.Println("No posts found.")
+		fmt.Fprintln(deps.Out, "No posts found.")
 		return nil
 	}
 
 	for i, post := range posts {
-		fmt.Printf("[%d] %s\n", i+1, post.Title)
-		fmt.Printf("    by %s in /%s\n", post.Author, post.Submolt)
-		fmt.Printf("    Score: %d | Comments: %d\n", post.Score, post.NumComments)
-		if post.Content != "" {
-			content := post.Content
-			if len(content) > 100 {
-				content = content[:100] + "..."
-			}
-			fmt.Printf("    %s\n", content)
-		}
-		if post.URL != "" {
-			fmt.Printf("    URL: %s\n", post.URL)
-		}
-		fmt.Printf("    ID: %s | Posted: %s\n", post.ID, post.CreatedAt)
-		fmt.Println()
+		printPost(out, r, i+1, post, browseFull)
+		fmt.Fprintf(out, "    ID: %s | Posted: %s\n", post.ID, localTime(post.CreatedAt))
+		fmt.Fprintln(out)
 	}
 
-	fmt.Printf("Total posts retrieved: %d\n", len(posts))
+	fmt.Fprintf(out, "Total posts retrieved: %d\n", len(posts))
 
+	if browseFull {
+		return render.Page(buf.String(), deps.Out)
+	}
 	return nil
 }
@@ -0,0 +1,725 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/moltgo/moltgo/pkg/approval"
+	"github.com/moltgo/moltgo/pkg/audit"
+	"github.com/moltgo/moltgo/pkg/compose"
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/timestamp"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// testNow is the fake clock's time in every command test
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// testAPIKey is long enough for status to show its prefix
+const testAPIKey = "moltbook_sk_test_0123456789abcdef"
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	// Times are shown in local time and listings wrap to the terminal
+	time.Local = time.UTC
+	os.Setenv("COLUMNS", "80")
+	os.Exit(m.Run())
+}
+
+// fakeConfig serves a fixed configuration. Without one, there are no
+// credentials.
+type fakeConfig struct {
+	cfg *config.Config
+}
+
+func (f *fakeConfig) LoadCredentials() (*config.Config, error) {
+	if f.cfg == nil || f.cfg.APIKey == "" {
+		return nil, config.ErrNoCredentials
+	}
+	cfg := *f.cfg
+	return &cfg, nil
+}
+
+func (f *fakeConfig) LoadSettings() (*config.Config, error) {
+	if f.cfg == nil {
+		return &config.Config{}, nil
+	}
+	cfg := *f.cfg
+	return &cfg, nil
+}
+
+// SaveCredentials keeps the configuration for later runs of the test
+func (f *fakeConfig) SaveCredentials(cfg *config.Config) error {
+	saved := *cfg
+	f.cfg = &saved
+	return nil
+}
+
+// fakeState keeps the state in memory and counts saves
+type fakeState struct {
+	state *config.State
+	saves int
+}
+
+func (f *fakeState) Load() (*config.State, error) {
+	if f.state == nil {
+		f.state = &config.State{}
+	}
+	return f.state, nil
+}
+
+func (f *fakeState) Save(state *config.State) error {
+	f.state = state
+	f.saves++
+	return nil
+}
+
+// fakeAPI serves fixed posts and comments and logs every call
+type fakeAPI struct {
+	profile  *moltbook.Agent
+	posts    []moltbook.Post
+	comments map[string][]moltbook.Comment
+
+	// errs fails the named methods
+	errs map[string]error
+
+	calls []string
+}
+
+func (a *fakeAPI) call(method string, args ...interface{}) error {
+	parts := make([]string, len(args))
+	for i, arg := range args {
+		parts[i] = fmt.Sprintf("%+v", arg)
+	}
+	a.calls = append(a.calls, method+"("+strings.Join(parts, ", ")+")")
+	return a.errs[method]
+}
+
+func (a *fakeAPI) Register(_ context.Context, name, description string) (*moltbook.RegisterResponse, error) {
+	if err := a.call("Register", name, description); err != nil {
+		return nil, err
+	}
+	return &moltbook.RegisterResponse{
+		Success:          true,
+		AgentID:          "agent_new",
+		APIKey:           "moltbook_sk_new_0123456789abcdef",
+		ClaimURL:         "https://www.moltbook.com/claim/moltbook_claim_new",
+		VerificationCode: "reef-X4B2",
+	}, nil
+}
+
+func (a *fakeAPI) GetProfile(context.Context) (*moltbook.Agent, error) {
+	if err := a.call("GetProfile"); err != nil {
+		return nil, err
+	}
+	if a.profile == nil {
+		return &moltbook.Agent{}, nil
+	}
+	return a.profile, nil
+}
+
+func (a *fakeAPI) UpdateProfile(_ context.Context, req *moltbook.UpdateProfileRequest) (*moltbook.Agent, error) {
+	if err := a.call("UpdateProfile", *req); err != nil {
+		return nil, err
+	}
+	return &moltbook.Agent{Description: req.Description}, nil
+}
+
+func (a *fakeAPI) BrowsePosts(_ context.Context, req *moltbook.BrowsePostsRequest) ([]moltbook.Post, error) {
+	if err := a.call("BrowsePosts", *req); err != nil {
+		return nil, err
+	}
+	var posts []moltbook.Post
+	for _, p := range a.posts {
+		if req.Submolt == "" || p.Submolt == req.Submolt {
+			posts = append(posts, p)
+		}
+	}
+	if req.Limit > 0 && len(posts) > req.Limit {
+		posts = posts[:req.Limit]
+	}
+	return posts, nil
+}
+
+func (a *fakeAPI) GetPost(_ context.Context, postID string) (*moltbook.Post, error) {
+	if err := a.call("GetPost", postID); err != nil {
+		return nil, err
+	}
+	for _, p := range a.posts {
+		if p.ID == postID {
+			return &p, nil
+		}
+	}
+	return nil, &moltbook.APIError{Status: 404, Message: "Post not found"}
+}
+
+func (a *fakeAPI) GetComments(_ context.Context, postID, sort string) ([]moltbook.Comment, error) {
+	if err := a.call("GetComments", postID, sort); err != nil {
+		return nil, err
+	}
+	return a.comments[postID], nil
+}
+
+func (a *fakeAPI) Search(_ context.Context, query string) ([]moltbook.Post, error) {
+	if err := a.call("Search", query); err != nil {
+		return nil, err
+	}
+	var posts []moltbook.Post
+	for _, p := range a.posts {
+		if strings.Contains(strings.ToLower(p.Title+" "+p.Content), strings.ToLower(query)) {
+			posts = append(posts, p)
+		}
+	}
+	return posts, nil
+}
+
+func (a *fakeAPI) CreatePost(_ context.Context, req *moltbook.CreatePostRequest) (*moltbook.Post, error) {
+	if err := a.call("CreatePost", *req); err != nil {
+		return nil, err
+	}
+	return &moltbook.Post{ID: "post_new", Submolt: req.Submolt, Title: req.Title, Content: req.Content, URL: req.URL}, nil
+}
+
+func (a *fakeAPI) CreateComment(ctx context.Context, postID string, content string) (*moltbook.Comment, error) {
+	return a.CreateReply(ctx, postID, "", content)
+}
+
+func (a *fakeAPI) CreateReply(_ context.Context, postID, parentID, content string) (*moltbook.Comment, error) {
+	if err := a.call("CreateReply", postID, parentID, content); err != nil {
+		return nil, err
+	}
+	return &moltbook.Comment{ID: "comment_new", PostID: postID, ParentID: parentID, Content: content}, nil
+}
+
+func (a *fakeAPI) Vote(_ context.Context, targetType, targetID, direction string) error {
+	return a.call("Vote", targetType, targetID, direction)
+}
+
+// resetFlags puts every flag of c and its subcommands back to its default,
+// since flag values live in package variables shared by all runs
+func resetFlags(c *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		if s, ok := f.Value.(pflag.SliceValue); ok {
+			s.Replace(nil)
+		} else {
+			f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	}
+	c.Flags().VisitAll(reset)
+	c.PersistentFlags().VisitAll(reset)
+	for _, sub := range c.Commands() {
+		resetFlags(sub)
+	}
+}
+
+func testPosts() []moltbook.Post {
+	return []moltbook.Post{
+		{ID: "post_1", Submolt: "golang", Title: "Generics in practice", Content: "Where do type parameters pay off?",
+			Author: "gopher", Score: 12, NumComments: 2, CreatedAt: timestamp.New(testNow.Add(-3 * time.Hour))},
+		{ID: "post_2", Submolt: "general", Title: "Error wrapping", URL: "https://go.dev/blog/go1.13-errors",
+			Author: "wrapper", Score: 4, CreatedAt: timestamp.New(testNow.Add(-26 * time.Hour))},
+	}
+}
+
+func testConfig() *config.Config {
+	return &config.Config{APIKey: testAPIKey, AgentName: "TestAgent"}
+}
+
+// writeFile writes a file at the config path get returns, which is under
+// the test's home directory
+func writeFile(t *testing.T, get func() (string, error), content string) {
+	t.Helper()
+	path, err := get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+const testPolicy = `
+[feed]
+sort = "new"
+
+[[rule]]
+name = "generics"
+action = "upvote"
+keywords = ["generics"]
+
+[[rule]]
+name = "errors"
+action = "comment"
+submolts = ["general"]
+comment = "Wrapping with %w keeps errors inspectable."
+`
+
+// queueRequests holds a post and a vote for approval, as an unattended
+// run would
+func queueRequests(t *testing.T) {
+	t.Helper()
+	path, err := config.GetApprovalsPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = approval.Update(path, func(q *approval.Queue) error {
+		post := approval.Request{Kind: approval.KindPost, Source: "heartbeat"}
+		if err := post.SetBody(&moltbook.CreatePostRequest{Submolt: "golang", Title: "Table tests", Content: "Every package deserves them."}); err != nil {
+			return err
+		}
+		q.Add(post, testNow.Add(-2*time.Hour))
+
+		vote := approval.Request{Kind: approval.KindVote, Source: "heartbeat"}
+		if err := vote.SetBody(&moltbook.VoteRequest{TargetType: "post", TargetID: "post_2", Direction: "up"}); err != nil {
+			return err
+		}
+		q.Add(vote, testNow.Add(-time.Hour))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeAuditLog logs a manual post and a failed heartbeat vote
+func writeAuditLog(t *testing.T) {
+	t.Helper()
+	path, err := config.GetAuditPath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, err := audit.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries := []audit.Entry{
+		{Time: timestamp.New(testNow.Add(-time.Hour)), Profile: "TestAgent", Action: "post", Endpoint: "/posts",
+			PayloadHash: audit.HashPayload([]byte(`{"title":"Hello"}`)), ResponseID: "post_9", Status: 201, Trigger: audit.TriggerManual},
+		{Time: timestamp.New(testNow), Profile: "TestAgent", Action: "vote", Endpoint: "/posts/post_2/upvote",
+			PayloadHash: audit.HashPayload(nil), Status: 429, Error: "rate limited", Trigger: audit.TriggerHeartbeat},
+	}
+	for _, e := range entries {
+		if _, err := log.Append(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+type cmdTest struct {
+	name string
+
+	// runs are the command lines run in turn, without the program name
+	runs [][]string
+
+	cfg   *config.Config
+	state *config.State
+	api   *fakeAPI
+	stdin string
+
+	// setup writes files, such as the approval queue, under the test's
+	// home directory before the first run
+	setup func(t *testing.T)
+}
+
+var cmdTests = []cmdTest{
+	{
+		name: "browse",
+		runs: [][]string{{"browse", "--limit", "5"}},
+		cfg:  testConfig(),
+		api:  &fakeAPI{posts: testPosts()},
+	},
+	{
+		name: "browse_submolt_empty",
+		runs: [][]string{{"browse", "--submolt", "rust"}},
+		cfg:  testConfig(),
+		api:  &fakeAPI{posts: testPosts()},
+	},
+	{
+		name: "browse_control_characters",
+		runs: [][]string{{"browse"}},
+		cfg:  testConfig(),
+		api: &fakeAPI{posts: []moltbook.Post{
+			{ID: "post_3", Submolt: "general", Title: "Clean\x1b]0;owned\x07 title", Author: "evil\x1b[2J", Content: "Body\x9b31m"},
+		}},
+	},
+	{
+		name: "browse_server_error",
+		runs: [][]string{{"browse"}},
+		cfg:  testConfig(),
+		api:  &fakeAPI{errs: map[string]error{"BrowsePosts": &moltbook.APIError{Status: 503, Message: "Service unavailable"}}},
+	},
+	{
+		name: "browse_rate_limited_json",
+		runs: [][]string{{"browse", "--output", "json"}},
+		cfg:  testConfig(),
+		api:  &fakeAPI{errs: map[string]error{"BrowsePosts": &moltbook.RateLimitError{RetryAfter: 42 * time.Second}}},
+	},
+	{
+		name: "browse_no_credentials",
+		runs: [][]string{{"browse"}},
+		api:  &fakeAPI{},
+	},
+	{
+		name: "browse_then_local_search",
+		runs: [][]string{{"browse"}, {"search", "--local", "generics"}},
+		cfg:  testConfig(),
+		api:  &fakeAPI{posts: testPosts()},
+	},
+	{
+		name: "search",
+		runs: [][]string{{"search", "error", "wrapping"}},
+		cfg:  testConfig(),
+		api:  &fakeAPI{posts: testPosts()},
+	},
+	{
+		name: "search_filter_without_local",
+		runs: [][]string{{"search", "go", "--author", "gopher"}},
+		cfg:  testConfig(),
+		api:  &fakeAPI{},
+	},
+	{
+		name: "status",
+		runs: [][]string{{"status"}},
+		cfg:  testConfig(),
+		state: &config.State{
+			PostsCreated:      3,
+			CommentsCreated:   7,
+			LastMoltbookCheck: timestamp.New(testNow.Add(-90 * time.Minute)),
+			LastPostTime:      timestamp.New(testNow.Add(-2 * time.Hour)),
+		},
+		api: &fakeAPI{profile: &moltbook.Agent{ID: "agent_1", Name: "TestAgent", Description: "Testing\x1b[31m agent"}},
+	},
+	{
+		name: "status_not_registered",
+		runs: [][]string{{"status"}},
+		api:  &fakeAPI{},
+	},
+	{
+		name: "comment",
+		runs: [][]string{{"comment", "--post", "post_1", "--text", "Nice **post**", "--yes"}},
+		cfg:  testConfig(),
+		api:  &fakeAPI{posts: testPosts()},
+	},
+	{
+		name:  "comment_from_stdin",
+		runs:  [][]string{{"comment", "--post", "post_1", "--text-file", "-"}},
+		cfg:   testConfig(),
+		api:   &fakeAPI{posts: testPosts()},
+		stdin: "Read from stdin\n",
+	},
+	{
+		name:  "comment_rate_limited",
+		runs:  [][]string{{"comment", "--post", "post_1", "--text", "Too soon", "--yes"}},
+		cfg:   testConfig(),
+		state: &config.State{LastCommentTime: timestamp.New(testNow.Add(-5 * time.Second))},
+		api:   &fakeAPI{},
+	},
+	{
+		name: "comment_missing_post",
+		runs: [][]string{{"comment", "--text", "Hi"}},
+		cfg:  testConfig(),
+		api:  &fakeAPI{},
+	},
+	{
+		name: "comment_failed",
+		runs: [][]string{{"comment", "--post", "post_9", "--text", "Hi", "--yes"}},
+		cfg:  testConfig(),
+		api:  &fakeAPI{errs: map[string]error{"CreateReply": &moltbook.APIError{Status: 404, Message: "Post not found"}}},
+	},
+	{
+		name: "comment_dry_run",
+		runs: [][]string{{"comment", "--post", "post_1", "--text", "Just looking", "--yes", "--dry-run"}},
+		cfg:  testConfig(),
+		api:  &fakeAPI{},
+	},
+	{
+		name: "draft_add_and_list",
+		runs: [][]string{
+			{"draft", "add", "--submolt", "golang", "--title", "Later", "--content", "Body", "--schedule", "2026-03-02T09:00"},
+			{"draft", "add", "--submolt", "golang", "--title", "Urgent", "--content", "Body", "--priority", "5"},
+			{"draft", "add", "--submolt", "general", "--title", "Whenever", "--url", "https://example.com"},
+			{"draft", "list"},
+		},
+		api: &fakeAPI{},
+	},
+	{
+		name: "draft_schedule",
+		runs: [][]string{
+			{"draft", "add", "--submolt", "golang", "--title", "Scheduled", "--content", "Body"},
+			{"draft", "publish", "--schedule", "2026-03-01T18:30"},
+			{"draft", "list"},
+		},
+		api: &fakeAPI{},
+	},
+	{
+		name: "draft_publish",
+		runs: [][]string{
+			{"draft", "add", "--submolt", "golang", "--title", "Weekly notes", "--content", "What we learned this week"},
+			{"draft", "publish"},
+			{"draft", "list"},
+		},
+		cfg: testConfig(),
+		api: &fakeAPI{},
+	},
+	{
+		name: "post_duplicate",
+		runs: [][]string{
+			{"post", "--submolt", "golang", "--title", "Generics in practice", "--content", "Where do type parameters pay off?", "--yes"},
+			{"post", "--submolt", "golang", "--title", "Generics in practice", "--content", "Where do type parameters pay off?", "--yes", "--allow-duplicate"},
+		},
+		cfg: testConfig(),
+		api: &fakeAPI{posts: testPosts()},
+	},
+	{
+		name: "post_idempotent_retry",
+		runs: [][]string{{"post", "--submolt", "golang", "--title", "Weekly notes", "--content", "What we learned", "--yes", "--idempotency-key", "weekly-1"}},
+		cfg:  testConfig(),
+		// An earlier attempt got no answer, but its post is there
+		state: &config.State{SentPosts: []config.SentPost{{Key: "weekly-1", Submolt: "golang", Title: "Weekly notes", Content: "What we learned"}}},
+		api: &fakeAPI{posts: []moltbook.Post{
+			{ID: "post_7", Submolt: "golang", Title: "Weekly notes", Content: "What we learned", Author: "TestAgent"},
+		}},
+	},
+	{
+		name: "register",
+		runs: [][]string{{"register", "--name", "NewAgent", "--description", "Fresh from the reef"}, {"status"}},
+		api:  &fakeAPI{profile: &moltbook.Agent{Name: "NewAgent"}},
+	},
+	{
+		name: "register_dry_run",
+		runs: [][]string{{"--dry-run", "register"}, {"status"}},
+		api:  &fakeAPI{},
+	},
+	{
+		name: "update",
+		runs: [][]string{{"update", "--description", "Now with tests"}, {"update"}},
+		cfg:  testConfig(),
+		api:  &fakeAPI{},
+	},
+	{
+		name:  "heartbeat_policy",
+		runs:  [][]string{{"--dry-run", "heartbeat"}, {"heartbeat"}, {"heartbeat"}},
+		cfg:   testConfig(),
+		api:   &fakeAPI{posts: testPosts()},
+		setup: func(t *testing.T) { writeFile(t, config.GetPolicyPath, testPolicy) },
+	},
+	{
+		name:  "approve",
+		runs:  [][]string{{"approve", "list"}, {"approve", "accept", "1", "--as", "reviewer"}, {"approve", "reject", "2", "--as", "reviewer", "--note", "not this one"}, {"approve", "list", "--all"}},
+		cfg:   testConfig(),
+		api:   &fakeAPI{posts: testPosts()},
+		setup: queueRequests,
+	},
+	{
+		name: "run_invalid_config",
+		runs: [][]string{{"run"}},
+		cfg:  &config.Config{APIKey: testAPIKey, AgentName: "TestAgent", Daemon: config.DaemonConfig{HeartbeatInterval: "hourly"}},
+		api:  &fakeAPI{},
+	},
+	{
+		name: "archive",
+		runs: [][]string{{"archive", "sync", "--submolt", "golang", "--rate", "60000"}, {"archive", "status"}, {"archive", "show", "post_1"}, {"search", "--local", "generics"}},
+		cfg:  testConfig(),
+		api: &fakeAPI{posts: testPosts(), comments: map[string][]moltbook.Comment{
+			"post_1": {{ID: "comment_1", Author: "reviewer", Content: "Mostly in containers.", Score: 2, CreatedAt: timestamp.New(testNow.Add(-2 * time.Hour))}},
+		}},
+	},
+	{
+		name:  "audit",
+		runs:  [][]string{{"audit", "show"}, {"audit", "show", "--trigger", "heartbeat"}, {"audit", "verify"}},
+		api:   &fakeAPI{},
+		setup: writeAuditLog,
+	},
+	{
+		name: "tui_not_terminal",
+		runs: [][]string{{"tui"}},
+		cfg:  testConfig(),
+		api:  &fakeAPI{},
+	},
+	{
+		name: "invalid_log_level",
+		runs: [][]string{{"browse", "--log-level", "loud"}},
+		api:  &fakeAPI{},
+	},
+	{
+		name: "post_missing_content",
+		runs: [][]string{{"post", "--submolt", "general", "--title", "Empty", "--yes"}},
+		cfg:  testConfig(),
+		api:  &fakeAPI{},
+	},
+	{
+		name: "draft_add_invalid",
+		runs: [][]string{{"draft", "add", "--submolt", "general", "--content", "No title"}, {"draft", "add", "--title", "x", "--content", "y", "--schedule", "someday"}},
+		api:  &fakeAPI{},
+	},
+	{
+		name: "unknown_flag",
+		runs: [][]string{{"browse", "--nope"}},
+		api:  &fakeAPI{},
+	},
+}
+
+func TestCommands(t *testing.T) {
+	for _, tt := range cmdTests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			for _, name := range []string{"MOLTBOOK_API_URL", "MOLTGO_CASSETTE", "MOLTGO_STRICT", "EDITOR", "PAGER"} {
+				t.Setenv(name, "")
+			}
+
+			if tt.setup != nil {
+				tt.setup(t)
+			}
+			got := runCommands(t, &tt)
+			got = strings.ReplaceAll(got, home, "$HOME")
+			checkGolden(t, filepath.Join("testdata", tt.name+".golden"), got)
+		})
+	}
+}
+
+// runCommands runs each of the test's command lines against fakes and
+// returns a transcript of their output, exit codes, API calls and state
+// saves
+func runCommands(t *testing.T, tt *cmdTest) string {
+	t.Helper()
+	var transcript strings.Builder
+	cfg := &fakeConfig{cfg: tt.cfg}
+	state := &fakeState{state: cloneState(t, tt.state)}
+	api := tt.api
+
+	saved := deps
+	t.Cleanup(func() { deps = saved })
+
+	for _, args := range tt.runs {
+		var stdout, stderr bytes.Buffer
+		deps = &Deps{
+			Config: cfg,
+			State:  state,
+			NewClient: func(cfg *config.Config, unattended bool) (moltbook.API, error) {
+				api.calls = append(api.calls, fmt.Sprintf("NewClient(unattended=%v)", unattended))
+				return api, nil
+			},
+			In:  strings.NewReader(tt.stdin),
+			Out: &stdout,
+			Err: &stderr,
+			Now: func() time.Time { return testNow },
+		}
+		resetFlags(rootCmd)
+		rootCmd.SetArgs(args)
+		rootCmd.SetOut(&stdout)
+		rootCmd.SetErr(&stderr)
+
+		api.calls = nil
+		saves := state.saves
+		err := Execute()
+		if err != nil {
+			PrintError(err)
+		}
+
+		fmt.Fprintf(&transcript, "$ moltgo %s\n", strings.Join(args, " "))
+		fmt.Fprintf(&transcript, "-- stdout --\n%s", stdout.String())
+		fmt.Fprintf(&transcript, "-- stderr --\n%s", stderr.String())
+		fmt.Fprintf(&transcript, "-- api --\n")
+		for _, call := range api.calls {
+			fmt.Fprintln(&transcript, call)
+		}
+		fmt.Fprintf(&transcript, "-- exit %d, state saved %d time(s) --\n\n", ExitCode(err), state.saves-saves)
+	}
+	return transcript.String()
+}
+
+// cloneState copies a test's starting state through its file encoding, since
+// runs change the state they load in place
+func cloneState(t *testing.T, state *config.State) *config.State {
+	t.Helper()
+	if state == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(state); err != nil {
+		t.Fatal(err)
+	}
+	clone := &config.State{}
+	if _, err := toml.Decode(buf.String(), clone); err != nil {
+		t.Fatal(err)
+	}
+	return clone
+}
+
+// checkGolden compares got with the golden file at path, or rewrites the
+// file with -update
+func checkGolden(t *testing.T, path, got string) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("golden file %s does not exist; run go test ./cmd -update to create it", path)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s (run go test ./cmd -update to accept it):\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
+
+func TestResetFlags(t *testing.T) {
+	browseCmd.Flags().Set("limit", "3")
+	rootCmd.PersistentFlags().Set("dry-run", "true")
+	resetFlags(rootCmd)
+
+	if browseLimit != 10 || browseCmd.Flags().Changed("limit") {
+		t.Errorf("browse --limit = %d after reset, want 10", browseLimit)
+	}
+	if dryRun {
+		t.Errorf("--dry-run still set after reset")
+	}
+}
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errors.New("boom"), 1},
+		{usageError{errors.New("bad flag")}, 2},
+		{fmt.Errorf("failed to save draft: %w", (&compose.PostDraft{Submolt: "general"}).Validate()), 2},
+		{fmt.Errorf("wrapped: %w", config.ErrNoCredentials), 3},
+		{&moltbook.RateLimitError{RetryAfter: time.Second}, 4},
+		{&moltbook.APIError{Status: 404}, 5},
+		{&moltbook.APIError{Status: 422}, 2},
+		{&moltbook.APIError{Status: 502}, 7},
+	}
+	for _, tt := range tests {
+		if got := ExitCode(tt.err); got != tt.want {
+			t.Errorf("ExitCode(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
@@ -1,64 +1,140 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
+	"github.com/moltgo/moltgo/pkg/approval"
+	"github.com/moltgo/moltgo/pkg/compose"
 	"github.com/moltgo/moltgo/pkg/config"
 	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/render"
 	"github.com/spf13/cobra"
 )
 
 var (
-	commentPostID string
-	commentText   string
+	commentPostID   string
+	commentText     string
+	commentTextFile string
+	commentYes      bool
 )
 
 var commentCmd = &cobra.Command{
 	Use:   "comment",
 	Short: "Comment on a post",
-	Long:  `Add a comment to an existing post on Moltbook.`,
-	RunE:  runComment,
+	Long: `Add a comment to an existing post on Moltbook.
+
+The comment can be given inline with --text, read from a file with
+--text-file, or from stdin with --text-file -. When no text is given, $EDITOR
+is opened. The rendered comment is shown for confirmation before it is sent;
+use --yes to skip the prompt.`,
+	RunE: runComment,
 }
 
 func init() {
 	rootCmd.AddCommand(commentCmd)
 
 	commentCmd.Flags().StringVarP(&commentPostID, "post", "p", "", "Post ID to comment on (required)")
-	commentCmd.Flags().StringVarP(&commentText, "text", "t", "", "Comment text (required)")
+	commentCmd.Flags().StringVarP(&commentText, "text", "t", "", "Comment text")
+	commentCmd.Flags().StringVarP(&commentTextFile, "text-file", "F", "", "Read comment text from a file (- for stdin)")
+	commentCmd.Flags().BoolVarP(&commentYes, "yes", "y", false, "Send without asking for confirmation")
 
 	commentCmd.MarkFlagRequired("post")
-	commentCmd.MarkFlagRequired("text")
+	commentCmd.MarkFlagsMutuallyExclusive("text", "text-file")
 }
 
 func runComment(cmd *cobra.Command, args []string) error {
-	cfg, err := config.LoadCredentials()
+	cfg, err := deps.Config.LoadCredentials()
 	if err != nil {
 		return err
 	}
 
-	state, err := config.LoadState()
+	state, err := deps.State.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load state: %w", err)
 	}
 
-	client := moltbook.NewClient(cfg.APIKey)
+	// Check rate limit (1 comment per 20 seconds, 50 per day)
+	if err := state.CheckComment(deps.Now()); err != nil {
+		return err
+	}
+
+	text, err := composeComment()
+	if err != nil {
+		return err
+	}
 
-	fmt.Printf("Adding comment to post %s...\n", commentPostID)
+	r := render.New(deps.Out)
+	previewComment(deps.Out, r, text)
+	if !commentYes && interactive() && !confirm("Send this comment?") {
+		fmt.Fprintln(deps.Out, "Comment not sent.")
+		return nil
+	}
 
-	comment, err := client.CreateComment(commentPostID, commentText)
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	return submitComment(cmd.Context(), client, state, commentPostID, text)
+}
+
+// submitComment creates the comment and records it in the state
+func submitComment(ctx context.Context, client moltbook.API, state *config.State, postID, text string) error {
+	fmt.Fprintf(deps.Out, "Adding comment to post %s...\n", postID)
+
+	comment, err := client.CreateComment(ctx, postID, text)
+	if approval.IsHeld(err) {
+		fmt.Fprintf(deps.Out, "Comment %v\n", err)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create comment: %w", err)
 	}
 
-	fmt.Println("Comment added successfully!")
-	fmt.Printf("  ID: %s\n", comment.ID)
-	fmt.Printf("  Content: %s\n", comment.Content)
+	fmt.Fprintln(deps.Out, "Comment added successfully!")
+	fmt.Fprintf(deps.Out, "  ID: %s\n", comment.ID)
+	fmt.Fprintf(deps.Out, "  Content: %s\n", comment.Content)
 
 	// Update state
-	state.CommentsCreated++
-	if err := config.SaveState(state); err != nil {
-		fmt.Printf("Warning: failed to save state: %v\n", err)
+	state.RecordComment(deps.Now())
+	if err := saveState(state); err != nil {
+		fmt.Fprintf(deps.Out, "Warning: failed to save state: %v\n", err)
 	}
 
 	return nil
 }
+
+// composeComment reads the comment from flags, a file or stdin, or the
+// user's editor
+func composeComment() (string, error) {
+	text := commentText
+	if commentTextFile != "" {
+		content, err := compose.ReadSource(commentTextFile, deps.In)
+		if err != nil {
+			return "", err
+		}
+		text = content
+	}
+
+	if strings.TrimSpace(text) == "" {
+		if !interactive() {
+			return "", usageError{fmt.Errorf("must provide either --text or --text-file")}
+		}
+		edited, err := compose.Edit(compose.CommentTemplate(commentPostID, ""))
+		if err != nil {
+			return "", err
+		}
+		text, err = compose.ParseComment(edited)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", fmt.Errorf("aborting comment due to empty text")
+	}
+	return text, nil
+}
@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+)
+
+// Deps is everything commands take from outside the process: configuration
+// and state, the Moltbook API, the terminal and the clock. Commands go
+// through deps instead of reaching for them directly, so they can be run
+// against fakes.
+type Deps struct {
+	Config ConfigStore
+	State  StateStore
+
+	// NewClient creates the API client for a command. Unattended clients
+	// hold content for approval when the configuration asks for it.
+	NewClient func(cfg *config.Config, unattended bool) (moltbook.API, error)
+
+	In  io.Reader
+	Out io.Writer
+	Err io.Writer
+
+	Now func() time.Time
+}
+
+// ConfigStore loads configuration
+type ConfigStore interface {
+	// LoadCredentials loads configuration, failing without an API key
+	LoadCredentials() (*config.Config, error)
+	// LoadSettings loads configuration without requiring credentials
+	LoadSettings() (*config.Config, error)
+	// SaveCredentials saves the API key and agent name, keeping the rest of
+	// the configuration file
+	SaveCredentials(cfg *config.Config) error
+}
+
+// StateStore loads and saves the agent's state
+type StateStore interface {
+	Load() (*config.State, error)
+	Save(state *config.State) error
+}
+
+// deps are the dependencies commands use
+var deps *Deps
+
+func init() {
+	deps = DefaultDeps()
+}
+
+// DefaultDeps returns the real dependencies: files in the config directory,
+// the Moltbook API, the process's standard streams and the system clock
+func DefaultDeps() *Deps {
+	return &Deps{
+		Config:    fileConfig{},
+		State:     fileState{},
+		NewClient: newAPIClient,
+		In:        os.Stdin,
+		Out:       os.Stdout,
+		Err:       os.Stderr,
+		Now:       time.Now,
+	}
+}
+
+// fileConfig is configuration from the environment, .env and config.toml
+type fileConfig struct{}
+
+func (fileConfig) LoadCredentials() (*config.Config, error) { return config.LoadCredentials() }
+func (fileConfig) LoadSettings() (*config.Config, error)    { return config.LoadSettings() }
+func (fileConfig) SaveCredentials(cfg *config.Config) error { return config.SaveCredentials(cfg) }
+
+// fileState is state.toml
+type fileState struct{}
+
+func (fileState) Load() (*config.State, error)   { return config.LoadState() }
+func (fileState) Save(state *config.State) error { return config.SaveState(state) }
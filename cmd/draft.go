@@ -0,0 +1,457 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/approval"
+	"github.com/moltgo/moltgo/pkg/compose"
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/drafts"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/timestamp"
+	"github.com/spf13/cobra"
+)
+
+var (
+	draftSubmolt     string
+	draftTitle       string
+	draftContent     string
+	draftContentFile string
+	draftURL         string
+	draftPriority    int
+	draftSchedule    string
+	draftAllowDup    bool
+)
+
+var draftCmd = &cobra.Command{
+	Use:   "draft",
+	Short: "Manage locally stored draft posts",
+	Long: `Store posts locally and publish them later. Drafts are published in
+priority order (highest first) once their scheduled time has passed and the
+post rate limit allows. Drafts that fail to publish are retried with an
+increasing backoff.
+
+The heartbeat publishes the next due draft automatically.`,
+}
+
+var draftAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Store a new draft",
+	Long: `Store a new draft post. Content is taken from --content, --content-file
+(- for stdin), or $EDITOR when neither content nor a URL is given.`,
+	Args: cobra.NoArgs,
+	RunE: runDraftAdd,
+}
+
+var draftListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List drafts in publishing order",
+	Args:  cobra.NoArgs,
+	RunE:  runDraftList,
+}
+
+var draftEditCmd = &cobra.Command{
+	Use:   "edit <id>",
+	Short: "Edit a draft",
+	Long: `Edit a draft. With --priority or --schedule only those are changed;
+otherwise the draft is opened in $EDITOR.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runDraftEdit,
+}
+
+var draftRmCmd = &cobra.Command{
+	Use:   "rm <id>",
+	Short: "Delete a draft",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDraftRm,
+}
+
+var draftPublishCmd = &cobra.Command{
+	Use:   "publish [id]",
+	Short: "Publish or schedule a draft",
+	Long: `Publish a draft now, or schedule it with --schedule.
+
+Without an ID, the next due draft is published, or with --schedule the next
+unscheduled draft is scheduled. Schedules accept "2006-01-02T15:04" in local
+time or RFC 3339.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDraftPublish,
+}
+
+func init() {
+	rootCmd.AddCommand(draftCmd)
+	draftCmd.AddCommand(draftAddCmd, draftListCmd, draftEditCmd, draftRmCmd, draftPublishCmd)
+
+	draftAddCmd.Flags().StringVarP(&draftSubmolt, "submolt", "s", "", "Submolt (community) to post in")
+	draftAddCmd.Flags().StringVarP(&draftTitle, "title", "t", "", "Post title")
+	draftAddCmd.Flags().StringVarP(&draftContent, "content", "c", "", "Post content (text)")
+	draftAddCmd.Flags().StringVarP(&draftContentFile, "content-file", "F", "", "Read post content from a file (- for stdin)")
+	draftAddCmd.Flags().StringVarP(&draftURL, "url", "u", "", "Post URL (link)")
+	draftAddCmd.MarkFlagsMutuallyExclusive("content", "content-file")
+
+	for _, c := range []*cobra.Command{draftAddCmd, draftEditCmd} {
+		c.Flags().IntVarP(&draftPriority, "priority", "p", 0, "Publishing priority (higher goes first)")
+		c.Flags().StringVar(&draftSchedule, "schedule", "", "Publish no earlier than this time")
+	}
+	draftPublishCmd.Flags().StringVar(&draftSchedule, "schedule", "", "Schedule instead of publishing now")
+	draftPublishCmd.Flags().BoolVar(&draftAllowDup, "allow-duplicate", false, "Publish even if the post looks like a duplicate")
+}
+
+func loadDrafts() (*drafts.Queue, string, error) {
+	path, err := config.GetDraftsPath()
+	if err != nil {
+		return nil, "", err
+	}
+	queue, err := drafts.Load(path)
+	if err != nil {
+		return nil, "", err
+	}
+	return queue, path, nil
+}
+
+func parseDraftID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, usageError{fmt.Errorf("invalid draft ID %q", arg)}
+	}
+	return id, nil
+}
+
+func parseScheduleFlag() (timestamp.Time, error) {
+	if draftSchedule == "" {
+		return timestamp.Time{}, nil
+	}
+	at, err := drafts.ParseSchedule(draftSchedule)
+	if err != nil {
+		return timestamp.Time{}, usageError{err}
+	}
+	return timestamp.New(at), nil
+}
+
+func runDraftAdd(cmd *cobra.Command, args []string) error {
+	path, err := config.GetDraftsPath()
+	if err != nil {
+		return err
+	}
+
+	publishAt, err := parseScheduleFlag()
+	if err != nil {
+		return err
+	}
+
+	post, err := composePost(&compose.PostDraft{
+		Submolt: draftSubmolt,
+		Title:   draftTitle,
+		URL:     draftURL,
+		Content: draftContent,
+	}, draftContentFile)
+	if err != nil {
+		return err
+	}
+
+	return addDraft(path, drafts.Draft{
+		Submolt:   post.Submolt,
+		Title:     post.Title,
+		URL:       post.URL,
+		Content:   post.Content,
+		Priority:  draftPriority,
+		PublishAt: publishAt,
+	})
+}
+
+// addDraft stores a new draft in the queue at path
+func addDraft(path string, d drafts.Draft) error {
+	err := drafts.Update(path, func(queue *drafts.Queue) error {
+		d = *queue.Add(d, deps.Now())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(deps.Out, "Draft %d saved: %s\n", d.ID, d.Title)
+	return nil
+}
+
+func runDraftList(cmd *cobra.Command, args []string) error {
+	queue, _, err := loadDrafts()
+	if err != nil {
+		return err
+	}
+
+	if len(queue.Drafts) == 0 {
+		fmt.Fprintln(deps.Out, "No drafts.")
+		return nil
+	}
+
+	now := deps.Now()
+	for _, d := range queue.Sorted() {
+		fmt.Fprintf(deps.Out, "[%d] %s\n", d.ID, d.Title)
+		fmt.Fprintf(deps.Out, "    in /%s | Priority: %d | %s\n", d.Submolt, d.Priority, draftStatus(&d, now))
+		if d.LastError != "" {
+			fmt.Fprintf(deps.Out, "    Failed %d time(s): %s\n", d.Attempts, d.LastError)
+		}
+		fmt.Fprintln(deps.Out)
+	}
+
+	fmt.Fprintf(deps.Out, "Total drafts: %d\n", len(queue.Drafts))
+	return nil
+}
+
+func draftStatus(d *drafts.Draft, now time.Time) string {
+	if d.Due(now) {
+		return "Due"
+	}
+	if retry := d.RetryAt(); now.Before(retry) {
+		return "Retry at " + localTime(timestamp.New(retry))
+	}
+	return "Scheduled for " + localTime(d.PublishAt)
+}
+
+func runDraftEdit(cmd *cobra.Command, args []string) error {
+	id, err := parseDraftID(args[0])
+	if err != nil {
+		return err
+	}
+
+	queue, path, err := loadDrafts()
+	if err != nil {
+		return err
+	}
+
+	d := queue.Get(id)
+	if d == nil {
+		return fmt.Errorf("draft %d not found", id)
+	}
+
+	flags := cmd.Flags()
+	var publishAt timestamp.Time
+	if flags.Changed("schedule") {
+		if publishAt, err = parseScheduleFlag(); err != nil {
+			return err
+		}
+	}
+
+	// The editor runs without the lock; the draft may be published or
+	// deleted meanwhile
+	var post *compose.PostDraft
+	if !flags.Changed("priority") && !flags.Changed("schedule") {
+		current := &compose.PostDraft{Submolt: d.Submolt, Title: d.Title, URL: d.URL, Content: d.Content}
+		edited, err := compose.Edit(current.Template())
+		if err != nil {
+			return err
+		}
+		if post, err = compose.ParsePostDraft(edited); err != nil {
+			return err
+		}
+		if err := post.Validate(); err != nil {
+			return err
+		}
+	}
+
+	err = drafts.Update(path, func(queue *drafts.Queue) error {
+		d := queue.Get(id)
+		if d == nil {
+			return fmt.Errorf("draft %d was published or deleted while editing; edit discarded", id)
+		}
+		if flags.Changed("priority") {
+			d.Priority = draftPriority
+		}
+		if flags.Changed("schedule") {
+			d.PublishAt = publishAt
+		}
+		if post != nil {
+			d.Submolt, d.Title, d.URL, d.Content = post.Submolt, post.Title, post.URL, post.Content
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(deps.Out, "Draft %d updated.\n", id)
+	return nil
+}
+
+func runDraftRm(cmd *cobra.Command, args []string) error {
+	id, err := parseDraftID(args[0])
+	if err != nil {
+		return err
+	}
+
+	path, err := config.GetDraftsPath()
+	if err != nil {
+		return err
+	}
+
+	err = drafts.Update(path, func(queue *drafts.Queue) error {
+		if !queue.Remove(id) {
+			return fmt.Errorf("draft %d not found", id)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(deps.Out, "Draft %d deleted.\n", id)
+	return nil
+}
+
+func runDraftPublish(cmd *cobra.Command, args []string) error {
+	path, err := config.GetDraftsPath()
+	if err != nil {
+		return err
+	}
+
+	// 0 publishes the next due draft
+	id := 0
+	if len(args) == 1 {
+		if id, err = parseDraftID(args[0]); err != nil {
+			return err
+		}
+	}
+
+	if draftSchedule != "" {
+		publishAt, err := parseScheduleFlag()
+		if err != nil {
+			return err
+		}
+		err = drafts.Update(path, func(queue *drafts.Queue) error {
+			if id == 0 {
+				for _, candidate := range queue.Sorted() {
+					if candidate.PublishAt.IsZero() {
+						id = candidate.ID
+						break
+					}
+				}
+				if id == 0 {
+					return fmt.Errorf("no unscheduled drafts")
+				}
+			}
+			d := queue.Get(id)
+			if d == nil {
+				return fmt.Errorf("draft %d not found", id)
+			}
+			d.PublishAt = publishAt
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(deps.Out, "Draft %d scheduled for %s\n", id, localTime(publishAt))
+		return nil
+	}
+
+	cfg, err := deps.Config.LoadCredentials()
+	if err != nil {
+		return err
+	}
+
+	state, err := deps.State.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	err = publishDraft(cmd.Context(), deps.Out, path, id, client, cfg, state, deps.Now(), draftAllowDup)
+	if errors.Is(err, errNoDraftsDue) {
+		fmt.Fprintln(deps.Out, "No drafts are due.")
+		return nil
+	}
+	return err
+}
+
+// errNoDraftsDue is returned by publishDraft when asked for the next due
+// draft and none is due
+var errNoDraftsDue = errors.New("no drafts are due")
+
+// updateDrafts applies fn to the drafts queue at path under its lock, or,
+// in a dry run, to a copy that is not saved
+func updateDrafts(path string, fn func(queue *drafts.Queue) error) error {
+	if !dryRun {
+		return drafts.Update(path, fn)
+	}
+	queue, err := drafts.Load(path)
+	if err != nil {
+		return err
+	}
+	return fn(queue)
+}
+
+// publishDraft publishes the queued draft with the given ID, or with 0 the
+// next due draft, if the post rate limit allows and it does not duplicate
+// an existing post, unless allowDuplicate is set. The attempt is recorded
+// in the queue before the post is sent and its outcome after, each under
+// the queue's lock, and a published post is recorded in state. A duplicate
+// counts as a failed attempt, so the draft is held back before it is
+// checked again.
+func publishDraft(ctx context.Context, w io.Writer, path string, id int, client moltbook.API, cfg *config.Config, state *config.State, now time.Time, allowDuplicate bool) error {
+	if err := state.CheckPost(now); err != nil {
+		return err
+	}
+
+	var d drafts.Draft
+	err := updateDrafts(path, func(queue *drafts.Queue) error {
+		if id == 0 {
+			next := queue.NextDue(now)
+			if next == nil {
+				return errNoDraftsDue
+			}
+			id = next.ID
+		}
+		begun, err := queue.Begin(id, now)
+		if err != nil {
+			return err
+		}
+		d = *begun
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Publishing draft %d...\n", id)
+	draft := &compose.PostDraft{Submolt: d.Submolt, Title: d.Title, URL: d.URL, Content: d.Content}
+	var post *moltbook.Post
+	err = checkDuplicate(ctx, w, client, cfg, state, draft, allowDuplicate)
+	if err == nil {
+		post, err = client.CreatePost(ctx, d.Request())
+	}
+
+	if saveErr := updateDrafts(path, func(queue *drafts.Queue) error {
+		queue.Finish(id, err)
+		return nil
+	}); saveErr != nil {
+		fmt.Fprintf(w, "Warning: failed to save drafts: %v\n", saveErr)
+	}
+	if approval.IsHeld(err) {
+		fmt.Fprintf(w, "Draft %d %v\n", id, err)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to publish draft %d: %w", id, err)
+	}
+
+	fmt.Fprintln(w, "Post created successfully!")
+	fmt.Fprintf(w, "  ID: %s\n", post.ID)
+	fmt.Fprintf(w, "  Title: %s\n", post.Title)
+	fmt.Fprintf(w, "  Submolt: /%s\n", post.Submolt)
+
+	// Remembered like posts sent by the post command, so a later post or
+	// draft with the same content is caught as a duplicate
+	state.RecordPost(post.ID, now)
+	state.RememberPost(sentPost(draft, "", post.ID), now)
+	if err := saveState(state); err != nil {
+		fmt.Fprintf(w, "Warning: failed to save state: %v\n", err)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/drafts"
+)
+
+func TestPublishedDraftIsRemembered(t *testing.T) {
+	saved := deps
+	t.Cleanup(func() { deps = saved })
+	deps = &Deps{State: &fakeState{}, Out: io.Discard, Now: func() time.Time { return testNow }}
+
+	path := filepath.Join(t.TempDir(), "drafts.toml")
+	addNotes := func() {
+		t.Helper()
+		if err := addDraft(path, drafts.Draft{Submolt: "golang", Title: "Weekly notes", Content: "What we learned this week"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	api := &fakeAPI{}
+	cfg := testConfig()
+	state := &config.State{}
+
+	addNotes()
+	if err := publishDraft(context.Background(), io.Discard, path, 0, api, cfg, state, testNow, false); err != nil {
+		t.Fatal(err)
+	}
+	if sent := state.SentPosts; len(sent) != 1 || sent[0].ID != "post_new" {
+		t.Fatalf("sent posts = %+v, want the published draft", sent)
+	}
+
+	// The same draft again, once the rate limit allows, is refused and kept
+	addNotes()
+	later := testNow.Add(time.Hour)
+	err := publishDraft(context.Background(), io.Discard, path, 0, api, cfg, state, later, false)
+	if err == nil || !strings.Contains(err.Error(), "duplicate of our post") {
+		t.Fatalf("publishing the same draft again: %v, want a duplicate error", err)
+	}
+	queue, err := drafts.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queue.Drafts) != 1 || queue.Drafts[0].Attempts != 1 || !strings.Contains(queue.Drafts[0].LastError, "duplicate") {
+		t.Errorf("drafts = %+v, want the duplicate kept with its error", queue.Drafts)
+	}
+	if queue.NextDue(later) != nil {
+		t.Errorf("refused draft is due again at once")
+	}
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/moltgo/moltgo/pkg/compose"
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/safety"
+)
+
+var (
+	outputFormat string
+
+	// commandStarted is set once flags and arguments have been accepted;
+	// errors before then are usage errors
+	commandStarted bool
+
+	// failedCommand is the command an error came from
+	failedCommand string
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "text", "Error format: text, or json for a JSON object on stderr")
+}
+
+// Kinds of errors, each with its own exit code
+const (
+	kindError      = "error"
+	kindValidation = "validation"
+	kindAuth       = "auth"
+	kindRateLimit  = "rate_limit"
+	kindNotFound   = "not_found"
+	kindNetwork    = "network"
+	kindServer     = "server"
+)
+
+// exitCodes are the documented exit codes of each kind of error
+var exitCodes = map[string]int{
+	kindError:      1,
+	kindValidation: 2,
+	kindAuth:       3,
+	kindRateLimit:  4,
+	kindNotFound:   5,
+	kindNetwork:    6,
+	kindServer:     7,
+}
+
+// usageError is an error in the command line itself
+type usageError struct {
+	err error
+}
+
+func (e usageError) Error() string { return e.err.Error() }
+func (e usageError) Unwrap() error { return e.err }
+
+// errorKind classifies an error by the typed errors it wraps
+func errorKind(err error) string {
+	var usage usageError
+	var invalid *compose.ValidationError
+	var blocked *safety.BlockedError
+	var rateLimited *moltbook.RateLimitError
+	var apiErr *moltbook.APIError
+	var netErr net.Error
+
+	switch {
+	case errors.As(err, &usage), errors.As(err, &invalid), errors.As(err, &blocked):
+		return kindValidation
+	case errors.Is(err, config.ErrNoCredentials):
+		return kindAuth
+	case errors.Is(err, config.ErrRateLimited), errors.As(err, &rateLimited):
+		return kindRateLimit
+	case errors.As(err, &apiErr):
+		return statusKind(apiErr.Status)
+	case errors.As(err, &netErr):
+		return kindNetwork
+	}
+	return kindError
+}
+
+// statusKind classifies an error status from the API
+func statusKind(status int) string {
+	switch {
+	case status == http.StatusUnauthorized, status == http.StatusForbidden:
+		return kindAuth
+	case status == http.StatusNotFound:
+		return kindNotFound
+	case status == http.StatusTooManyRequests:
+		return kindRateLimit
+	case status >= 500:
+		return kindServer
+	case status >= 400:
+		return kindValidation
+	}
+	return kindError
+}
+
+// ExitCode returns the exit code for an error returned by Execute: 0 for
+// none, and otherwise the code of its kind
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	return exitCodes[errorKind(err)]
+}
+
+// errorReport is the JSON object an error is reported as
+type errorReport struct {
+	Error      string `json:"error"`
+	Kind       string `json:"kind"`
+	ExitCode   int    `json:"exit_code"`
+	Command    string `json:"command,omitempty"`
+	Status     int    `json:"status,omitempty"`
+	Hint       string `json:"hint,omitempty"`
+	RetryAfter int    `json:"retry_after_seconds,omitempty"`
+}
+
+// PrintError reports an error returned by Execute on stderr, as text or,
+// with --output json, as a JSON object
+func PrintError(err error) {
+	kind := errorKind(err)
+	if outputFormat != "json" {
+		fmt.Fprintf(deps.Err, "Error: %v\n", err)
+		if kind == kindValidation && !commandStarted {
+			fmt.Fprintf(deps.Err, "Run '%s --help' for usage.\n", failedCommand)
+		}
+		return
+	}
+
+	report := errorReport{
+		Error:    err.Error(),
+		Kind:     kind,
+		ExitCode: exitCodes[kind],
+		Command:  failedCommand,
+	}
+	var apiErr *moltbook.APIError
+	if errors.As(err, &apiErr) {
+		report.Status = apiErr.Status
+		report.Hint = apiErr.Hint
+	}
+	var rateLimited *moltbook.RateLimitError
+	if errors.As(err, &rateLimited) {
+		report.Status = http.StatusTooManyRequests
+		report.RetryAfter = int(rateLimited.RetryAfter.Seconds())
+	}
+	json.NewEncoder(deps.Err).Encode(report)
+}
+
+// checkOutputFormat validates --output
+func checkOutputFormat() error {
+	if outputFormat != "text" && outputFormat != "json" {
+		return usageError{fmt.Errorf("invalid --output %q: want text or json", outputFormat)}
+	}
+	return nil
+}
@@ -0,0 +1,316 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/moltgo/moltgo/pkg/compose"
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/drafts"
+	"github.com/moltgo/moltgo/pkg/injection"
+	"github.com/moltgo/moltgo/pkg/llm"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/render"
+	"github.com/moltgo/moltgo/pkg/tracing"
+	"github.com/spf13/cobra"
+)
+
+var (
+	generateSubmolt   string
+	generateTopic     string
+	generatePostID    string
+	generateYes       bool
+	generateSaveDraft bool
+	generateAllowDup  bool
+)
+
+var generateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Draft posts and comments with a language model",
+	Long: `Generate content with an OpenAI-compatible model server (llama.cpp, Ollama,
+vLLM or a hosted API) configured in the [llm] section of config.toml:
+
+  [llm]
+  base_url = "http://localhost:11434/v1"
+  model = "llama3"
+  persona = "You are a Go enthusiast who shares practical tips."
+
+The API key can also be set with MOLTGO_LLM_API_KEY. Generated content is
+shown for review and only sent once you accept it, or with --yes.`,
+}
+
+var generatePostCmd = &cobra.Command{
+	Use:   "post",
+	Short: "Generate a new post for a submolt",
+	Args:  cobra.NoArgs,
+	RunE:  runGeneratePost,
+}
+
+var generateCommentCmd = &cobra.Command{
+	Use:   "comment",
+	Short: "Generate a comment for a post from its thread",
+	Args:  cobra.NoArgs,
+	RunE:  runGenerateComment,
+}
+
+func init() {
+	rootCmd.AddCommand(generateCmd)
+	generateCmd.AddCommand(generatePostCmd, generateCommentCmd)
+
+	generateCmd.PersistentFlags().BoolVarP(&generateYes, "yes", "y", false, "Send without review")
+
+	generatePostCmd.Flags().StringVarP(&generateSubmolt, "submolt", "s", "general", "Submolt (community) to post in")
+	generatePostCmd.Flags().StringVar(&generateTopic, "topic", "", "What the post should be about")
+	generatePostCmd.Flags().BoolVar(&generateSaveDraft, "save-draft", false, "Store the post in the drafts queue instead of sending it")
+	generatePostCmd.Flags().BoolVar(&generateAllowDup, "allow-duplicate", false, "Send even if the post looks like a duplicate")
+
+	generateCommentCmd.Flags().StringVarP(&generatePostID, "post", "p", "", "Post ID to comment on (required)")
+	generateCommentCmd.MarkFlagRequired("post")
+}
+
+func newLLMBackend(cfg *config.Config) llm.Backend {
+	return llm.NewOpenAI(cfg.LLM.Endpoint(), cfg.LLM.Key(), cfg.LLM.ModelName(), cfg.LLM.RequestTimeout())
+}
+
+func complete(ctx context.Context, backend llm.Backend, cfg *config.Config, what string, messages []llm.Message) (string, error) {
+	fmt.Fprintf(deps.Out, "Generating with %s...\n", cfg.LLM.ModelName())
+	return generateText(ctx, backend, cfg, what, messages)
+}
+
+// generateText has the model complete messages, traced as a span of ctx's
+// trace; what names the content, "post" or "comment"
+func generateText(ctx context.Context, backend llm.Backend, cfg *config.Config, what string, messages []llm.Message) (out string, err error) {
+	ctx, span := tracing.Start(ctx, "generate "+what,
+		slog.String("gen_ai.operation.name", "chat"),
+		slog.String("gen_ai.request.model", cfg.LLM.ModelName()))
+	defer func() {
+		span.Fail(err)
+		span.End()
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.LLM.RequestTimeout())
+	defer cancel()
+
+	out, err = backend.Complete(ctx, &llm.Request{
+		Messages:    messages,
+		Temperature: cfg.LLM.Temperature,
+		MaxTokens:   cfg.LLM.MaxTokens,
+	})
+	if err != nil {
+		return "", fmt.Errorf("generation failed: %w", err)
+	}
+	return llm.StripFences(out), nil
+}
+
+func runGeneratePost(cmd *cobra.Command, args []string) error {
+	cfg, err := deps.Config.LoadCredentials()
+	if err != nil {
+		return err
+	}
+
+	state, err := deps.State.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	if !generateSaveDraft {
+		if err := state.CheckPost(deps.Now()); err != nil {
+			return fmt.Errorf("%w (use --save-draft to queue the post)", err)
+		}
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	backend := newLLMBackend(cfg)
+
+	recent, err := client.BrowsePosts(cmd.Context(), &moltbook.BrowsePostsRequest{
+		Submolt: generateSubmolt,
+		Sort:    moltbook.SortNew,
+		Limit:   10,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to browse posts: %w", err)
+	}
+
+	generate := func() (*compose.PostDraft, error) {
+		out, err := complete(cmd.Context(), backend, cfg, "post", llm.PostMessages(cfg.LLM.Persona, generateSubmolt, generateTopic, recent))
+		if err != nil {
+			return nil, err
+		}
+		draft, err := compose.ParsePostDraft(out)
+		if err != nil {
+			return nil, fmt.Errorf("model returned an unusable post: %w", err)
+		}
+		draft.Submolt = generateSubmolt
+		if draft.Title == "" {
+			return nil, fmt.Errorf("model returned a post without a title")
+		}
+		return draft, nil
+	}
+
+	draft, err := generate()
+	if err != nil {
+		return err
+	}
+
+	r := render.New(deps.Out)
+	in := bufio.NewReader(deps.In)
+	for {
+		previewPost(deps.Out, r, draft)
+
+		choice := "s"
+		if !generateYes {
+			if !interactive() {
+				fmt.Fprintln(deps.Out, "Post not sent (use --yes to send it or --save-draft to queue it).")
+				return nil
+			}
+			choice = reviewChoice(in)
+		}
+
+		switch choice {
+		case "s":
+			if err := draft.Validate(); err != nil {
+				return err
+			}
+			if generateSaveDraft {
+				return saveGeneratedDraft(draft)
+			}
+			return submitPost(cmd.Context(), client, cfg, state, draft, "", generateAllowDup)
+		case "e":
+			edited, err := compose.Edit(draft.Template())
+			if err != nil {
+				return err
+			}
+			if draft, err = compose.ParsePostDraft(edited); err != nil {
+				return err
+			}
+		case "r":
+			if draft, err = generate(); err != nil {
+				return err
+			}
+		default:
+			fmt.Fprintln(deps.Out, "Post discarded.")
+			return nil
+		}
+	}
+}
+
+func saveGeneratedDraft(post *compose.PostDraft) error {
+	path, err := config.GetDraftsPath()
+	if err != nil {
+		return err
+	}
+	return addDraft(path, drafts.Draft{
+		Submolt: post.Submolt,
+		Title:   post.Title,
+		URL:     post.URL,
+		Content: post.Content,
+	})
+}
+
+func runGenerateComment(cmd *cobra.Command, args []string) error {
+	cfg, err := deps.Config.LoadCredentials()
+	if err != nil {
+		return err
+	}
+
+	state, err := deps.State.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	if err := state.CheckComment(deps.Now()); err != nil {
+		return err
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	backend := newLLMBackend(cfg)
+
+	post, err := client.GetPost(cmd.Context(), generatePostID)
+	if err != nil {
+		return fmt.Errorf("failed to get post: %w", err)
+	}
+	comments, err := client.GetComments(cmd.Context(), generatePostID, moltbook.SortTop)
+	if err != nil {
+		return fmt.Errorf("failed to get comments: %w", err)
+	}
+
+	if report := injection.AnalyzePost(post); report.Flagged() {
+		fmt.Fprintf(deps.Out, "Warning: this post may be a prompt injection attempt (%s); review the comment carefully\n", strings.Join(report.Kinds(), ", "))
+	}
+	comments, dropped := injection.DropFlagged(comments)
+	if dropped > 0 {
+		fmt.Fprintf(deps.Out, "Leaving %d flagged comment(s) out of the prompt\n", dropped)
+	}
+
+	generate := func() (string, error) {
+		return complete(cmd.Context(), backend, cfg, "comment", llm.CommentMessages(cfg.LLM.Persona, post, comments))
+	}
+
+	text, err := generate()
+	if err != nil {
+		return err
+	}
+
+	r := render.New(deps.Out)
+	in := bufio.NewReader(deps.In)
+	for {
+		previewComment(deps.Out, r, text)
+
+		choice := "s"
+		if !generateYes {
+			if !interactive() {
+				fmt.Fprintln(deps.Out, "Comment not sent (use --yes to send it).")
+				return nil
+			}
+			choice = reviewChoice(in)
+		}
+
+		switch choice {
+		case "s":
+			if strings.TrimSpace(text) == "" {
+				return fmt.Errorf("aborting comment due to empty text")
+			}
+			return submitComment(cmd.Context(), client, state, generatePostID, text)
+		case "e":
+			edited, err := compose.Edit(compose.CommentTemplate(generatePostID, text))
+			if err != nil {
+				return err
+			}
+			if text, err = compose.ParseComment(edited); err != nil {
+				return err
+			}
+		case "r":
+			if text, err = generate(); err != nil {
+				return err
+			}
+		default:
+			fmt.Fprintln(deps.Out, "Comment discarded.")
+			return nil
+		}
+	}
+}
+
+// reviewChoice asks what to do with generated content: s(end), e(dit),
+// r(egenerate) or q(uit). in is read for every choice of a command run, so
+// answers it has buffered are not lost between prompts.
+func reviewChoice(in *bufio.Reader) string {
+	fmt.Fprint(deps.Out, "[s]end, [e]dit, [r]egenerate or [q]uit? ")
+	answer, err := in.ReadString('\n')
+	if err != nil {
+		return "q"
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	if answer == "" {
+		return "q"
+	}
+	return answer[:1]
+}
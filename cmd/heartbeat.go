@@ -1,45 +1,112 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"time"
 
+	"github.com/moltgo/moltgo/pkg/audit"
 	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/injection"
 	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/render"
+	"github.com/moltgo/moltgo/pkg/timestamp"
+	"github.com/moltgo/moltgo/pkg/tracing"
 	"github.com/spf13/cobra"
 )
 
+var (
+	heartbeatSkipDrafts bool
+	heartbeatPolicy     string
+)
+
 var heartbeatCmd = &cobra.Command{
 	Use:   "heartbeat",
 	Short: "Perform periodic check-in with Moltbook",
 	Long: `Perform a heartbeat check-in with Moltbook. This should be run every 4+ hours
-to keep your agent active and engaged with the community.`,
+to keep your agent active and engaged with the community.
+
+If an engagement policy exists (policy.toml in the config directory, or
+--policy), its rules decide which posts to upvote, downvote or comment on.
+Use --dry-run to print the actions the policy would take without taking them.`,
 	RunE: runHeartbeat,
 }
 
 func init() {
 	rootCmd.AddCommand(heartbeatCmd)
+
+	heartbeatCmd.Flags().BoolVar(&heartbeatSkipDrafts, "skip-drafts", false, "Do not publish due drafts")
+	heartbeatCmd.Flags().StringVar(&heartbeatPolicy, "policy", "", "Engagement policy file (default policy.toml in the config directory)")
+}
+
+// heartbeatOptions controls what a heartbeat does besides checking in
+type heartbeatOptions struct {
+	PublishDrafts bool
+	DryRun        bool
+
+	// PolicyPath is the engagement policy; a missing default policy file
+	// disables the policy engine
+	PolicyPath string
 }
 
 func runHeartbeat(cmd *cobra.Command, args []string) error {
-	cfg, err := config.LoadCredentials()
+	cfg, err := deps.Config.LoadCredentials()
 	if err != nil {
 		return err
 	}
 
-	state, err := config.LoadState()
+	client, err := newUnattendedClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	opts := heartbeatOptions{
+		PublishDrafts: !heartbeatSkipDrafts,
+		DryRun:        dryRun,
+		PolicyPath:    heartbeatPolicy,
+	}
+	if err := heartbeat(cmd.Context(), deps.Out, cfg, client, opts); err != nil {
+		return err
+	}
+
+	// Show next check time
+	nextCheck := deps.Now().Add(cfg.Daemon.HeartbeatEvery())
+	fmt.Fprintf(deps.Out, "\nNext heartbeat recommended: %s\n", nextCheck.Format("2006-01-02 15:04:05"))
+
+	return nil
+}
+
+// heartbeat performs one check-in: it browses recent posts, applies the
+// engagement policy, publishes the next due draft, and records the check
+// time. It is traced as a span of ctx's trace, or as a trace of its own.
+func heartbeat(ctx context.Context, w io.Writer, cfg *config.Config, client moltbook.API, opts heartbeatOptions) (err error) {
+	start := time.Now()
+	ctx = audit.WithTrigger(ctx, audit.TriggerHeartbeat)
+	ctx, span := tracing.Start(ctx, "heartbeat", slog.Bool("moltgo.dry_run", opts.DryRun))
+	defer func() {
+		span.Fail(err)
+		span.End()
+		observeHeartbeat(start, err)
+	}()
+	state, err := deps.State.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load state: %w", err)
 	}
 
-	client := moltbook.NewClient(cfg.APIKey)
+	pol, err := loadPolicy(opts.PolicyPath)
+	if err != nil {
+		return err
+	}
 
-	now := time.Now()
-	fmt.Printf("Heartbeat check at %s\n\n", now.Format("2006-01-02 15:04:05"))
+	now := deps.Now()
+	fmt.Fprintf(w, "Heartbeat check at %s\n\n", now.Format("2006-01-02 15:04:05"))
 
 	// Browse recent posts
-	fmt.Println("Browsing recent posts...")
-	posts, err := client.BrowsePosts(&moltbook.BrowsePostsRequest{
+	fmt.Fprintln(w, "Browsing recent posts...")
+	posts, err := client.BrowsePosts(ctx, &moltbook.BrowsePostsRequest{
 		Limit: 5,
 	})
 	if err != nil {
@@ -47,31 +114,76 @@ func runHeartbeat(cmd *cobra.Command, args []string) error {
 	}
 
 	if len(posts) > 0 {
-		fmt.Printf("\nFound %d recent posts:\n\n", len(posts))
+		fmt.Fprintf(w, "\nFound %d recent posts:\n\n", len(posts))
 		for i, post := range posts {
 			if i >= 3 { // Show only top 3
 				break
 			}
-			fmt.Printf("  [%d] %s\n", i+1, post.Title)
-			fmt.Printf("      by %s in /%s\n", post.Author, post.Submolt)
-			fmt.Printf("      Score: %d | Comments: %d\n", post.Score, post.NumComments)
-			fmt.Println()
+			fmt.Fprintf(w, "  [%d] %s\n", i+1, render.Sanitize(post.Title))
+			fmt.Fprintf(w, "      by %s in /%s\n", render.Sanitize(post.Author), render.Sanitize(post.Submolt))
+			fmt.Fprintf(w, "      Score: %d | Comments: %d\n", post.Score, post.NumComments)
+			if warning := injectionWarning(injection.AnalyzePost(&post)); warning != "" {
+				fmt.Fprintf(w, "      %s\n", warning)
+			}
+			fmt.Fprintln(w)
 		}
 	} else {
-		fmt.Println("  No posts found.")
+		fmt.Fprintln(w, "  No posts found.")
 	}
 
-	// Update state
-	state.LastMoltbookCheck = now.Format(time.RFC3339)
-	if err := config.SaveState(state); err != nil {
-		fmt.Printf("\nWarning: failed to save state: %v\n", err)
+	if pol != nil {
+		if err := applyPolicy(ctx, w, cfg, client, state, pol, opts.DryRun); err != nil {
+			fmt.Fprintf(w, "Warning: policy evaluation failed: %v\n\n", err)
+		}
 	}
 
-	fmt.Println("Heartbeat complete")
+	// Publish the next due draft when the rate limit allows
+	if opts.PublishDrafts {
+		publishDueDraft(ctx, w, client, cfg, state, now)
+	}
 
-	// Show next check time
-	nextCheck := now.Add(4 * time.Hour)
-	fmt.Printf("\nNext heartbeat recommended: %s\n", nextCheck.Format("2006-01-02 15:04:05"))
+	if opts.DryRun {
+		fmt.Fprintln(w, "Dry run complete (state not updated)")
+		return nil
+	}
+
+	// Update state
+	state.LastMoltbookCheck = timestamp.New(now)
+	if err := saveState(state); err != nil {
+		fmt.Fprintf(w, "\nWarning: failed to save state: %v\n", err)
+	}
+
+	fmt.Fprintln(w, "Heartbeat complete")
 
 	return nil
 }
+
+// publishDueDraft publishes the next due draft, if any. Failures are
+// reported but do not fail the heartbeat; the draft is retried later.
+func publishDueDraft(ctx context.Context, w io.Writer, client moltbook.API, cfg *config.Config, state *config.State, now time.Time) {
+	queue, path, err := loadDrafts()
+	if err != nil {
+		fmt.Fprintf(w, "Warning: failed to load drafts: %v\n", err)
+		return
+	}
+
+	d := queue.NextDue(now)
+	if d == nil {
+		return
+	}
+
+	if wait := state.PostCooldown(now); wait > 0 {
+		fmt.Fprintf(w, "Draft %d is due; waiting %d more minutes for the post rate limit\n\n", d.ID, int(wait.Minutes())+1)
+		return
+	}
+
+	err = publishDraft(ctx, w, path, 0, client, cfg, state, now, false)
+	if errors.Is(err, errNoDraftsDue) {
+		// Another run published it first
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(w, "Warning: %v (will retry)\n", err)
+	}
+	fmt.Fprintln(w)
+}
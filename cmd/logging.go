@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	logLevel  string
+	logFormat string
+	logFile   string
+	debugHTTP bool
+
+	// logger is the structured logger for commands and API clients, set up
+	// from the logging flags before a command runs
+	logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// loggerLevel is the logger's level, which commands may lower when
+	// --log-level was not given
+	loggerLevel slog.LevelVar
+
+	// logOutput is the open --log-file, closed when the command finishes
+	logOutput io.Closer
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&logLevel, "log-level", "error", "Log level: debug, info, warn or error")
+	rootCmd.PersistentFlags().StringVar(&logFormat, "log-format", "text", "Log format: text or json")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "Append logs to this file instead of stderr")
+	rootCmd.PersistentFlags().BoolVar(&debugHTTP, "debug-http", false, "Log every API request with its headers and bodies, credentials redacted (implies --log-level debug)")
+}
+
+// setupLogging builds the logger from the logging flags
+func setupLogging() error {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(logLevel)); err != nil {
+		return usageError{fmt.Errorf("invalid --log-level %q: want debug, info, warn or error", logLevel)}
+	}
+	if debugHTTP {
+		level = slog.LevelDebug
+	}
+
+	out := deps.Err
+	if logFile != "" {
+		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return fmt.Errorf("failed to open log file: %w", err)
+		}
+		out = f
+		logOutput = f
+	}
+
+	loggerLevel.Set(level)
+	opts := &slog.HandlerOptions{Level: &loggerLevel}
+	var handler slog.Handler
+	switch strings.ToLower(logFormat) {
+	case "text":
+		handler = slog.NewTextHandler(out, opts)
+	case "json":
+		handler = slog.NewJSONHandler(out, opts)
+	default:
+		return usageError{fmt.Errorf("invalid --log-format %q: want text or json", logFormat)}
+	}
+
+	logger = slog.New(handler).With("command", invocation)
+	slog.SetDefault(logger)
+	return nil
+}
+
+// defaultLogLevel lowers the log level to level for a command whose log is
+// its output, unless a level was chosen with --log-level or --debug-http
+func defaultLogLevel(cmd *cobra.Command, level slog.Level) {
+	if !cmd.Flags().Changed("log-level") && !debugHTTP {
+		loggerLevel.Set(level)
+	}
+}
+
+// closeLog closes the --log-file, if one was opened
+func closeLog() {
+	if logOutput != nil {
+		logOutput.Close()
+		logOutput = nil
+	}
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/metrics"
+)
+
+var (
+	metricsAddr     string
+	metricsTextfile string
+
+	// metricsServer serves --metrics-addr while a command runs
+	metricsServer *http.Server
+)
+
+var (
+	heartbeatDuration = metrics.Default.Histogram("moltgo_heartbeat_duration_seconds",
+		"Time taken by each heartbeat", []float64{1, 2.5, 5, 10, 30, 60, 120, 300})
+	heartbeats = metrics.Default.Counter("moltgo_heartbeats_total",
+		"Heartbeats by result: ok or error", "result")
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&metricsAddr, "metrics-addr", "", "Serve Prometheus metrics at http://<addr>/metrics while the command runs, e.g. :9464")
+	rootCmd.PersistentFlags().StringVar(&metricsTextfile, "metrics-textfile", "", "Write Prometheus metrics to this file when the command finishes, for node-exporter's textfile collector")
+}
+
+// setupMetrics starts the --metrics-addr listener
+func setupMetrics() error {
+	if metricsAddr == "" {
+		return nil
+	}
+	ln, err := net.Listen("tcp", metricsAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on --metrics-addr: %w", err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Default.Handler())
+	metricsServer = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := metricsServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Warn("metrics server stopped", "error", err)
+		}
+	}()
+	logger.Info("serving metrics", "addr", ln.Addr().String())
+	return nil
+}
+
+// finishMetrics stops the metrics listener and writes --metrics-textfile
+func finishMetrics() {
+	if metricsServer != nil {
+		metricsServer.Close()
+		metricsServer = nil
+	}
+	if metricsTextfile != "" {
+		if err := metrics.Default.WriteTextfile(metricsTextfile); err != nil {
+			fmt.Fprintf(deps.Err, "Warning: %v\n", err)
+		}
+	}
+}
+
+// observeHeartbeat records a heartbeat's duration and result
+func observeHeartbeat(start time.Time, err error) {
+	heartbeatDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		heartbeats.Inc("error")
+	} else {
+		heartbeats.Inc("ok")
+	}
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/moltgo/moltgo/pkg/compose"
+	"github.com/moltgo/moltgo/pkg/injection"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/render"
+	"github.com/moltgo/moltgo/pkg/timestamp"
+)
+
+// previewWidth caps one-line content previews in post listings
+const previewWidth = 100
+
+// printPost writes a numbered post summary to w. With full set, the whole
+// post content is rendered as Markdown instead of a one-line preview.
+func printPost(w io.Writer, r *render.Renderer, n int, post moltbook.Post, full bool) {
+	author, submolt := render.Sanitize(post.Author), render.Sanitize(post.Submolt)
+	fmt.Fprintf(w, "[%d] %s\n", n, r.Style(render.Sanitize(post.Title), render.Bold))
+	if post.CreatedAt.IsZero() {
+		fmt.Fprintf(w, "    by %s in /%s\n", author, submolt)
+	} else {
+		fmt.Fprintf(w, "    by %s in /%s, %s\n", author, submolt, ago(post.CreatedAt))
+	}
+	fmt.Fprintf(w, "    Score: %d | Comments: %d\n", post.Score, post.NumComments)
+	if warning := injectionWarning(injection.AnalyzePost(&post)); warning != "" {
+		fmt.Fprintf(w, "    %s\n", r.Style(warning, render.Yellow))
+	}
+	if post.Content != "" {
+		if full {
+			fmt.Fprintf(w, "\n%s\n\n", r.Markdown(post.Content, "    "))
+		} else {
+			fmt.Fprintf(w, "    %s\n", render.Preview(post.Content, min(previewWidth, r.Width-4)))
+		}
+	}
+	if post.URL != "" {
+		fmt.Fprintf(w, "    URL: %s\n", render.Sanitize(post.URL))
+	}
+}
+
+// injectionWarning describes flagged inbound content, or returns "" if the
+// content was not flagged
+func injectionWarning(report injection.Report) string {
+	if !report.Flagged() {
+		return ""
+	}
+	return "⚠ Possible prompt injection: " + strings.Join(report.Kinds(), ", ")
+}
+
+// previewPost shows a draft the way it will read once posted
+func previewPost(w io.Writer, r *render.Renderer, draft *compose.PostDraft) {
+	rule := r.Style(strings.Repeat("─", min(r.Width, 60)), render.Dim)
+	fmt.Fprintln(w, rule)
+	fmt.Fprintf(w, "%s\n", r.Style(draft.Title, render.Bold))
+	fmt.Fprintf(w, "in /%s\n", draft.Submolt)
+	if draft.URL != "" {
+		fmt.Fprintf(w, "URL: %s\n", draft.URL)
+	}
+	if draft.Content != "" {
+		fmt.Fprintf(w, "\n%s\n", r.Markdown(draft.Content, ""))
+	}
+	fmt.Fprintln(w, rule)
+}
+
+// previewComment shows a comment the way it will read once posted
+func previewComment(w io.Writer, r *render.Renderer, content string) {
+	rule := r.Style(strings.Repeat("─", min(r.Width, 60)), render.Dim)
+	fmt.Fprintln(w, rule)
+	fmt.Fprintln(w, r.Markdown(content, ""))
+	fmt.Fprintln(w, rule)
+}
+
+// localTime shows a time in local time, to the minute
+func localTime(t timestamp.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Time.Local().Format("2006-01-02 15:04")
+}
+
+// ago shows how long before now a time was, such as "3h ago"
+func ago(t timestamp.Time) string {
+	return t.Ago(deps.Now())
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(question string) bool {
+	fmt.Fprintf(deps.Out, "%s [y/N] ", question)
+	answer, err := bufio.NewReader(deps.In).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
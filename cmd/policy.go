@@ -0,0 +1,200 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/approval"
+	"github.com/moltgo/moltgo/pkg/audit"
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/injection"
+	"github.com/moltgo/moltgo/pkg/llm"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/policy"
+	"github.com/moltgo/moltgo/pkg/tracing"
+)
+
+// loadPolicy reads the engagement policy. An explicit path must exist; the
+// default policy file is optional.
+func loadPolicy(path string) (*policy.Policy, error) {
+	if path != "" {
+		return policy.Load(path)
+	}
+
+	path, err := config.GetPolicyPath()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	return policy.Load(path)
+}
+
+// fetchPolicyFeed browses the posts the policy is evaluated against
+func fetchPolicyFeed(ctx context.Context, client moltbook.API, feed policy.Feed) ([]moltbook.Post, error) {
+	limit := feed.Limit
+	if limit <= 0 {
+		limit = 25
+	}
+	sort := feed.Sort
+	if sort == "" {
+		sort = moltbook.SortNew
+	}
+
+	submolts := feed.Submolts
+	if len(submolts) == 0 {
+		submolts = []string{""}
+	}
+
+	var posts []moltbook.Post
+	seen := make(map[string]bool)
+	for _, submolt := range submolts {
+		batch, err := client.BrowsePosts(ctx, &moltbook.BrowsePostsRequest{
+			Submolt: strings.TrimPrefix(submolt, "/"),
+			Sort:    sort,
+			Limit:   limit,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to browse posts: %w", err)
+		}
+		for _, p := range batch {
+			if !seen[p.ID] {
+				seen[p.ID] = true
+				posts = append(posts, p)
+			}
+		}
+	}
+	return posts, nil
+}
+
+// applyPolicy evaluates the policy against the feed and takes the resulting
+// actions, or only prints them in a dry run
+func applyPolicy(ctx context.Context, w io.Writer, cfg *config.Config, client moltbook.API, state *config.State, pol *policy.Policy, dryRun bool) (err error) {
+	ctx, span := tracing.Start(ctx, "policy.evaluate", slog.Bool("moltgo.dry_run", dryRun))
+	defer func() {
+		span.Fail(err)
+		span.End()
+	}()
+
+	posts, err := fetchPolicyFeed(ctx, client, pol.Feed)
+	if err != nil {
+		return err
+	}
+
+	actions := pol.Evaluate(posts, policy.Env{
+		Self: cfg.AgentName,
+		Now:  deps.Now(),
+		Done: state.Acted,
+	})
+	span.SetAttributes(
+		slog.Int("moltgo.policy.posts", len(posts)),
+		slog.Int("moltgo.policy.actions", len(actions)))
+
+	fmt.Fprintf(w, "Policy: %d action(s) from %d posts\n", len(actions), len(posts))
+	if len(actions) == 0 {
+		fmt.Fprintln(w)
+		return nil
+	}
+
+	for _, a := range actions {
+		label := fmt.Sprintf("%s %q by %s (%s)", a.Type, a.Post.Title, a.Post.Author, a.Rule)
+		if dryRun {
+			fmt.Fprintf(w, "  [dry-run] would %s\n", label)
+			if a.Type == policy.ActionComment {
+				if a.Generate {
+					fmt.Fprintln(w, "            text: <generated>")
+				} else {
+					fmt.Fprintf(w, "            text: %s\n", a.Text)
+				}
+			}
+			continue
+		}
+
+		err := takeAction(ctx, cfg, client, state, a)
+		if err != nil && !approval.IsHeld(err) {
+			fmt.Fprintf(w, "  failed to %s: %v\n", label, err)
+			continue
+		}
+		state.RecordAction(a.Key(), deps.Now())
+		if err != nil {
+			fmt.Fprintf(w, "  %s: %v\n", label, err)
+		} else {
+			fmt.Fprintf(w, "  %s\n", label)
+		}
+	}
+	fmt.Fprintln(w)
+	return nil
+}
+
+// takeAction performs a single policy action
+func takeAction(ctx context.Context, cfg *config.Config, client moltbook.API, state *config.State, a policy.Action) (err error) {
+	ctx = audit.WithTrigger(ctx, "policy:"+a.Rule)
+	ctx, span := tracing.Start(ctx, "policy.action",
+		slog.String("moltgo.action", a.Type),
+		slog.String("moltgo.policy.rule", a.Rule),
+		slog.String("moltgo.post_id", a.Post.ID))
+	defer func() {
+		if approval.IsHeld(err) {
+			span.SetAttributes(slog.Bool("moltgo.held", true))
+		} else {
+			span.Fail(err)
+		}
+		span.End()
+	}()
+
+	switch a.Type {
+	case policy.ActionUpvote:
+		return client.Vote(ctx, "post", a.Post.ID, "up")
+	case policy.ActionDownvote:
+		return client.Vote(ctx, "post", a.Post.ID, "down")
+	case policy.ActionComment:
+		// Wait out a short comment cooldown; give up on the daily cap
+		now := deps.Now()
+		if wait := state.CommentCooldown(now); wait > 0 {
+			if wait > config.CommentInterval {
+				return state.CheckComment(now)
+			}
+			// A shutdown must not wait for the cooldown
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+
+		text := a.Text
+		if a.Generate {
+			generated, err := generateComment(ctx, cfg, client, &a.Post)
+			if err != nil {
+				return err
+			}
+			text = generated
+		}
+
+		if _, err := client.CreateComment(ctx, a.Post.ID, text); err != nil {
+			return err
+		}
+		state.RecordComment(deps.Now())
+		return nil
+	}
+	return fmt.Errorf("unknown action %q", a.Type)
+}
+
+// generateComment writes a comment for post with the configured model
+func generateComment(ctx context.Context, cfg *config.Config, client moltbook.API, post *moltbook.Post) (string, error) {
+	comments, err := client.GetComments(ctx, post.ID, moltbook.SortTop)
+	if err != nil {
+		return "", fmt.Errorf("failed to get comments: %w", err)
+	}
+	// Never feed likely injection attempts to the model
+	comments, _ = injection.DropFlagged(comments)
+
+	return generateText(ctx, newLLMBackend(cfg), cfg, "comment", llm.CommentMessages(cfg.LLM.Persona, post, comments))
+}
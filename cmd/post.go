@@ -1,96 +1,298 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
-	"time"
+	"io"
+	"strings"
 
+	"github.com/moltgo/moltgo/pkg/approval"
+	"github.com/moltgo/moltgo/pkg/compose"
 	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/dedupe"
 	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/render"
 	"github.com/spf13/cobra"
 )
 
 var (
-	postSubmolt string
-	postTitle   string
-	postContent string
-	postURL     string
+	postSubmolt     string
+	postTitle       string
+	postContent     string
+	postContentFile string
+	postURL         string
+	postYes         bool
+	postAllowDup    bool
+	postKey         string
 )
 
 var postCmd = &cobra.Command{
 	Use:   "post",
 	Short: "Create a new post on Moltbook",
 	Long: `Create a new post on Moltbook. You must specify a submolt (community),
-title, and either content or a URL.`,
+title, and either content or a URL.
+
+Content can be given inline with --content, read from a file with
+--content-file, or from stdin with --content-file -. When neither content nor
+a URL is given, $EDITOR is opened on a template whose front matter holds the
+submolt, title and url.
+
+The rendered post is shown for confirmation before it is sent; use --yes to
+skip the prompt.
+
+Before sending, the post is compared with our recent posts and with posts in
+the submolt on the same topic. Near-duplicates are refused unless
+--allow-duplicate is given. Give an --idempotency-key to make retries safe:
+a post whose key was already sent is not sent again, and a retry after a
+timeout finds the post if the first attempt went through.`,
 	RunE: runPost,
 }
 
 func init() {
 	rootCmd.AddCommand(postCmd)
 
-	postCmd.Flags().StringVarP(&postSubmolt, "submolt", "s", "", "Submolt (community) to post in (required)")
-	postCmd.Flags().StringVarP(&postTitle, "title", "t", "", "Post title (required)")
+	postCmd.Flags().StringVarP(&postSubmolt, "submolt", "s", "", "Submolt (community) to post in")
+	postCmd.Flags().StringVarP(&postTitle, "title", "t", "", "Post title")
 	postCmd.Flags().StringVarP(&postContent, "content", "c", "", "Post content (text)")
+	postCmd.Flags().StringVarP(&postContentFile, "content-file", "F", "", "Read post content from a file (- for stdin)")
 	postCmd.Flags().StringVarP(&postURL, "url", "u", "", "Post URL (link)")
+	postCmd.Flags().BoolVarP(&postYes, "yes", "y", false, "Send without asking for confirmation")
+	postCmd.Flags().BoolVar(&postAllowDup, "allow-duplicate", false, "Send even if the post looks like a duplicate")
+	postCmd.Flags().StringVar(&postKey, "idempotency-key", "", "Key identifying this post across retries")
 
-	postCmd.MarkFlagRequired("submolt")
-	postCmd.MarkFlagRequired("title")
+	postCmd.MarkFlagsMutuallyExclusive("content", "content-file")
 }
 
 func runPost(cmd *cobra.Command, args []string) error {
-	if postContent == "" && postURL == "" {
-		return fmt.Errorf("must provide either --content or --url")
-	}
-
-	cfg, err := config.LoadCredentials()
+	cfg, err := deps.Config.LoadCredentials()
 	if err != nil {
 		return err
 	}
 
 	// Load and check state for rate limiting
-	state, err := config.LoadState()
+	state, err := deps.State.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load state: %w", err)
 	}
 
-	// Check rate limit (1 post per 30 minutes)
-	if state.LastPostTime != "" {
-		lastPost, err := time.Parse(time.RFC3339, state.LastPostTime)
-		if err == nil {
-			timeSince := time.Since(lastPost)
-			if timeSince < 30*time.Minute {
-				waitTime := 30*time.Minute - timeSince
-				return fmt.Errorf("rate limit: wait %d more minutes before posting", int(waitTime.Minutes())+1)
-			}
-		}
+	// A retry of a post that was already sent
+	if sent := state.SentPostByKey(postKey); sent != nil && sent.ID != "" {
+		fmt.Fprintf(deps.Out, "Post already created with idempotency key %q: %s\n", postKey, sent.ID)
+		return nil
 	}
 
-	client := moltbook.NewClient(cfg.APIKey)
+	// Check rate limit (1 post per 30 minutes)
+	if err := state.CheckPost(deps.Now()); err != nil {
+		return err
+	}
 
-	req := &moltbook.CreatePostRequest{
+	draft, err := composePost(&compose.PostDraft{
 		Submolt: postSubmolt,
 		Title:   postTitle,
-		Content: postContent,
 		URL:     postURL,
+		Content: postContent,
+	}, postContentFile)
+	if err != nil {
+		return err
 	}
 
-	fmt.Printf("Creating post in /%s...\n", postSubmolt)
+	r := render.New(deps.Out)
+	previewPost(deps.Out, r, draft)
+	if !postYes && interactive() && !confirm("Send this post?") {
+		fmt.Fprintln(deps.Out, "Post not sent.")
+		return nil
+	}
 
-	post, err := client.CreatePost(req)
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	// The duplicate lookup is made once. If an earlier attempt with this
+	// key got no answer, a match among our own posts is that attempt, which
+	// went through after all.
+	match := findDuplicate(cmd.Context(), deps.Out, client, cfg, state, draft)
+	if match != nil && match.Own && state.SentPostByKey(postKey) != nil {
+		state.RememberPost(sentPost(draft, postKey, match.Post.ID), deps.Now())
+		if err := saveState(state); err != nil {
+			fmt.Fprintf(deps.Out, "Warning: failed to save state: %v\n", err)
+		}
+		fmt.Fprintf(deps.Out, "Post already created with idempotency key %q: %s\n", postKey, match.Post.ID)
+		return nil
+	}
+	if err := refuseDuplicate(deps.Out, match, postAllowDup); err != nil {
+		return err
+	}
+
+	return createPost(cmd.Context(), client, state, draft, postKey)
+}
+
+// checkDuplicate refuses a post that repeats an existing one, or with
+// allow set only warns about it on w
+func checkDuplicate(ctx context.Context, w io.Writer, client moltbook.API, cfg *config.Config, state *config.State, draft *compose.PostDraft, allow bool) error {
+	return refuseDuplicate(w, findDuplicate(ctx, w, client, cfg, state, draft), allow)
+}
+
+// refuseDuplicate returns an error for a match found by findDuplicate, or
+// with allow set only warns about it on w
+func refuseDuplicate(w io.Writer, match *dedupe.Match, allow bool) error {
+	if match == nil {
+		return nil
+	}
+	desc := describeDuplicate(match)
+	if !allow {
+		return fmt.Errorf("post looks like a duplicate of %s (use --allow-duplicate to post anyway)", desc)
+	}
+	fmt.Fprintf(w, "Warning: post looks like a duplicate of %s\n", desc)
+	return nil
+}
+
+// findDuplicate looks for an existing post the draft repeats: our recently
+// sent posts, our newest posts in the submolt, and posts on Moltbook found by
+// searching for the title. A failed lookup is reported on w and skipped.
+func findDuplicate(ctx context.Context, w io.Writer, client moltbook.API, cfg *config.Config, state *config.State, draft *compose.PostDraft) *dedupe.Match {
+	var own, others []moltbook.Post
+	for _, sent := range state.SentPosts {
+		if sent.ID != "" && sent.Submolt == draft.Submolt {
+			own = append(own, moltbook.Post{ID: sent.ID, Submolt: sent.Submolt, Title: sent.Title, Content: sent.Content, URL: sent.URL, Author: cfg.AgentName})
+		}
+	}
+
+	recent, err := client.BrowsePosts(ctx, &moltbook.BrowsePostsRequest{Submolt: draft.Submolt, Sort: moltbook.SortNew, Limit: 25})
+	if err != nil {
+		fmt.Fprintf(w, "Warning: failed to check recent posts for duplicates: %v\n", err)
+	}
+	for _, post := range recent {
+		if strings.EqualFold(post.Author, cfg.AgentName) {
+			own = append(own, post)
+		} else {
+			others = append(others, post)
+		}
+	}
+
+	found, err := client.Search(ctx, draft.Title)
+	if err != nil {
+		fmt.Fprintf(w, "Warning: failed to search for duplicates: %v\n", err)
+	}
+	for _, post := range found {
+		if !strings.EqualFold(post.Submolt, draft.Submolt) {
+			continue
+		}
+		if strings.EqualFold(post.Author, cfg.AgentName) {
+			own = append(own, post)
+		} else {
+			others = append(others, post)
+		}
+	}
+
+	candidate := &moltbook.Post{Submolt: draft.Submolt, Title: draft.Title, Content: draft.Content, URL: draft.URL}
+	return dedupe.Find(candidate, own, others, dedupe.DefaultThreshold)
+}
+
+// describeDuplicate names the post a draft duplicates
+func describeDuplicate(m *dedupe.Match) string {
+	whose := "our post"
+	if !m.Own {
+		whose = "a post by " + m.Post.Author
+	}
+	desc := fmt.Sprintf("%s %q", whose, m.Post.Title)
+	if m.Post.ID != "" {
+		desc += " (" + m.Post.ID + ")"
+	}
+	return fmt.Sprintf("%s, %.0f%% similar", desc, m.Similarity*100)
+}
+
+func sentPost(draft *compose.PostDraft, key, id string) config.SentPost {
+	return config.SentPost{Key: key, ID: id, Submolt: draft.Submolt, Title: draft.Title, Content: draft.Content, URL: draft.URL}
+}
+
+// submitPost creates the post, unless it duplicates an existing one and
+// allowDuplicate is not set
+func submitPost(ctx context.Context, client moltbook.API, cfg *config.Config, state *config.State, draft *compose.PostDraft, key string, allowDuplicate bool) error {
+	if err := checkDuplicate(ctx, deps.Out, client, cfg, state, draft, allowDuplicate); err != nil {
+		return err
+	}
+	return createPost(ctx, client, state, draft, key)
+}
+
+// createPost creates the post and records it in the state. With an
+// idempotency key, the attempt is saved before sending so that a retry can
+// tell it was made.
+func createPost(ctx context.Context, client moltbook.API, state *config.State, draft *compose.PostDraft, key string) error {
+	req := &moltbook.CreatePostRequest{
+		Submolt: draft.Submolt,
+		Title:   draft.Title,
+		Content: draft.Content,
+		URL:     draft.URL,
+	}
+
+	if key != "" {
+		state.RememberPost(sentPost(draft, key, ""), deps.Now())
+		if err := saveState(state); err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+	}
+
+	fmt.Fprintf(deps.Out, "Creating post in /%s...\n", draft.Submolt)
+
+	post, err := client.CreatePost(ctx, req)
+	if approval.IsHeld(err) {
+		state.ForgetPost(key)
+		if err := saveState(state); err != nil {
+			fmt.Fprintf(deps.Out, "Warning: failed to save state: %v\n", err)
+		}
+		fmt.Fprintf(deps.Out, "Post %v\n", err)
+		return nil
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create post: %w", err)
 	}
 
-	fmt.Println("Post created successfully!")
-	fmt.Printf("  ID: %s\n", post.ID)
-	fmt.Printf("  Title: %s\n", post.Title)
-	fmt.Printf("  Submolt: /%s\n", post.Submolt)
+	fmt.Fprintln(deps.Out, "Post created successfully!")
+	fmt.Fprintf(deps.Out, "  ID: %s\n", post.ID)
+	fmt.Fprintf(deps.Out, "  Title: %s\n", post.Title)
+	fmt.Fprintf(deps.Out, "  Submolt: /%s\n", post.Submolt)
 
 	// Update state
-	state.PostsCreated++
-	state.LastPostTime = time.Now().Format(time.RFC3339)
-	if err := config.SaveState(state); err != nil {
-		fmt.Printf("Warning: failed to save state: %v\n", err)
+	state.RecordPost(post.ID, deps.Now())
+	state.RememberPost(sentPost(draft, key, post.ID), deps.Now())
+	if err := saveState(state); err != nil {
+		fmt.Fprintf(deps.Out, "Warning: failed to save state: %v\n", err)
 	}
 
 	return nil
 }
+
+// composePost completes a post from a content file or stdin, or the user's
+// editor when neither content nor a URL was given
+func composePost(draft *compose.PostDraft, contentFile string) (*compose.PostDraft, error) {
+	if contentFile != "" {
+		content, err := compose.ReadSource(contentFile, deps.In)
+		if err != nil {
+			return nil, err
+		}
+		draft.Content = content
+	}
+
+	if draft.Content == "" && draft.URL == "" {
+		if !interactive() {
+			return nil, usageError{fmt.Errorf("must provide either --content, --content-file or --url")}
+		}
+		edited, err := compose.Edit(draft.Template())
+		if err != nil {
+			return nil, err
+		}
+		draft, err = compose.ParsePostDraft(edited)
+		if err != nil {
+			return nil, err
+		}
+		if draft.Content == "" && draft.URL == "" {
+			return nil, fmt.Errorf("aborting post due to empty content")
+		}
+	}
+
+	if err := draft.Validate(); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
@@ -5,7 +5,6 @@ import (
 	"os"
 
 	"github.com/moltgo/moltgo/pkg/config"
-	"github.com/moltgo/moltgo/pkg/moltbook"
 	"github.com/spf13/cobra"
 )
 
@@ -34,35 +33,53 @@ func init() {
 }
 
 func runRegister(cmd *cobra.Command, args []string) error {
-	fmt.Printf("Registering agent '%s'...\n", agentName)
+	fmt.Fprintf(deps.Out, "Registering agent '%s'...\n", agentName)
 
-	result, err := moltbook.Register(agentName, agentDescription)
+	// The client is unauthenticated; its requests are audited under the new
+	// agent's name
+	cfg, err := deps.Config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	cfg.APIKey = ""
+	cfg.AgentName = agentName
+	client, err := deps.NewClient(cfg, false)
+	if err != nil {
+		return err
+	}
+
+	result, err := client.Register(cmd.Context(), agentName, agentDescription)
 	if err != nil {
 		return fmt.Errorf("registration failed: %w", err)
 	}
 
-	fmt.Println("Registration successful!")
+	fmt.Fprintln(deps.Out, "Registration successful!")
 
 	if result.AgentID != "" {
-		fmt.Printf("  Agent ID: %s\n", result.AgentID)
+		fmt.Fprintf(deps.Out, "  Agent ID: %s\n", result.AgentID)
 	}
 
 	if result.APIKey != "" {
 		if len(result.APIKey) > 20 {
-			fmt.Printf("  API Key: %s...\n", result.APIKey[:20])
+			fmt.Fprintf(deps.Out, "  API Key: %s...\n", result.APIKey[:20])
 		} else {
-			fmt.Printf("  API Key: %s\n", result.APIKey)
+			fmt.Fprintf(deps.Out, "  API Key: %s\n", result.APIKey)
 		}
 	} else {
 		return fmt.Errorf("registration returned empty API key")
 	}
 
+	if dryRun {
+		fmt.Fprintln(deps.Out, "\nDry run: credentials were not saved")
+		return nil
+	}
+
 	// Handle different output formats
 	if exportFormat {
 		// Just output export commands
-		fmt.Println("\n# Add these to your shell profile (~/.bashrc, ~/.zshrc, etc.):")
-		fmt.Printf("export MOLTBOOK_API_KEY=\"%s\"\n", result.APIKey)
-		fmt.Printf("export MOLTBOOK_AGENT_NAME=\"%s\"\n", agentName)
+		fmt.Fprintln(deps.Out, "\n# Add these to your shell profile (~/.bashrc, ~/.zshrc, etc.):")
+		fmt.Fprintf(deps.Out, "export MOLTBOOK_API_KEY=\"%s\"\n", result.APIKey)
+		fmt.Fprintf(deps.Out, "export MOLTBOOK_AGENT_NAME=\"%s\"\n", agentName)
 		return nil
 	}
 
@@ -73,32 +90,28 @@ func runRegister(cmd *cobra.Command, args []string) error {
 		if err := os.WriteFile(envPath, []byte(envContent), 0600); err != nil {
 			return fmt.Errorf("failed to write .env file: %w", err)
 		}
-		fmt.Printf("\nCredentials saved to %s\n", envPath)
-		fmt.Println("\n  To use: source .env")
+		fmt.Fprintf(deps.Out, "\nCredentials saved to %s\n", envPath)
+		fmt.Fprintln(deps.Out, "\n  To use: source .env")
 	} else {
-		// Save to TOML file (default)
-		cfg := &config.Config{
-			APIKey:    result.APIKey,
-			AgentName: agentName,
-		}
-
-		if err := config.SaveCredentials(cfg); err != nil {
+		// Save to TOML file (default), keeping its other sections
+		cfg.APIKey = result.APIKey
+		if err := deps.Config.SaveCredentials(cfg); err != nil {
 			return fmt.Errorf("failed to save credentials: %w", err)
 		}
 
 		credPath, _ := config.GetCredentialsPath()
-		fmt.Printf("\nCredentials saved to %s\n", credPath)
+		fmt.Fprintf(deps.Out, "\nCredentials saved to %s\n", credPath)
 
 		// Also show export commands as an option
-		fmt.Println("\n  Or set as environment variables:")
-		fmt.Printf("    export MOLTBOOK_API_KEY=\"%s\"\n", result.APIKey)
-		fmt.Printf("    export MOLTBOOK_AGENT_NAME=\"%s\"\n", agentName)
+		fmt.Fprintln(deps.Out, "\n  Or set as environment variables:")
+		fmt.Fprintf(deps.Out, "    export MOLTBOOK_API_KEY=\"%s\"\n", result.APIKey)
+		fmt.Fprintf(deps.Out, "    export MOLTBOOK_AGENT_NAME=\"%s\"\n", agentName)
 	}
 
-	fmt.Println("\nIMPORTANT: Share this claim URL with your human:")
-	fmt.Printf("  %s\n", result.ClaimURL)
-	fmt.Printf("\n  Verification code: %s\n", result.VerificationCode)
-	fmt.Println("\n  Tweet this URL to verify ownership of your agent!")
+	fmt.Fprintln(deps.Out, "\nIMPORTANT: Share this claim URL with your human:")
+	fmt.Fprintf(deps.Out, "  %s\n", result.ClaimURL)
+	fmt.Fprintf(deps.Out, "\n  Verification code: %s\n", result.VerificationCode)
+	fmt.Fprintln(deps.Out, "\n  Tweet this URL to verify ownership of your agent!")
 
 	return nil
 }
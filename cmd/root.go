@@ -3,30 +3,188 @@ package cmd
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/moltgo/moltgo/pkg/approval"
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/metrics"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/moltbook/cassette"
+	"github.com/moltgo/moltgo/pkg/render"
+	"github.com/moltgo/moltgo/pkg/safety"
 	"github.com/spf13/cobra"
 )
 
+var (
+	dryRun         bool
+	nonInteractive bool
+	force          bool
+
+	// invocation is the command being run, recorded with held requests
+	invocation string
+)
+
 var rootCmd = &cobra.Command{
 	Use:   "moltgo",
 	Short: "Moltbook AI Agent - Participate in the agent internet",
 	Long: `MoltGo is an AI agent that can register and participate on Moltbook,
 the social network for AI agents. It can browse posts, create content,
 comment, vote, and interact with other agents.`,
+	// PrintError reports errors, with a usage hint only for usage errors
+	SilenceErrors: true,
+	SilenceUsage:  true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		invocation = cmd.CommandPath()
+		// Cobra checks these after this hook; check them first so they are
+		// reported as usage errors
+		if err := cmd.ValidateRequiredFlags(); err != nil {
+			return err
+		}
+		if err := cmd.ValidateFlagGroups(); err != nil {
+			return err
+		}
+		commandStarted = true
+		if err := checkOutputFormat(); err != nil {
+			return err
+		}
+		if err := setupLogging(); err != nil {
+			return err
+		}
+		if err := setupMetrics(); err != nil {
+			return err
+		}
+		return setupTracing(cmd)
+	},
 }
 
+// Execute runs the command line. Errors are returned for PrintError to
+// report and ExitCode to turn into the exit status.
 func Execute() error {
-	return rootCmd.Execute()
+	defer closeLog()
+	defer finishMetrics()
+
+	commandStarted = false
+	start := time.Now()
+	cmd, err := rootCmd.ExecuteC()
+	failedCommand = cmd.CommandPath()
+	if err != nil && !commandStarted {
+		err = usageError{err}
+	}
+	finishTracing(cmd, err)
+	if err != nil {
+		logger.Info("command failed", "duration", time.Since(start), "error", err)
+	} else {
+		logger.Info("command finished", "duration", time.Since(start))
+	}
+	return err
 }
 
 func init() {
 	cobra.OnInitialize(initConfig)
+
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Log mutating API requests instead of sending them, and leave local state unchanged")
+	rootCmd.PersistentFlags().BoolVar(&nonInteractive, "non-interactive", false, "Never prompt; treat this as an unattended run for approval")
+	rootCmd.PersistentFlags().BoolVar(&force, "force", false, "Send content the safety filter would block or redact")
+}
+
+// interactive reports whether the user can be prompted
+func interactive() bool {
+	f, ok := deps.In.(*os.File)
+	return !nonInteractive && ok && render.IsTerminal(f)
+}
+
+// clientOptions returns the options every API client is created with: the
+// outbound safety filter, the audit log and the global flags
+func clientOptions(cfg *config.Config) ([]moltbook.Option, error) {
+	filter, err := safety.New(cfg.Safety.Rules, cfg.Safety.Denylist)
+	if err != nil {
+		return nil, fmt.Errorf("invalid [safety] configuration: %w", err)
+	}
+
+	opts, err := apiOptions()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(opts, moltbook.WithContentFilter(&safety.Guard{Filter: filter, Force: force, Out: deps.Err}))
+	// Dry runs send nothing to record, and leave the log alone
+	if dryRun {
+		return append(opts, moltbook.WithDryRun(deps.Err)), nil
+	}
+	log, err := auditLog()
+	if err != nil {
+		return nil, err
+	}
+	return append(opts, moltbook.WithAudit(log, cfg.AgentName, deps.Err)), nil
+}
+
+// apiOptions points clients at MOLTBOOK_API_URL when it is set, such as a
+// fake server in tests, logs and meters requests, records or
+// replays MOLTGO_CASSETTE, and warns about unknown response fields with
+// MOLTGO_STRICT=1
+func apiOptions() ([]moltbook.Option, error) {
+	var opts []moltbook.Option
+	if url := os.Getenv("MOLTBOOK_API_URL"); url != "" {
+		opts = append(opts, moltbook.WithBaseURL(url))
+	}
+	opts = append(opts,
+		moltbook.WithLogger(logger),
+		moltbook.WithMetrics(metrics.Default))
+	if debugHTTP {
+		opts = append(opts, moltbook.WithHTTPDebug())
+	}
+	if os.Getenv("MOLTGO_STRICT") == "1" {
+		opts = append(opts, moltbook.WithStrictDecoding(deps.Err))
+	}
+	if path := os.Getenv("MOLTGO_CASSETTE"); path != "" {
+		rec, err := cassette.New(path, cassette.ModeFromEnv())
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, rec.Option())
+	}
+	return opts, nil
+}
+
+// newClient creates an API client honoring the configuration and global
+// flags. With --non-interactive it is an unattended client.
+func newClient(cfg *config.Config) (moltbook.API, error) {
+	return deps.NewClient(cfg, nonInteractive)
+}
+
+// newUnattendedClient creates a client for runs without a human watching.
+// When approval is required, its posts, comments and votes are queued for
+// approval instead of sent.
+func newUnattendedClient(cfg *config.Config) (moltbook.API, error) {
+	return deps.NewClient(cfg, true)
+}
+
+// newAPIClient creates a Moltbook API client; it is the default
+// Deps.NewClient
+func newAPIClient(cfg *config.Config, unattended bool) (moltbook.API, error) {
+	opts, err := clientOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if unattended && cfg.Approval.Required && !dryRun {
+		// Without a usable path every held request fails rather than being sent
+		path, _ := config.GetApprovalsPath()
+		opts = append(opts, moltbook.WithHolder(&approval.Holder{Path: path, Source: invocation}))
+	}
+	return moltbook.NewClient(cfg.APIKey, opts...), nil
+}
+
+// saveState persists state unless this is a dry run
+func saveState(state *config.State) error {
+	if dryRun {
+		return nil
+	}
+	return deps.State.Save(state)
 }
 
 func initConfig() {
 	home, err := os.UserHomeDir()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: could not get home directory: %v\n", err)
+		fmt.Fprintf(deps.Err, "Warning: could not get home directory: %v\n", err)
 		return
 	}
 
@@ -0,0 +1,360 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/daemon"
+	"github.com/moltgo/moltgo/pkg/injection"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/render"
+	"github.com/moltgo/moltgo/pkg/scheduler"
+	"github.com/moltgo/moltgo/pkg/tracing"
+	"github.com/spf13/cobra"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the agent as a long-running daemon",
+	Long: `Run the agent in the foreground with an internal scheduler. The daemon
+performs heartbeats (with jitter), writes periodic status snapshots, and polls
+your own recent posts for new comments.
+
+Intervals are read from the [daemon] section of config.toml:
+
+  [daemon]
+  heartbeat_interval = "4h"
+  heartbeat_jitter = "15m"
+  status_interval = "1h"
+  poll_interval = "15m"
+
+The daemon logs its jobs to stderr at info level; use --log-level, --log-format
+and --log-file to change that.
+
+SIGTERM or SIGINT stops scheduling jobs and shuts down once the running job
+finishes; a second signal aborts the running job. SIGHUP reloads the
+configuration. A PID file prevents two daemons from running the same agent
+profile.`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// pidPath returns the PID file for the agent profile
+func pidPath(cfg *config.Config) (string, error) {
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	profile := unsafeFileChars.ReplaceAllString(cfg.AgentName, "_")
+	if profile == "" {
+		profile = "default"
+	}
+	return filepath.Join(configDir, "run-"+profile+".pid"), nil
+}
+
+func runDaemon(cmd *cobra.Command, args []string) error {
+	cfg, err := deps.Config.LoadCredentials()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Daemon.Validate(); err != nil {
+		return err
+	}
+
+	path, err := pidPath(cfg)
+	if err != nil {
+		return err
+	}
+	lock, err := daemon.AcquireLock(path)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	defaultLogLevel(cmd, slog.LevelInfo)
+	logger.Info("daemon started", "agent", cfg.AgentName, "pid", os.Getpid())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	var sched *scheduler.Scheduler
+	for {
+		client, err := newUnattendedClient(cfg)
+		if err != nil {
+			return err
+		}
+
+		// Stopping ends scheduling; aborting also cancels the running job.
+		// Each job is traced as a trace of its own, with the command's tracer.
+		stop, stopScheduling := context.WithCancel(context.Background())
+		jobCtx, abort := context.WithCancel(tracing.ContextWithTracer(context.Background(), tracing.TracerFromContext(cmd.Context())))
+		sched = newDaemonScheduler(cfg, client, sched)
+		done := make(chan struct{})
+		go func() {
+			sched.Run(stop, jobCtx)
+			close(done)
+		}()
+
+		sig := <-signals
+		logger.Info("stopping after the running job", "signal", sig.String())
+		stopScheduling()
+		for waiting := true; waiting; {
+			select {
+			case <-done:
+				waiting = false
+			case again := <-signals:
+				if again == syscall.SIGHUP {
+					continue
+				}
+				logger.Warn("aborting the running job", "signal", again.String())
+				sig = again
+				abort()
+			}
+		}
+		abort()
+
+		if sig != syscall.SIGHUP {
+			logger.Info("daemon stopped")
+			return nil
+		}
+
+		reloaded, err := deps.Config.LoadCredentials()
+		if err == nil {
+			err = reloaded.Daemon.Validate()
+		}
+		if err == nil {
+			_, err = clientOptions(reloaded)
+		}
+		if err != nil {
+			logger.Error("failed to reload config, keeping previous", "error", err)
+			continue
+		}
+		cfg = reloaded
+		logger.Info("configuration reloaded")
+	}
+}
+
+// newDaemonScheduler builds the daemon's jobs from the configuration. When
+// the configuration is reloaded, prev is the scheduler it replaces, and jobs
+// keep their next run from it, shortened to the new interval if need be.
+func newDaemonScheduler(cfg *config.Config, client moltbook.API, prev *scheduler.Scheduler) *scheduler.Scheduler {
+	out := logWriter{logger}
+	sched := scheduler.New(logger)
+
+	// Resume the heartbeat cadence from the last check
+	var heartbeatDelay time.Duration
+	if state, err := deps.State.Load(); err == nil && !state.LastMoltbookCheck.IsZero() {
+		next := state.LastMoltbookCheck.Add(cfg.Daemon.HeartbeatEvery())
+		heartbeatDelay = max(next.Sub(deps.Now()), 0)
+	}
+
+	sched.Add(scheduler.Job{
+		Name:     "heartbeat",
+		Interval: cfg.Daemon.HeartbeatEvery(),
+		Jitter:   cfg.Daemon.HeartbeatSpread(),
+		Delay:    heartbeatDelay,
+		Run: func(ctx context.Context) error {
+			return heartbeat(ctx, out, cfg, client, heartbeatOptions{PublishDrafts: true, DryRun: dryRun})
+		},
+	})
+	sched.Add(scheduler.Job{
+		Name:     "status",
+		Interval: cfg.Daemon.StatusEvery(),
+		Delay:    resumeDelay(prev, "status", cfg.Daemon.StatusEvery()),
+		Run: func(ctx context.Context) (err error) {
+			ctx, span := tracing.Start(ctx, "status")
+			defer func() {
+				span.Fail(err)
+				span.End()
+			}()
+			return writeStatusSnapshot(ctx, client)
+		},
+	})
+	sched.Add(scheduler.Job{
+		Name:     "comments",
+		Interval: cfg.Daemon.PollEvery(),
+		Delay:    resumeDelay(prev, "comments", cfg.Daemon.PollEvery()),
+		Run: func(ctx context.Context) (err error) {
+			ctx, span := tracing.Start(ctx, "comments")
+			defer func() {
+				span.Fail(err)
+				span.End()
+			}()
+			return pollComments(ctx, out, client)
+		},
+	})
+	return sched
+}
+
+// resumeDelay returns the wait until the job's next run in prev, at most
+// interval, or 0 to run it at once when there is no previous scheduler
+func resumeDelay(prev *scheduler.Scheduler, name string, interval time.Duration) time.Duration {
+	if prev == nil {
+		return 0
+	}
+	next, ok := prev.NextRun(name)
+	if !ok {
+		return 0
+	}
+	return min(max(time.Until(next), 0), interval)
+}
+
+// logWriter adapts command output to info log records, one per line
+type logWriter struct {
+	logger *slog.Logger
+}
+
+func (w logWriter) Write(p []byte) (int, error) {
+	for _, line := range strings.Split(string(p), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			w.logger.Info(line)
+		}
+	}
+	return len(p), nil
+}
+
+// StatusSnapshot is the periodic status record written by the daemon
+type StatusSnapshot struct {
+	Time            string `json:"time"`
+	AgentID         string `json:"agent_id,omitempty"`
+	AgentName       string `json:"agent_name,omitempty"`
+	PostsCreated    int    `json:"posts_created"`
+	CommentsCreated int    `json:"comments_created"`
+	CommentsToday   int    `json:"comments_today"`
+	LastCheck       string `json:"last_check,omitempty"`
+	LastPost        string `json:"last_post,omitempty"`
+	ProfileError    string `json:"profile_error,omitempty"`
+}
+
+// writeStatusSnapshot records the agent profile and local statistics
+func writeStatusSnapshot(ctx context.Context, client moltbook.API) error {
+	state, err := deps.State.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	snapshot := StatusSnapshot{
+		Time:            deps.Now().Format(time.RFC3339),
+		PostsCreated:    state.PostsCreated,
+		CommentsCreated: state.CommentsCreated,
+		LastCheck:       state.LastMoltbookCheck.String(),
+		LastPost:        state.LastPostTime.String(),
+	}
+	if state.CommentDay == deps.Now().Format("2006-01-02") {
+		snapshot.CommentsToday = state.CommentsToday
+	}
+
+	if profile, err := client.GetProfile(ctx); err != nil {
+		snapshot.ProfileError = err.Error()
+	} else {
+		snapshot.AgentID = profile.ID
+		snapshot.AgentName = profile.Name
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	path, err := config.GetSnapshotPath()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// pollComments reports comments that arrived on our own recent posts since
+// the last check
+func pollComments(ctx context.Context, w io.Writer, client moltbook.API) error {
+	state, err := deps.State.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+	if state.SeenComments == nil {
+		state.SeenComments = make(map[string][]string)
+	}
+
+	for _, postID := range state.RecentPosts {
+		if ctx.Err() != nil {
+			break
+		}
+
+		comments, err := client.GetComments(ctx, postID, moltbook.SortNew)
+		if err != nil {
+			fmt.Fprintf(w, "Warning: failed to fetch comments for post %s: %v\n", postID, err)
+			continue
+		}
+
+		// Comments are told apart by ID, so deleted comments and replies
+		// deep in a thread neither hide nor repeat new ones
+		all := flattenComments(comments)
+		seen, known := state.SeenComments[postID]
+		ids := make([]string, len(all))
+		for i, c := range all {
+			ids[i] = c.ID
+		}
+		state.SeenComments[postID] = ids
+		if !known {
+			continue
+		}
+
+		seenIDs := make(map[string]bool, len(seen))
+		for _, id := range seen {
+			seenIDs[id] = true
+		}
+		var fresh []moltbook.Comment
+		for _, c := range all {
+			if !seenIDs[c.ID] {
+				fresh = append(fresh, c)
+			}
+		}
+		if len(fresh) == 0 {
+			continue
+		}
+		sort.SliceStable(fresh, func(i, j int) bool { return fresh[i].CreatedAt.Before(fresh[j].CreatedAt.Time) })
+		fmt.Fprintf(w, "%d new comment(s) on post %s:\n", len(fresh), postID)
+		for _, c := range fresh {
+			fmt.Fprintf(w, "  %s: %s\n", render.Sanitize(c.Author), render.Preview(c.Content, previewWidth))
+			if warning := injectionWarning(injection.AnalyzeComment(&c)); warning != "" {
+				fmt.Fprintf(w, "    %s\n", warning)
+			}
+		}
+	}
+
+	if err := saveState(state); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	return nil
+}
+
+// flattenComments lists every comment in a thread
+func flattenComments(comments []moltbook.Comment) []moltbook.Comment {
+	var all []moltbook.Comment
+	for _, c := range comments {
+		all = append(all, c)
+		all = append(all, flattenComments(c.Replies)...)
+	}
+	return all
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/scheduler"
+	"github.com/moltgo/moltgo/pkg/timestamp"
+)
+
+func TestPollCommentsByID(t *testing.T) {
+	at := func(minutes int) timestamp.Time {
+		return timestamp.New(testNow.Add(time.Duration(minutes) * time.Minute))
+	}
+	first := moltbook.Comment{ID: "c1", Author: "alice", Content: "First", CreatedAt: at(0)}
+	second := moltbook.Comment{ID: "c2", Author: "bob", Content: "Second", CreatedAt: at(5)}
+
+	state := &fakeState{state: &config.State{RecentPosts: []string{"post_1"}}}
+	saved := deps
+	t.Cleanup(func() { deps = saved })
+	deps = &Deps{State: state, Now: func() time.Time { return testNow }}
+	api := &fakeAPI{comments: map[string][]moltbook.Comment{"post_1": {first, second}}}
+
+	polls := []struct {
+		name     string
+		comments []moltbook.Comment
+		want     []string
+	}{
+		// The first check only learns which comments are there
+		{"first check", []moltbook.Comment{first, second}, nil},
+		{"nothing new", []moltbook.Comment{first, second}, nil},
+		// A deleted comment does not hide the one replacing it in the count
+		{"deleted and added", []moltbook.Comment{first, {ID: "c3", Author: "carol", Content: "Third", CreatedAt: at(10)}}, []string{"carol: Third"}},
+		// A reply timestamped before the newest comment is still new
+		{"late reply", []moltbook.Comment{
+			{ID: "c1", Author: "alice", Content: "First", CreatedAt: at(0), Replies: []moltbook.Comment{{ID: "c4", Author: "dave", Content: "Reply", CreatedAt: at(1)}}},
+			{ID: "c3", Author: "carol", Content: "Third", CreatedAt: at(10)},
+		}, []string{"dave: Reply"}},
+	}
+	for _, poll := range polls {
+		api.comments["post_1"] = poll.comments
+		var out bytes.Buffer
+		if err := pollComments(context.Background(), &out, api); err != nil {
+			t.Fatalf("%s: %v", poll.name, err)
+		}
+		for _, want := range poll.want {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%s: output %q does not report %q", poll.name, out.String(), want)
+			}
+		}
+		if poll.want == nil && out.Len() > 0 {
+			t.Errorf("%s: reported %q, want nothing", poll.name, out.String())
+		}
+		if n := strings.Count(out.String(), "\n  "); poll.want != nil && n != len(poll.want) {
+			t.Errorf("%s: reported %d comments, want %d", poll.name, n, len(poll.want))
+		}
+	}
+}
+
+func TestResumeDelay(t *testing.T) {
+	if got := resumeDelay(nil, "status", time.Hour); got != 0 {
+		t.Errorf("without a previous scheduler the delay is %v, want 0", got)
+	}
+
+	prev := scheduler.New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	prev.Add(scheduler.Job{Name: "status", Interval: time.Hour, Delay: 40 * time.Minute, Run: func(context.Context) error { return nil }})
+	stop, stopScheduling := context.WithCancel(context.Background())
+	stopScheduling()
+	prev.Run(stop, context.Background())
+
+	tests := []struct {
+		name     string
+		interval time.Duration
+		min, max time.Duration
+	}{
+		{"status", time.Hour, 39 * time.Minute, 40 * time.Minute},
+		{"status", 10 * time.Minute, 10 * time.Minute, 10 * time.Minute},
+		{"comments", time.Hour, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := resumeDelay(prev, tt.name, tt.interval); got < tt.min || got > tt.max {
+			t.Errorf("resumeDelay(%s, %v) = %v, want between %v and %v", tt.name, tt.interval, got, tt.min, tt.max)
+		}
+	}
+}
+
+// heartbeatSignal is a fake API that closes started on the first browse,
+// which comes from the daemon's first heartbeat
+type heartbeatSignal struct {
+	*fakeAPI
+	once    sync.Once
+	started chan struct{}
+}
+
+func (a *heartbeatSignal) BrowsePosts(ctx context.Context, req *moltbook.BrowsePostsRequest) ([]moltbook.Post, error) {
+	defer a.once.Do(func() { close(a.started) })
+	return a.fakeAPI.BrowsePosts(ctx, req)
+}
+
+func TestRunStopsOnSignal(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	saved := deps
+	t.Cleanup(func() { deps = saved })
+
+	api := &heartbeatSignal{fakeAPI: &fakeAPI{posts: testPosts()}, started: make(chan struct{})}
+	state := &fakeState{}
+	deps = &Deps{
+		Config: &fakeConfig{cfg: testConfig()},
+		State:  state,
+		NewClient: func(cfg *config.Config, unattended bool) (moltbook.API, error) {
+			if !unattended {
+				t.Error("the daemon made a client for attended use")
+			}
+			return api, nil
+		},
+		In:  strings.NewReader(""),
+		Out: io.Discard,
+		Err: io.Discard,
+		Now: func() time.Time { return testNow },
+	}
+	resetFlags(rootCmd)
+	rootCmd.SetArgs([]string{"run"})
+
+	done := make(chan error, 1)
+	go func() { done <- Execute() }()
+
+	select {
+	case <-api.started:
+	case err := <-done:
+		t.Fatalf("the daemon stopped before its first heartbeat: %v", err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("the daemon did not start a heartbeat")
+	}
+	if err := syscall.Kill(os.Getpid(), syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("the daemon stopped with %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("the daemon did not stop on SIGTERM")
+	}
+	if state.state == nil || !state.state.LastMoltbookCheck.Equal(testNow) {
+		t.Error("the heartbeat did not record its check")
+	}
+}
@@ -1,63 +1,209 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
-	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/archive"
 	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/render"
 	"github.com/spf13/cobra"
 )
 
+var (
+	searchFull     bool
+	searchLocal    bool
+	searchSemantic bool
+	searchAuthor   string
+	searchSubmolt  string
+	searchSince    string
+	searchUntil    string
+	searchMinScore int
+	searchLimit    int
+)
+
 var searchCmd = &cobra.Command{
 	Use:   "search [query]",
 	Short: "Search for posts using semantic search",
-	Long:  `Search for posts on Moltbook using AI-powered semantic search.`,
-	Args:  cobra.MinimumNArgs(1),
-	RunE:  runSearch,
+	Long: `Search for posts on Moltbook using AI-powered semantic search.
+
+With --local, search the posts indexed on this machine instead. Every post
+fetched by browse, search and archive sync is indexed, and local search works
+offline without spending the API rate limit. Results are ranked by BM25;
+"quoted phrases" must match word for word, and the filters narrow the results:
+
+  moltgo search --local '"rate limit" retries' --author alice --since 2026-01-01
+
+Add --semantic to rank local posts by meaning rather than by keywords, using
+the embedding provider in the [embedding] section of config.toml.`,
+	RunE: runSearch,
 }
 
 func init() {
 	rootCmd.AddCommand(searchCmd)
+
+	searchCmd.Flags().BoolVarP(&searchFull, "full", "f", false, "Show whole posts through $PAGER")
+	searchCmd.Flags().BoolVar(&searchLocal, "local", false, "Search the local index instead of Moltbook")
+	searchCmd.Flags().BoolVar(&searchSemantic, "semantic", false, "Rank local results by embedding similarity (with --local)")
+	searchCmd.Flags().StringVar(&searchAuthor, "author", "", "Only posts by this author (with --local)")
+	searchCmd.Flags().StringVarP(&searchSubmolt, "submolt", "s", "", "Only posts in this submolt (with --local)")
+	searchCmd.Flags().StringVar(&searchSince, "since", "", "Only posts created at or after this date, e.g. 2026-01-02 (with --local)")
+	searchCmd.Flags().StringVar(&searchUntil, "until", "", "Only posts created before this date (with --local)")
+	searchCmd.Flags().IntVar(&searchMinScore, "min-score", 0, "Only posts with at least this score (with --local)")
+	searchCmd.Flags().IntVarP(&searchLimit, "limit", "l", 20, "Maximum results (with --local)")
 }
 
 func runSearch(cmd *cobra.Command, args []string) error {
-	cfg, err := config.LoadCredentials()
+	query := strings.Join(args, " ")
+	if searchLocal {
+		return runLocalSearch(cmd, query)
+	}
+	for _, name := range []string{"semantic", "author", "submolt", "since", "until", "min-score", "limit"} {
+		if cmd.Flags().Changed(name) {
+			return usageError{fmt.Errorf("--%s requires --local", name)}
+		}
+	}
+	if query == "" {
+		return usageError{fmt.Errorf("a search query is required")}
+	}
+
+	cfg, err := deps.Config.LoadCredentials()
 	if err != nil {
 		return err
 	}
 
-	client := moltbook.NewClient(cfg.APIKey)
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
 
-	query := strings.Join(args, " ")
-	fmt.Printf("Searching for: %s\n\n", query)
+	fmt.Fprintf(deps.Out, "Searching for: %s\n\n", query)
 
-	results, err := client.Search(query)
+	results, err := client.Search(cmd.Context(), query)
 	if err != nil {
 		return fmt.Errorf("search failed: %w", err)
 	}
+	indexPosts(results)
+
+	return printResults(results)
+}
+
+func runLocalSearch(cmd *cobra.Command, query string) error {
+	q := archive.ParseQuery(query)
+	q.Author = strings.TrimPrefix(searchAuthor, "@")
+	q.Submolt = searchSubmolt
+	q.Limit = searchLimit
+	if cmd.Flags().Changed("min-score") {
+		q.MinScore = &searchMinScore
+	}
 
+	var err error
+	if q.Since, err = parseDateFlag("since", searchSince); err != nil {
+		return err
+	}
+	if q.Until, err = parseDateFlag("until", searchUntil); err != nil {
+		return err
+	}
+	if searchSemantic && strings.TrimSpace(query) == "" {
+		return usageError{fmt.Errorf("a search query is required for --semantic")}
+	}
+	if len(q.Terms) == 0 && len(q.Phrases) == 0 && searchAuthor == "" && searchSubmolt == "" &&
+		searchSince == "" && searchUntil == "" && q.MinScore == nil {
+		return usageError{fmt.Errorf("a search query or filter is required")}
+	}
+
+	db, err := openArchive()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if query != "" {
+		fmt.Fprintf(deps.Out, "Searching for: %s\n\n", query)
+	}
+
+	var hits []archive.Hit
+	if searchSemantic {
+		hits, err = semanticSearch(cmd.Context(), db, query, q)
+	} else {
+		hits, err = db.Search(q)
+	}
+	if err != nil {
+		return fmt.Errorf("search failed: %w", err)
+	}
+
+	results := make([]moltbook.Post, len(hits))
+	for i, hit := range hits {
+		results[i] = hit.Post
+	}
+	return printResults(results)
+}
+
+// semanticSearch ranks the archived posts matching q's filters by their
+// similarity to the query text
+func semanticSearch(ctx context.Context, db *archive.DB, query string, q archive.Query) ([]archive.Hit, error) {
+	cfg, err := deps.Config.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
+	provider, err := newEmbedder(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := embedArchive(ctx, db, provider); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, cfg.Embedding.RequestTimeout())
+	defer cancel()
+	vectors, err := provider.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	return db.Nearest(provider.Name(), vectors[0], q, "")
+}
+
+// printResults lists search results
+func printResults(results []moltbook.Post) error {
 	if len(results) == 0 {
-		fmt.Println("No results found.")
+		fmt.Fprintln(deps.Out, "No results found.")
 		return nil
 	}
 
-	fmt.Printf("Found %d results:\n\n", len(results))
+	r := render.New(deps.Out)
+	var out io.Writer = deps.Out
+	var buf bytes.Buffer
+	if searchFull {
+		out = &buf
+	}
+
+	fmt.Fprintf(out, "Found %d results:\n\n", len(results))
 
 	for i, post := range results {
-		fmt.Printf("[%d] %s\n", i+1, post.Title)
-		fmt.Printf("    by %s in /%s\n", post.Author, post.Submolt)
-		fmt.Printf("    Score: %d | Comments: %d\n", post.Score, post.NumComments)
-		if post.Content != "" {
-			content := post.Content
-			if len(content) > 100 {
-				content = content[:100] + "..."
-			}
-			fmt.Printf("    %s\n", content)
-		}
-		fmt.Printf("    ID: %s\n", post.ID)
-		fmt.Println()
+		printPost(out, r, i+1, post, searchFull)
+		fmt.Fprintf(out, "    ID: %s\n", post.ID)
+		fmt.Fprintln(out)
 	}
 
+	if searchFull {
+		return render.Page(buf.String(), deps.Out)
+	}
 	return nil
 }
+
+// parseDateFlag parses a date given as 2006-01-02 (local time) or RFC 3339
+func parseDateFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, usageError{fmt.Errorf("invalid --%s %q (use 2006-01-02 or RFC 3339)", name, value)}
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/moltgo/moltgo/pkg/archive"
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/embed"
+	"github.com/moltgo/moltgo/pkg/render"
+	"github.com/spf13/cobra"
+)
+
+var (
+	similarLimit         int
+	similarSubmolt       string
+	similarMinSimilarity float64
+)
+
+var similarCmd = &cobra.Command{
+	Use:   "similar <post-id>",
+	Short: "Find archived posts similar to a post",
+	Long: `List the locally indexed posts closest in meaning to a post, to check whether
+a topic has already been covered before posting about it.
+
+Posts are compared by embeddings from the provider configured in the
+[embedding] section of config.toml. The default hashing provider needs no
+model; an OpenAI-compatible embeddings endpoint gives better matches:
+
+  [embedding]
+  provider = "openai"
+  base_url = "http://localhost:11434/v1"
+  model = "nomic-embed-text"
+
+The openai provider uses the [llm] base_url and api_key when its own are not
+set.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSimilar,
+}
+
+func init() {
+	rootCmd.AddCommand(similarCmd)
+
+	similarCmd.Flags().IntVarP(&similarLimit, "limit", "l", 5, "Maximum results")
+	similarCmd.Flags().StringVarP(&similarSubmolt, "submolt", "s", "", "Only posts in this submolt")
+	similarCmd.Flags().Float64Var(&similarMinSimilarity, "min-similarity", 0.1, "Leave out posts less similar than this (0 to 1)")
+}
+
+// newEmbedder creates the configured embedding provider
+func newEmbedder(cfg *config.Config) (embed.Provider, error) {
+	name, err := cfg.Embedding.ProviderName()
+	if err != nil {
+		return nil, err
+	}
+	if name == config.EmbeddingHashing {
+		return embed.Hashing{}, nil
+	}
+
+	baseURL := cfg.Embedding.BaseURL
+	if baseURL == "" {
+		baseURL = cfg.LLM.Endpoint()
+	}
+	apiKey := cfg.Embedding.APIKey
+	if apiKey == "" {
+		apiKey = cfg.LLM.Key()
+	}
+	return embed.NewOpenAI(baseURL, apiKey, cfg.Embedding.ModelName(), cfg.Embedding.RequestTimeout()), nil
+}
+
+// embedArchive computes the vectors archived posts are still missing, such
+// as posts indexed before the provider was changed
+func embedArchive(ctx context.Context, db *archive.DB, provider embed.Provider) error {
+	_, err := db.EmbedMissing(ctx, provider, func(done, total int) {
+		fmt.Fprintf(deps.Err, "Embedding archived posts with %s: %d/%d\n", provider.Name(), done, total)
+	})
+	return err
+}
+
+func runSimilar(cmd *cobra.Command, args []string) error {
+	cfg, err := deps.Config.LoadSettings()
+	if err != nil {
+		return err
+	}
+	provider, err := newEmbedder(cfg)
+	if err != nil {
+		return err
+	}
+
+	db, err := openArchive()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	rec, err := db.Post(args[0])
+	if err != nil {
+		return err
+	}
+	if rec == nil {
+		// Fetch and index a post we have not seen yet
+		creds, err := deps.Config.LoadCredentials()
+		if err != nil {
+			return err
+		}
+		client, err := newClient(creds)
+		if err != nil {
+			return err
+		}
+		post, err := client.GetPost(cmd.Context(), args[0])
+		if err != nil {
+			return fmt.Errorf("failed to get post: %w", err)
+		}
+		if _, err := db.PutPost(*post, deps.Now()); err != nil {
+			return err
+		}
+		rec = &archive.PostRecord{Post: *post}
+	}
+
+	if err := embedArchive(cmd.Context(), db, provider); err != nil {
+		return err
+	}
+	vec, err := db.Vector(provider.Name(), rec.Post.ID)
+	if err != nil {
+		return err
+	}
+
+	hits, err := db.Nearest(provider.Name(), vec, archive.Query{Submolt: similarSubmolt, Limit: similarLimit}, rec.Post.ID)
+	if err != nil {
+		return err
+	}
+
+	for i, hit := range hits {
+		if hit.Score < similarMinSimilarity {
+			hits = hits[:i]
+			break
+		}
+	}
+
+	fmt.Fprintf(deps.Out, "Posts similar to: %s\n\n", render.Sanitize(rec.Post.Title))
+	if len(hits) == 0 {
+		fmt.Fprintln(deps.Out, "No similar posts found.")
+		return nil
+	}
+
+	r := render.New(deps.Out)
+	for i, hit := range hits {
+		printPost(deps.Out, r, i+1, hit.Post, false)
+		fmt.Fprintf(deps.Out, "    ID: %s | Similarity: %.2f\n", hit.Post.ID, hit.Score)
+		fmt.Fprintln(deps.Out)
+	}
+	return nil
+}
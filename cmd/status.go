@@ -5,7 +5,7 @@ import (
 	"time"
 
 	"github.com/moltgo/moltgo/pkg/config"
-	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/render"
 	"github.com/spf13/cobra"
 )
 
@@ -21,62 +21,59 @@ func init() {
 
 func runStatus(cmd *cobra.Command, args []string) error {
 	// Load credentials
-	cfg, err := config.LoadCredentials()
+	cfg, err := deps.Config.LoadCredentials()
 	if err != nil {
-		fmt.Println("Moltbook Agent Status")
-		fmt.Println("  Status: Not registered")
-		fmt.Println("\n  Run 'moltgo register' to get started!")
+		fmt.Fprintln(deps.Out, "Moltbook Agent Status")
+		fmt.Fprintln(deps.Out, "  Status: Not registered")
+		fmt.Fprintln(deps.Out, "\n  Run 'moltgo register' to get started!")
 		return nil
 	}
 
 	// Load state
-	state, err := config.LoadState()
+	state, err := deps.State.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load state: %w", err)
 	}
 
-	fmt.Println("Moltbook Agent Status")
-	fmt.Printf("  Name: %s\n", cfg.AgentName)
-	fmt.Println("  Status: Registered")
-	fmt.Printf("  API Key: %s...\n", cfg.APIKey[:20])
+	fmt.Fprintln(deps.Out, "Moltbook Agent Status")
+	fmt.Fprintf(deps.Out, "  Name: %s\n", cfg.AgentName)
+	fmt.Fprintln(deps.Out, "  Status: Registered")
+	fmt.Fprintf(deps.Out, "  API Key: %s...\n", cfg.APIKey[:20])
 
 	// Fetch profile from API to get description
-	client := moltbook.NewClient(cfg.APIKey)
-	profile, err := client.GetProfile()
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+	profile, err := client.GetProfile(cmd.Context())
 	if err == nil {
 		if profile.ID != "" {
-			fmt.Printf("  Agent ID: %s\n", profile.ID)
+			fmt.Fprintf(deps.Out, "  Agent ID: %s\n", profile.ID)
 		}
 		if profile.Description != "" {
-			fmt.Printf("  Description: %s\n", profile.Description)
+			fmt.Fprintf(deps.Out, "  Description: %s\n", render.Sanitize(profile.Description))
 		}
 	}
 
-	fmt.Println("\n  Statistics:")
-	fmt.Printf("    Posts created: %d\n", state.PostsCreated)
-	fmt.Printf("    Comments created: %d\n", state.CommentsCreated)
+	fmt.Fprintln(deps.Out, "\n  Statistics:")
+	fmt.Fprintf(deps.Out, "    Posts created: %d\n", state.PostsCreated)
+	fmt.Fprintf(deps.Out, "    Comments created: %d\n", state.CommentsCreated)
 
-	if state.LastMoltbookCheck != "" {
-		lastCheck, err := time.Parse(time.RFC3339, state.LastMoltbookCheck)
-		if err == nil {
-			fmt.Printf("    Last check: %s\n", lastCheck.Format("2006-01-02 15:04:05"))
-			timeSince := time.Since(lastCheck)
-			fmt.Printf("    Time since last check: %s\n", formatDuration(timeSince))
-		}
+	now := deps.Now()
+	if !state.LastMoltbookCheck.IsZero() {
+		fmt.Fprintf(deps.Out, "    Last check: %s\n", localTime(state.LastMoltbookCheck))
+		fmt.Fprintf(deps.Out, "    Time since last check: %s\n", formatDuration(now.Sub(state.LastMoltbookCheck.Time)))
 	}
 
-	if state.LastPostTime != "" {
-		lastPost, err := time.Parse(time.RFC3339, state.LastPostTime)
-		if err == nil {
-			fmt.Printf("    Last post: %s\n", lastPost.Format("2006-01-02 15:04:05"))
-		}
+	if !state.LastPostTime.IsZero() {
+		fmt.Fprintf(deps.Out, "    Last post: %s (%s)\n", localTime(state.LastPostTime), ago(state.LastPostTime))
 	}
 
 	credPath, _ := config.GetCredentialsPath()
 	statePath, _ := config.GetStatePath()
-	fmt.Printf("\n  Config files:\n")
-	fmt.Printf("    Credentials: %s\n", credPath)
-	fmt.Printf("    State: %s\n", statePath)
+	fmt.Fprintf(deps.Out, "\n  Config files:\n")
+	fmt.Fprintf(deps.Out, "    Credentials: %s\n", credPath)
+	fmt.Fprintf(deps.Out, "    State: %s\n", statePath)
 
 	return nil
 }
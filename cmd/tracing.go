@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/moltgo/moltgo/pkg/tracing"
+	"github.com/spf13/cobra"
+)
+
+var (
+	traceTarget string
+
+	// commandSpan is the root of the command's trace; it is nil without
+	// --trace
+	commandSpan *tracing.Span
+)
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&traceTarget, "trace", "", "Export traces to stdout, or over OTLP/HTTP to a collector URL such as http://localhost:4318")
+}
+
+// setupTracing starts the command's trace when --trace is set. The command's
+// context carries the trace's tracer and root span to the requests it sends.
+func setupTracing(cmd *cobra.Command) error {
+	if traceTarget == "" {
+		return nil
+	}
+
+	var exporter tracing.Exporter
+	switch {
+	case traceTarget == "stdout":
+		exporter = tracing.NewWriterExporter(deps.Out)
+	case strings.HasPrefix(traceTarget, "http://"), strings.HasPrefix(traceTarget, "https://"):
+		exporter = tracing.NewOTLPExporter(traceTarget, otlpHeaders())
+	default:
+		return usageError{fmt.Errorf("invalid --trace %q: want stdout or a collector URL such as http://localhost:4318", traceTarget)}
+	}
+
+	tracer := tracing.New("moltgo", exporter)
+	tracer.OnError = func(err error) {
+		fmt.Fprintf(deps.Err, "Warning: %v\n", err)
+	}
+	ctx := tracing.ContextWithTracer(cmd.Context(), tracer)
+	ctx, commandSpan = tracer.Start(ctx, invocation,
+		slog.String("moltgo.command", invocation),
+		slog.Bool("moltgo.dry_run", dryRun))
+	cmd.SetContext(ctx)
+	return nil
+}
+
+// finishTracing ends the command's trace, which exports it, and drops the
+// trace from the command's context so that a later run of the command in
+// the same process starts untraced
+func finishTracing(cmd *cobra.Command, err error) {
+	commandSpan.Fail(err)
+	commandSpan.End()
+	commandSpan = nil
+	cmd.SetContext(nil)
+}
+
+// otlpHeaders reads OTEL_EXPORTER_OTLP_HEADERS, a comma-separated list of
+// key=value pairs sent with each export
+func otlpHeaders() http.Header {
+	header := http.Header{}
+	for _, pair := range strings.Split(os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"), ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); ok && key != "" {
+			header.Add(key, strings.TrimSpace(value))
+		}
+	}
+	return header
+}
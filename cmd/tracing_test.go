@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/moltgo/moltgo/pkg/tracing"
+	"github.com/spf13/cobra"
+)
+
+func TestTracingEndsWithTheCommand(t *testing.T) {
+	saved, savedTarget := deps, traceTarget
+	t.Cleanup(func() { deps, traceTarget = saved, savedTarget })
+
+	var out strings.Builder
+	deps = &Deps{Out: &out, Err: io.Discard}
+	traceTarget = "stdout"
+
+	cmd := &cobra.Command{}
+	cmd.SetContext(context.Background())
+	if err := setupTracing(cmd); err != nil {
+		t.Fatal(err)
+	}
+	if tracing.SpanFromContext(cmd.Context()) != commandSpan || commandSpan == nil {
+		t.Fatal("the command's context does not carry its span")
+	}
+
+	finishTracing(cmd, nil)
+	if commandSpan != nil {
+		t.Error("the command's span is kept after it finished")
+	}
+	if cmd.Context() != nil {
+		t.Error("the command's context still carries the finished trace")
+	}
+	if n := strings.Count(out.String(), "\n"); n != 1 {
+		t.Errorf("exported %d lines, want the command's trace", n)
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/tui"
+	"github.com/spf13/cobra"
+)
+
+var (
+	tuiSubmolt string
+	tuiSort    string
+	tuiLimit   int
+)
+
+var tuiCmd = &cobra.Command{
+	Use:   "tui",
+	Short: "Browse and engage with Moltbook in a full-screen interface",
+	Long: `Open a full-screen terminal interface with the post feed, post details with
+their comment threads, and a compose pane for posts and replies. Press ? inside
+the interface for the list of keybindings.`,
+	RunE: runTUI,
+}
+
+func init() {
+	rootCmd.AddCommand(tuiCmd)
+
+	tuiCmd.Flags().StringVarP(&tuiSubmolt, "submolt", "s", "", "Start in a submolt (community)")
+	tuiCmd.Flags().StringVar(&tuiSort, "sort", moltbook.SortHot, "Initial sort order (hot, new, top, rising)")
+	tuiCmd.Flags().IntVarP(&tuiLimit, "limit", "l", 25, "Number of posts to load")
+}
+
+func runTUI(cmd *cobra.Command, args []string) error {
+	cfg, err := deps.Config.LoadCredentials()
+	if err != nil {
+		return err
+	}
+
+	state, err := deps.State.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load state: %w", err)
+	}
+
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	model := tui.NewModel(client, tui.Options{
+		Submolt:   tuiSubmolt,
+		Sort:      tuiSort,
+		Limit:     tuiLimit,
+		State:     state,
+		SaveState: saveState,
+		OpenURL:   tui.OpenURL,
+	})
+
+	return tui.Run(cmd.Context(), model, deps.In, deps.Out)
+}
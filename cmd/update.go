@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 
-	"github.com/moltgo/moltgo/pkg/config"
 	"github.com/moltgo/moltgo/pkg/moltbook"
 	"github.com/spf13/cobra"
 )
@@ -28,11 +27,11 @@ func init() {
 func runUpdate(cmd *cobra.Command, args []string) error {
 	// Check if any flags were provided
 	if newDescription == "" {
-		return fmt.Errorf("no updates specified. Use --description to update your agent description")
+		return usageError{fmt.Errorf("no updates specified. Use --description to update your agent description")}
 	}
 
 	// Load configuration
-	cfg, err := config.LoadCredentials()
+	cfg, err := deps.Config.LoadCredentials()
 	if err != nil {
 		return fmt.Errorf("failed to load credentials: %w", err)
 	}
@@ -41,23 +40,26 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no API key found. Please run 'moltgo register' first")
 	}
 
-	client := moltbook.NewClient(cfg.APIKey)
+	client, err := newClient(cfg)
+	if err != nil {
+		return err
+	}
 
-	fmt.Println("Updating agent profile...")
+	fmt.Fprintln(deps.Out, "Updating agent profile...")
 
 	// Update profile
 	req := &moltbook.UpdateProfileRequest{
 		Description: newDescription,
 	}
 
-	agent, err := client.UpdateProfile(req)
+	agent, err := client.UpdateProfile(cmd.Context(), req)
 	if err != nil {
 		return fmt.Errorf("failed to update profile: %w", err)
 	}
 
-	fmt.Println("Profile updated successfully!")
-	fmt.Printf("  Name: %s\n", agent.Name)
-	fmt.Printf("  Description: %s\n", agent.Description)
+	fmt.Fprintln(deps.Out, "Profile updated successfully!")
+	fmt.Fprintf(deps.Out, "  Name: %s\n", agent.Name)
+	fmt.Fprintf(deps.Out, "  Description: %s\n", agent.Description)
 
 	return nil
 }
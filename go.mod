@@ -5,9 +5,12 @@ go 1.23
 require (
 	github.com/BurntSushi/toml v1.6.0
 	github.com/spf13/cobra v1.8.1
+	github.com/spf13/pflag v1.0.5
+	go.etcd.io/bbolt v1.3.11
+	golang.org/x/term v0.27.0
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
+	golang.org/x/sys v0.28.0 // indirect
 )
@@ -0,0 +1,270 @@
+// Package approval implements the local queue of outbound content held for
+// human review before it is sent to Moltbook.
+package approval
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/moltgo/moltgo/pkg/filelock"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/timestamp"
+)
+
+// Kinds of held requests
+const (
+	KindPost    = "post"
+	KindComment = "comment"
+	KindVote    = "vote"
+)
+
+// Request statuses
+const (
+	StatusPending  = "pending"
+	StatusSending  = "sending"
+	StatusAccepted = "accepted"
+	StatusRejected = "rejected"
+)
+
+// Event is an entry in a request's audit trail
+type Event struct {
+	Action string         `toml:"action"`
+	By     string         `toml:"by"`
+	At     timestamp.Time `toml:"at"`
+	Note   string         `toml:"note,omitempty"`
+}
+
+// Request is an API request held for approval. Body is the JSON request
+// that is sent once the request is accepted.
+type Request struct {
+	ID        int            `toml:"id"`
+	Kind      string         `toml:"kind"`
+	PostID    string         `toml:"post_id,omitempty"`
+	Body      string         `toml:"body"`
+	Source    string         `toml:"source"`
+	Status    string         `toml:"status"`
+	CreatedAt timestamp.Time `toml:"created_at"`
+
+	// ID of the post or comment created when the request was sent
+	ResultID string `toml:"result_id,omitempty"`
+
+	History []Event `toml:"history,omitempty"`
+}
+
+// Post decodes a held post
+func (r *Request) Post() (*moltbook.CreatePostRequest, error) {
+	var req moltbook.CreatePostRequest
+	if err := r.decode(KindPost, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// Comment decodes a held comment
+func (r *Request) Comment() (*moltbook.CreateCommentRequest, error) {
+	var req moltbook.CreateCommentRequest
+	if err := r.decode(KindComment, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// Vote decodes a held vote
+func (r *Request) Vote() (*moltbook.VoteRequest, error) {
+	var req moltbook.VoteRequest
+	if err := r.decode(KindVote, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *Request) decode(kind string, v interface{}) error {
+	if r.Kind != kind {
+		return fmt.Errorf("request %d is a %s, not a %s", r.ID, r.Kind, kind)
+	}
+	if err := json.Unmarshal([]byte(r.Body), v); err != nil {
+		return fmt.Errorf("failed to parse request %d: %w", r.ID, err)
+	}
+	return nil
+}
+
+// SetBody replaces the held request body
+func (r *Request) SetBody(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+	r.Body = string(data)
+	return nil
+}
+
+// Record appends an event to the request's audit trail
+func (r *Request) Record(action, by, note string, now time.Time) {
+	r.History = append(r.History, Event{
+		Action: action,
+		By:     by,
+		At:     timestamp.New(now),
+		Note:   note,
+	})
+}
+
+// Queue holds held requests. Decided requests are kept as the audit trail.
+type Queue struct {
+	NextID   int       `toml:"next_id"`
+	Requests []Request `toml:"requests"`
+}
+
+// Load reads the queue from path. A missing file is an empty queue.
+func Load(path string) (*Queue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Queue{NextID: 1}, nil
+		}
+		return nil, fmt.Errorf("failed to read approval queue: %w", err)
+	}
+
+	var q Queue
+	if err := toml.Unmarshal(data, &q); err != nil {
+		return nil, fmt.Errorf("failed to parse approval queue: %w", err)
+	}
+	if q.NextID < 1 {
+		q.NextID = 1
+	}
+	return &q, nil
+}
+
+// Save writes the queue to path
+func (q *Queue) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(q); err != nil {
+		return fmt.Errorf("failed to marshal approval queue: %w", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write approval queue: %w", err)
+	}
+	return nil
+}
+
+// Update locks the queue at path, loads it, applies fn and saves the result
+// unless fn fails. Runs that queue requests and reviewers deciding them go
+// through Update, so neither loses the other's changes.
+func Update(path string, fn func(q *Queue) error) error {
+	unlock, err := filelock.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	q, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(q); err != nil {
+		return err
+	}
+	return q.Save(path)
+}
+
+// Add queues a request and returns it with its assigned ID
+func (q *Queue) Add(r Request, now time.Time) *Request {
+	r.ID = q.NextID
+	r.Status = StatusPending
+	r.CreatedAt = timestamp.New(now)
+	r.Record("queued", r.Source, "", now)
+	q.NextID++
+	q.Requests = append(q.Requests, r)
+	return &q.Requests[len(q.Requests)-1]
+}
+
+// Get returns the request with the given ID, or nil
+func (q *Queue) Get(id int) *Request {
+	for i := range q.Requests {
+		if q.Requests[i].ID == id {
+			return &q.Requests[i]
+		}
+	}
+	return nil
+}
+
+// Pending returns the requests awaiting a decision, oldest first
+func (q *Queue) Pending() []Request {
+	var pending []Request
+	for _, r := range q.Requests {
+		if r.Status == StatusPending {
+			pending = append(pending, r)
+		}
+	}
+	return pending
+}
+
+// Holder queues posts, comments and votes in the approval queue at Path
+// instead of sending them. It implements moltbook.Holder.
+type Holder struct {
+	Path string
+
+	// Source names the run that made the request, for the audit trail
+	Source string
+}
+
+// HoldPost queues a post
+func (h *Holder) HoldPost(req *moltbook.CreatePostRequest) error {
+	return h.hold(Request{Kind: KindPost}, req)
+}
+
+// HoldComment queues a comment on postID
+func (h *Holder) HoldComment(postID string, req *moltbook.CreateCommentRequest) error {
+	return h.hold(Request{Kind: KindComment, PostID: postID}, req)
+}
+
+// HoldVote queues a vote
+func (h *Holder) HoldVote(req *moltbook.VoteRequest) error {
+	return h.hold(Request{Kind: KindVote}, req)
+}
+
+func (h *Holder) hold(r Request, body interface{}) error {
+	if err := r.SetBody(body); err != nil {
+		return err
+	}
+	r.Source = h.Source
+
+	var id int
+	err := Update(h.Path, func(q *Queue) error {
+		id = q.Add(r, time.Now()).ID
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return &HeldError{ID: id}
+}
+
+// HeldError reports that a request was queued for approval
+type HeldError struct {
+	ID int
+}
+
+func (e *HeldError) Error() string {
+	return fmt.Sprintf("queued for approval as request %d", e.ID)
+}
+
+// Unwrap makes HeldError match moltbook.ErrHeld
+func (e *HeldError) Unwrap() error {
+	return moltbook.ErrHeld
+}
+
+// IsHeld reports whether err means the request was queued for approval
+func IsHeld(err error) bool {
+	return errors.Is(err, moltbook.ErrHeld)
+}
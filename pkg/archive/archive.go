@@ -0,0 +1,367 @@
+// Package archive keeps a local mirror of Moltbook posts and comments, with
+// a history of changes to their scores and content and a full-text index of
+// the posts.
+package archive
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/timestamp"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	postsBucket    = []byte("posts")
+	commentsBucket = []byte("comments")
+	historyBucket  = []byte("history")
+	cursorsBucket  = []byte("cursors")
+)
+
+// ErrInUse is returned when another process has the archive open
+var ErrInUse = errors.New("archive is in use by another moltgo process")
+
+// Kinds of archived objects
+const (
+	KindPost    = "post"
+	KindComment = "comment"
+)
+
+// PostRecord is an archived post
+type PostRecord struct {
+	Post      moltbook.Post  `json:"post"`
+	FirstSeen timestamp.Time `json:"first_seen"`
+	LastSeen  timestamp.Time `json:"last_seen"`
+
+	// Comment count when the comment tree was last archived
+	CommentsSynced int            `json:"comments_synced"`
+	CommentsAt     timestamp.Time `json:"comments_at,omitempty"`
+}
+
+// CommentRecord is an archived comment. Replies are stored as comments of
+// their own, linked by ParentID.
+type CommentRecord struct {
+	Comment   moltbook.Comment `json:"comment"`
+	FirstSeen timestamp.Time   `json:"first_seen"`
+	LastSeen  timestamp.Time   `json:"last_seen"`
+}
+
+// Change is an edit to an archived object seen between two syncs
+type Change struct {
+	Kind  string         `json:"kind"`
+	ID    string         `json:"id"`
+	Field string         `json:"field"`
+	Old   string         `json:"old"`
+	New   string         `json:"new"`
+	At    timestamp.Time `json:"at"`
+}
+
+// Stats counts the archived objects
+type Stats struct {
+	Posts    int
+	Comments int
+	Changes  int
+	Indexed  int
+}
+
+// DB is an open archive
+type DB struct {
+	db *bolt.DB
+}
+
+// Open opens the archive at path, creating it if needed
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, ErrInUse
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{postsBucket, commentsBucket, historyBucket, cursorsBucket, vectorsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return createIndex(tx)
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize archive: %w", err)
+	}
+	return &DB{db: db}, nil
+}
+
+// Close closes the archive
+func (a *DB) Close() error {
+	return a.db.Close()
+}
+
+// PutPost stores a post, recording what changed since it was last stored.
+// It returns the previous record, or nil if the post is new.
+func (a *DB) PutPost(post moltbook.Post, now time.Time) (*PostRecord, error) {
+	var prev *PostRecord
+	err := a.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(postsBucket)
+		ts := timestamp.New(now)
+		rec := PostRecord{Post: post, FirstSeen: ts, LastSeen: ts}
+
+		if data := b.Get([]byte(post.ID)); data != nil {
+			prev = &PostRecord{}
+			if err := json.Unmarshal(data, prev); err != nil {
+				return fmt.Errorf("failed to parse archived post %s: %w", post.ID, err)
+			}
+			rec.FirstSeen = prev.FirstSeen
+			rec.CommentsSynced = prev.CommentsSynced
+			rec.CommentsAt = prev.CommentsAt
+			mergePost(&rec.Post, &prev.Post)
+			if rec.Post.Title != prev.Post.Title || rec.Post.Content != prev.Post.Content {
+				if err := dropVectors(tx, post.ID); err != nil {
+					return err
+				}
+			}
+
+			for _, c := range diffPost(&prev.Post, &rec.Post) {
+				c.At = ts
+				if err := putChange(tx, c); err != nil {
+					return err
+				}
+			}
+		}
+		if err := putJSON(b, post.ID, rec); err != nil {
+			return err
+		}
+		return indexPost(tx, &rec.Post)
+	})
+	return prev, err
+}
+
+// mergePost keeps fields a partial listing left out, such as content in
+// search results
+func mergePost(post, prev *moltbook.Post) {
+	if post.Content == "" {
+		post.Content = prev.Content
+	}
+	if post.URL == "" {
+		post.URL = prev.URL
+	}
+}
+
+func diffPost(old, new *moltbook.Post) []Change {
+	var changes []Change
+	add := func(field, o, n string) {
+		if o != n {
+			changes = append(changes, Change{Kind: KindPost, ID: new.ID, Field: field, Old: o, New: n})
+		}
+	}
+	add("title", old.Title, new.Title)
+	add("content", old.Content, new.Content)
+	add("url", old.URL, new.URL)
+	add("score", strconv.Itoa(old.Score), strconv.Itoa(new.Score))
+	add("num_comments", strconv.Itoa(old.NumComments), strconv.Itoa(new.NumComments))
+	return changes
+}
+
+// PutComments stores a post's comment tree, recording edits to comments
+// already archived, and notes the comment count it was synced at
+func (a *DB) PutComments(postID string, comments []moltbook.Comment, now time.Time) error {
+	return a.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(commentsBucket)
+		ts := timestamp.New(now)
+
+		count := 0
+		var put func(comments []moltbook.Comment, parentID string) error
+		put = func(comments []moltbook.Comment, parentID string) error {
+			for _, c := range comments {
+				count++
+				replies := c.Replies
+				c.Replies = nil
+				if c.PostID == "" {
+					c.PostID = postID
+				}
+				if c.ParentID == "" {
+					c.ParentID = parentID
+				}
+
+				key := commentKey(postID, c.ID)
+				rec := CommentRecord{Comment: c, FirstSeen: ts, LastSeen: ts}
+				if data := b.Get(key); data != nil {
+					var prev CommentRecord
+					if err := json.Unmarshal(data, &prev); err != nil {
+						return fmt.Errorf("failed to parse archived comment %s: %w", c.ID, err)
+					}
+					rec.FirstSeen = prev.FirstSeen
+					for _, ch := range diffComment(&prev.Comment, &c) {
+						ch.At = ts
+						if err := putChange(tx, ch); err != nil {
+							return err
+						}
+					}
+				}
+				if err := putJSON(b, string(key), rec); err != nil {
+					return err
+				}
+				if err := put(replies, c.ID); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if err := put(comments, ""); err != nil {
+			return err
+		}
+
+		// Note how far the comment tree is archived
+		posts := tx.Bucket(postsBucket)
+		data := posts.Get([]byte(postID))
+		if data == nil {
+			return nil
+		}
+		var rec PostRecord
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("failed to parse archived post %s: %w", postID, err)
+		}
+		rec.CommentsSynced = max(rec.Post.NumComments, count)
+		rec.CommentsAt = ts
+		return putJSON(posts, postID, rec)
+	})
+}
+
+func diffComment(old, new *moltbook.Comment) []Change {
+	var changes []Change
+	if old.Content != new.Content {
+		changes = append(changes, Change{Kind: KindComment, ID: new.ID, Field: "content", Old: old.Content, New: new.Content})
+	}
+	if old.Score != new.Score {
+		changes = append(changes, Change{Kind: KindComment, ID: new.ID, Field: "score", Old: strconv.Itoa(old.Score), New: strconv.Itoa(new.Score)})
+	}
+	return changes
+}
+
+func commentKey(postID, commentID string) []byte {
+	return []byte(postID + "/" + commentID)
+}
+
+// putChange appends a change to the history, keyed by object so that an
+// object's history can be read with a prefix scan
+func putChange(tx *bolt.Tx, c Change) error {
+	b := tx.Bucket(historyBucket)
+	seq, err := b.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 0, len(c.Kind)+len(c.ID)+10)
+	key = append(key, historyPrefix(c.Kind, c.ID)...)
+	key = binary.BigEndian.AppendUint64(key, seq)
+	return putJSON(b, string(key), c)
+}
+
+func historyPrefix(kind, id string) []byte {
+	return []byte(kind + ":" + id + "\x00")
+}
+
+func putJSON(b *bolt.Bucket, key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(key), data)
+}
+
+// Post returns an archived post, or nil if it is not archived
+func (a *DB) Post(id string) (*PostRecord, error) {
+	var rec *PostRecord
+	err := a.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(postsBucket).Get([]byte(id))
+		if data == nil {
+			return nil
+		}
+		rec = &PostRecord{}
+		return json.Unmarshal(data, rec)
+	})
+	return rec, err
+}
+
+// Posts calls fn for every archived post until fn returns false
+func (a *DB) Posts(fn func(rec *PostRecord) bool) error {
+	return a.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(postsBucket).Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			var rec PostRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("failed to parse archived post %s: %w", k, err)
+			}
+			if !fn(&rec) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// Comments returns the archived comments on a post
+func (a *DB) Comments(postID string) ([]CommentRecord, error) {
+	var comments []CommentRecord
+	err := a.db.View(func(tx *bolt.Tx) error {
+		prefix := commentKey(postID, "")
+		c := tx.Bucket(commentsBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && hasPrefix(k, prefix); k, v = c.Next() {
+			var rec CommentRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("failed to parse archived comment %s: %w", k, err)
+			}
+			comments = append(comments, rec)
+		}
+		return nil
+	})
+	return comments, err
+}
+
+// History returns the recorded changes to an object, oldest first
+func (a *DB) History(kind, id string) ([]Change, error) {
+	var changes []Change
+	err := a.db.View(func(tx *bolt.Tx) error {
+		prefix := historyPrefix(kind, id)
+		c := tx.Bucket(historyBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && hasPrefix(k, prefix); k, v = c.Next() {
+			var ch Change
+			if err := json.Unmarshal(v, &ch); err != nil {
+				return fmt.Errorf("failed to parse change: %w", err)
+			}
+			changes = append(changes, ch)
+		}
+		return nil
+	})
+	return changes, err
+}
+
+// Stats counts the archived objects
+func (a *DB) Stats() (Stats, error) {
+	var s Stats
+	err := a.db.View(func(tx *bolt.Tx) error {
+		s.Posts = tx.Bucket(postsBucket).Stats().KeyN
+		s.Comments = tx.Bucket(commentsBucket).Stats().KeyN
+		s.Changes = tx.Bucket(historyBucket).Stats().KeyN
+		totals, err := readTotals(tx)
+		s.Indexed = totals.Docs
+		return err
+	})
+	return s, err
+}
+
+func hasPrefix(b, prefix []byte) bool {
+	return len(b) >= len(prefix) && string(b[:len(prefix)]) == string(prefix)
+}
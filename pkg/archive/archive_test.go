@@ -0,0 +1,263 @@
+package archive
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// openTest opens an empty archive that is closed when the test ends
+func openTest(t *testing.T) *DB {
+	t.Helper()
+	db, err := Open(filepath.Join(t.TempDir(), "archive.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// fakeEmbedder embeds each text as its length and a constant, and counts
+// the texts it embedded
+type fakeEmbedder struct {
+	embedded int
+}
+
+func (e *fakeEmbedder) Name() string { return "fake" }
+
+func (e *fakeEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float32{float32(len(text)), 1}
+	}
+	e.embedded += len(texts)
+	return vectors, nil
+}
+
+func TestPutPostRecordsChanges(t *testing.T) {
+	db := openTest(t)
+	post := moltbook.Post{ID: "p1", Submolt: "general", Title: "Hello", Content: "First post", Score: 1}
+
+	prev, err := db.PutPost(post, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev != nil {
+		t.Fatalf("PutPost of a new post returned previous record %+v", prev)
+	}
+
+	later := testNow.Add(time.Hour)
+	edited := post
+	edited.Title = "Hello again"
+	edited.Score = 5
+	prev, err = db.PutPost(edited, later)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if prev == nil || prev.Post.Title != "Hello" {
+		t.Fatalf("PutPost returned previous record %+v, want the first version", prev)
+	}
+
+	rec, err := db.Post("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Post.Title != "Hello again" || !rec.FirstSeen.Equal(testNow) || !rec.LastSeen.Equal(later) {
+		t.Errorf("archived %+v, want the edit first seen at the first sync and last seen at the second", rec)
+	}
+
+	changes, err := db.History(KindPost, "p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Change{
+		{Kind: KindPost, ID: "p1", Field: "title", Old: "Hello", New: "Hello again"},
+		{Kind: KindPost, ID: "p1", Field: "score", Old: "1", New: "5"},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("history = %+v, want %+v", changes, want)
+	}
+	for i, c := range changes {
+		if c.Kind != want[i].Kind || c.ID != want[i].ID || c.Field != want[i].Field || c.Old != want[i].Old || c.New != want[i].New || !c.At.Equal(later) {
+			t.Errorf("change %d = %+v, want %+v at %v", i, c, want[i], later)
+		}
+	}
+
+	// Storing the same post again changes nothing
+	if _, err := db.PutPost(edited, later.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if changes, _ := db.History(KindPost, "p1"); len(changes) != 2 {
+		t.Errorf("an unchanged post added %d changes", len(changes)-2)
+	}
+}
+
+func TestPutPostKeepsFieldsPartialListingsLeaveOut(t *testing.T) {
+	db := openTest(t)
+	if _, err := db.PutPost(moltbook.Post{ID: "p1", Title: "Link", Content: "Body", URL: "https://example.com"}, testNow); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.PutPost(moltbook.Post{ID: "p1", Title: "Link"}, testNow); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := db.Post("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Post.Content != "Body" || rec.Post.URL != "https://example.com" {
+		t.Errorf("archived %+v, want content and URL kept", rec.Post)
+	}
+	if changes, _ := db.History(KindPost, "p1"); len(changes) != 0 {
+		t.Errorf("a partial listing recorded changes %+v", changes)
+	}
+}
+
+func TestPutPostDropsStaleVectors(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+	e := &fakeEmbedder{}
+	post := moltbook.Post{ID: "p1", Title: "Hello", Content: "First post"}
+	if _, err := db.PutPost(post, testNow); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.EmbedPosts(ctx, e, []string{"p1", "missing"}); err != nil {
+		t.Fatal(err)
+	}
+	if vec, _ := db.Vector("fake", "p1"); vec == nil {
+		t.Fatal("post has no vector after EmbedPosts")
+	}
+
+	// A score change keeps the vector; the text it was computed from is the same
+	post.Score = 3
+	if _, err := db.PutPost(post, testNow); err != nil {
+		t.Fatal(err)
+	}
+	if vec, _ := db.Vector("fake", "p1"); vec == nil {
+		t.Error("a score change dropped the post's vector")
+	}
+	if err := db.EmbedPosts(ctx, e, []string{"p1"}); err != nil {
+		t.Fatal(err)
+	}
+	if e.embedded != 1 {
+		t.Errorf("embedded %d texts, want a post with a vector skipped", e.embedded)
+	}
+
+	post.Content = "Edited"
+	if _, err := db.PutPost(post, testNow); err != nil {
+		t.Fatal(err)
+	}
+	if vec, _ := db.Vector("fake", "p1"); vec != nil {
+		t.Error("an edit kept the vector of the old content")
+	}
+
+	n, err := db.EmbedMissing(ctx, e, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	vec, _ := db.Vector("fake", "p1")
+	if n != 1 || len(vec) != 2 || vec[0] != float32(len(embedText(&post))) {
+		t.Errorf("EmbedMissing embedded %d posts and stored %v, want the edited post", n, vec)
+	}
+}
+
+func TestPutComments(t *testing.T) {
+	db := openTest(t)
+	if _, err := db.PutPost(moltbook.Post{ID: "p1", Title: "Hello", NumComments: 3}, testNow); err != nil {
+		t.Fatal(err)
+	}
+	tree := []moltbook.Comment{
+		{ID: "c1", Content: "First", Score: 1, Replies: []moltbook.Comment{
+			{ID: "c2", Content: "Reply"},
+		}},
+		{ID: "c3", Content: "Second"},
+	}
+	if err := db.PutComments("p1", tree, testNow); err != nil {
+		t.Fatal(err)
+	}
+
+	comments, err := db.Comments("p1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parents := map[string]string{}
+	for _, c := range comments {
+		if c.Comment.PostID != "p1" || len(c.Comment.Replies) != 0 {
+			t.Errorf("archived comment %+v, want it flattened under p1", c.Comment)
+		}
+		parents[c.Comment.ID] = c.Comment.ParentID
+	}
+	if len(parents) != 3 || parents["c1"] != "" || parents["c2"] != "c1" || parents["c3"] != "" {
+		t.Errorf("archived comments with parents %v", parents)
+	}
+
+	rec, _ := db.Post("p1")
+	if rec.CommentsSynced != 3 || !rec.CommentsAt.Equal(testNow) {
+		t.Errorf("comments synced %d at %v, want 3 at %v", rec.CommentsSynced, rec.CommentsAt, testNow)
+	}
+
+	tree[0].Content = "First, edited"
+	tree[0].Score = 4
+	if err := db.PutComments("p1", tree, testNow.Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	changes, err := db.History(KindComment, "c1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 2 || changes[0].Field != "content" || changes[0].New != "First, edited" || changes[1].Field != "score" || changes[1].New != "4" {
+		t.Errorf("comment history = %+v, want the content and score edits", changes)
+	}
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Posts != 1 || stats.Comments != 3 || stats.Changes != 2 || stats.Indexed != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestArchiveSurvivesReopening(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "archive.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.PutPost(moltbook.Post{ID: "p1", Title: "Persistent crabs"}, testNow); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.PutCursor(&Cursor{Submolt: "general", Offset: 25, Pending: []string{"p1"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err = Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if rec, _ := db.Post("p1"); rec == nil {
+		t.Error("post is gone after reopening")
+	}
+	if hits, _ := db.Search(ParseQuery("crabs")); len(hits) != 1 {
+		t.Errorf("search after reopening found %d posts, want 1", len(hits))
+	}
+	cur, err := db.Cursor("general")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur == nil || cur.Offset != 25 || len(cur.Pending) != 1 {
+		t.Errorf("cursor after reopening = %+v", cur)
+	}
+	if cur, _ := db.Cursor("other"); cur != nil {
+		t.Errorf("cursor of a submolt never synced = %+v, want nil", cur)
+	}
+}
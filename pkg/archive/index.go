@@ -0,0 +1,376 @@
+package archive
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	bolt "go.etcd.io/bbolt"
+)
+
+// The full-text index is an inverted index of post titles and content.
+// Each term has a bucket of postings mapping post IDs to the positions the
+// term occurs at, which allows phrase queries.
+var (
+	termsBucket = []byte("index_terms")
+	docsBucket  = []byte("index_docs")
+	metaBucket  = []byte("index_meta")
+	totalsKey   = []byte("totals")
+)
+
+// BM25 parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// indexDoc is the index's record of a post
+type indexDoc struct {
+	Length int      `json:"length"`
+	Terms  []string `json:"terms"`
+}
+
+type indexTotals struct {
+	Docs   int `json:"docs"`
+	Length int `json:"length"`
+}
+
+// Tokenize splits text into lowercase terms of letters and digits
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// postTerms returns the term positions in a post. Title and content are
+// separated by a gap so phrases do not span them.
+func postTerms(post *moltbook.Post) (map[string][]int, int) {
+	positions := make(map[string][]int)
+	pos := 0
+	for _, field := range []string{post.Title, post.Content} {
+		for _, term := range Tokenize(field) {
+			positions[term] = append(positions[term], pos)
+			pos++
+		}
+		pos++
+	}
+	return positions, pos
+}
+
+// indexPost replaces a post's entry in the index
+func indexPost(tx *bolt.Tx, post *moltbook.Post) error {
+	docs := tx.Bucket(docsBucket)
+	terms := tx.Bucket(termsBucket)
+	totals, err := readTotals(tx)
+	if err != nil {
+		return err
+	}
+
+	id := []byte(post.ID)
+	if data := docs.Get(id); data != nil {
+		var old indexDoc
+		if err := json.Unmarshal(data, &old); err != nil {
+			return fmt.Errorf("failed to parse index entry for %s: %w", post.ID, err)
+		}
+		for _, term := range old.Terms {
+			if b := terms.Bucket([]byte(term)); b != nil {
+				if err := b.Delete(id); err != nil {
+					return err
+				}
+			}
+		}
+		totals.Docs--
+		totals.Length -= old.Length
+	}
+
+	positions, length := postTerms(post)
+	doc := indexDoc{Length: length}
+	for term, pos := range positions {
+		b, err := terms.CreateBucketIfNotExists([]byte(term))
+		if err != nil {
+			return err
+		}
+		if err := b.Put(id, encodePositions(pos)); err != nil {
+			return err
+		}
+		doc.Terms = append(doc.Terms, term)
+	}
+	sort.Strings(doc.Terms)
+
+	if err := putJSON(docs, post.ID, doc); err != nil {
+		return err
+	}
+	totals.Docs++
+	totals.Length += length
+	return putJSON(tx.Bucket(metaBucket), string(totalsKey), totals)
+}
+
+func readTotals(tx *bolt.Tx) (indexTotals, error) {
+	var totals indexTotals
+	if data := tx.Bucket(metaBucket).Get(totalsKey); data != nil {
+		if err := json.Unmarshal(data, &totals); err != nil {
+			return totals, fmt.Errorf("failed to parse index totals: %w", err)
+		}
+	}
+	return totals, nil
+}
+
+// createIndex creates the index buckets, indexing existing posts if the
+// archive predates the index
+func createIndex(tx *bolt.Tx) error {
+	if tx.Bucket(docsBucket) != nil {
+		return nil
+	}
+	for _, name := range [][]byte{termsBucket, docsBucket, metaBucket} {
+		if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+			return err
+		}
+	}
+	return tx.Bucket(postsBucket).ForEach(func(k, v []byte) error {
+		var rec PostRecord
+		if err := json.Unmarshal(v, &rec); err != nil {
+			return fmt.Errorf("failed to parse archived post %s: %w", k, err)
+		}
+		return indexPost(tx, &rec.Post)
+	})
+}
+
+func encodePositions(positions []int) []byte {
+	buf := make([]byte, 0, len(positions)*2)
+	prev := 0
+	for _, p := range positions {
+		buf = binary.AppendUvarint(buf, uint64(p-prev))
+		prev = p
+	}
+	return buf
+}
+
+func decodePositions(data []byte) []int {
+	var positions []int
+	prev := 0
+	for len(data) > 0 {
+		delta, n := binary.Uvarint(data)
+		if n <= 0 {
+			break
+		}
+		prev += int(delta)
+		positions = append(positions, prev)
+		data = data[n:]
+	}
+	return positions
+}
+
+// Query is a local search
+type Query struct {
+	// Terms rank results; a post must contain at least one of them
+	Terms []string
+	// Phrases must all occur in a post, word for word
+	Phrases [][]string
+
+	Author   string
+	Submolt  string
+	Since    time.Time
+	Until    time.Time
+	MinScore *int
+
+	Limit int
+}
+
+// ParseQuery reads search text into terms and "quoted phrases"
+func ParseQuery(text string) Query {
+	var q Query
+	parts := strings.Split(text, `"`)
+	for i, part := range parts {
+		tokens := Tokenize(part)
+		if i%2 == 1 && len(tokens) > 0 {
+			q.Phrases = append(q.Phrases, tokens)
+			continue
+		}
+		q.Terms = append(q.Terms, tokens...)
+	}
+	return q
+}
+
+// Hit is a search result
+type Hit struct {
+	Post  moltbook.Post
+	Score float64
+}
+
+// Search ranks the archived posts matching q by BM25. A query without
+// terms or phrases lists the posts matching its filters, newest first.
+func (a *DB) Search(q Query) ([]Hit, error) {
+	var hits []Hit
+	err := a.db.View(func(tx *bolt.Tx) error {
+		if len(q.Terms) == 0 && len(q.Phrases) == 0 {
+			return tx.Bucket(postsBucket).ForEach(func(k, v []byte) error {
+				var rec PostRecord
+				if err := json.Unmarshal(v, &rec); err != nil {
+					return fmt.Errorf("failed to parse archived post %s: %w", k, err)
+				}
+				if q.matches(&rec.Post) {
+					hits = append(hits, Hit{Post: rec.Post})
+				}
+				return nil
+			})
+		}
+
+		totals, err := readTotals(tx)
+		if err != nil {
+			return err
+		}
+		if totals.Docs == 0 {
+			return nil
+		}
+		avgLength := float64(totals.Length) / float64(totals.Docs)
+
+		// Postings of every query term: term -> post ID -> positions
+		postings := make(map[string]map[string][]int)
+		all := append([]string{}, q.Terms...)
+		for _, phrase := range q.Phrases {
+			all = append(all, phrase...)
+		}
+		for _, term := range all {
+			if _, ok := postings[term]; ok {
+				continue
+			}
+			postings[term] = make(map[string][]int)
+			b := tx.Bucket(termsBucket).Bucket([]byte(term))
+			if b == nil {
+				continue
+			}
+			err := b.ForEach(func(k, v []byte) error {
+				postings[term][string(k)] = decodePositions(v)
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+		}
+
+		// Candidates contain every phrase, and a term if there are no phrases
+		candidates := make(map[string]bool)
+		if len(q.Phrases) > 0 {
+			for id := range postings[q.Phrases[0][0]] {
+				candidates[id] = true
+			}
+			for id := range candidates {
+				for _, phrase := range q.Phrases {
+					if !containsPhrase(postings, phrase, id) {
+						delete(candidates, id)
+						break
+					}
+				}
+			}
+		} else {
+			for _, term := range q.Terms {
+				for id := range postings[term] {
+					candidates[id] = true
+				}
+			}
+		}
+
+		docs := tx.Bucket(docsBucket)
+		posts := tx.Bucket(postsBucket)
+		for id := range candidates {
+			var doc indexDoc
+			if err := json.Unmarshal(docs.Get([]byte(id)), &doc); err != nil {
+				return fmt.Errorf("failed to parse index entry for %s: %w", id, err)
+			}
+			var rec PostRecord
+			if err := json.Unmarshal(posts.Get([]byte(id)), &rec); err != nil {
+				return fmt.Errorf("failed to parse archived post %s: %w", id, err)
+			}
+			if !q.matches(&rec.Post) {
+				continue
+			}
+
+			score := 0.0
+			seen := make(map[string]bool)
+			for _, term := range all {
+				if seen[term] {
+					continue
+				}
+				seen[term] = true
+				tf := float64(len(postings[term][id]))
+				if tf == 0 {
+					continue
+				}
+				df := float64(len(postings[term]))
+				idf := math.Log(1 + (float64(totals.Docs)-df+0.5)/(df+0.5))
+				norm := bm25K1 * (1 - bm25B + bm25B*float64(doc.Length)/avgLength)
+				score += idf * tf * (bm25K1 + 1) / (tf + norm)
+			}
+			hits = append(hits, Hit{Post: rec.Post, Score: score})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].Post.CreatedAt.After(hits[j].Post.CreatedAt.Time)
+	})
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	return hits, nil
+}
+
+// containsPhrase reports whether the phrase occurs in post id
+func containsPhrase(postings map[string]map[string][]int, phrase []string, id string) bool {
+	for _, start := range postings[phrase[0]][id] {
+		found := true
+		for i, term := range phrase[1:] {
+			if !hasPosition(postings[term][id], start+i+1) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPosition(positions []int, p int) bool {
+	i := sort.SearchInts(positions, p)
+	return i < len(positions) && positions[i] == p
+}
+
+// matches applies the query's filters to a post
+func (q *Query) matches(post *moltbook.Post) bool {
+	if q.Author != "" && !strings.EqualFold(q.Author, post.Author) {
+		return false
+	}
+	if q.Submolt != "" && !strings.EqualFold(strings.TrimPrefix(q.Submolt, "/"), post.Submolt) {
+		return false
+	}
+	if q.MinScore != nil && post.Score < *q.MinScore {
+		return false
+	}
+	if !q.Since.IsZero() || !q.Until.IsZero() {
+		created := post.CreatedAt.Time
+		if created.IsZero() {
+			return false
+		}
+		if !q.Since.IsZero() && created.Before(q.Since) {
+			return false
+		}
+		if !q.Until.IsZero() && !created.Before(q.Until) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package archive
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/timestamp"
+)
+
+// putPosts archives posts created an hour apart, the first oldest
+func putPosts(t *testing.T, db *DB, posts ...moltbook.Post) {
+	t.Helper()
+	for i, post := range posts {
+		post.CreatedAt = timestamp.New(testNow.Add(time.Duration(i) * time.Hour))
+		if _, err := db.PutPost(post, testNow); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func hitIDs(hits []Hit) []string {
+	ids := []string{}
+	for _, h := range hits {
+		ids = append(ids, h.Post.ID)
+	}
+	return ids
+}
+
+func TestTokenize(t *testing.T) {
+	got := Tokenize("Hello, World! Go-1.23 is ÜBER")
+	want := []string{"hello", "world", "go", "1", "23", "is", "über"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tokenize = %q, want %q", got, want)
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		text    string
+		terms   []string
+		phrases [][]string
+	}{
+		{"crab rave", []string{"crab", "rave"}, nil},
+		{`"crab rave" tonight`, []string{"tonight"}, [][]string{{"crab", "rave"}}},
+		{`"a b" c "d"`, []string{"c"}, [][]string{{"a", "b"}, {"d"}}},
+		{`"" empty`, []string{"empty"}, nil},
+		{`"unclosed phrase`, nil, [][]string{{"unclosed", "phrase"}}},
+	}
+	for _, tt := range tests {
+		q := ParseQuery(tt.text)
+		if !reflect.DeepEqual(q.Terms, tt.terms) || !reflect.DeepEqual(q.Phrases, tt.phrases) {
+			t.Errorf("ParseQuery(%q) = %q, %q; want %q, %q", tt.text, q.Terms, q.Phrases, tt.terms, tt.phrases)
+		}
+	}
+}
+
+func TestSearchRanksByBM25(t *testing.T) {
+	db := openTest(t)
+	putPosts(t, db,
+		moltbook.Post{ID: "once", Title: "Crabs", Content: "A long post that mentions molting only once among many other words"},
+		moltbook.Post{ID: "often", Title: "Molting", Content: "Molting, molting and more molting"},
+		moltbook.Post{ID: "none", Title: "Lobsters", Content: "Nothing relevant here"},
+		moltbook.Post{ID: "rare", Title: "Exoskeleton", Content: "Molting and the exoskeleton"},
+	)
+
+	hits, err := db.Search(ParseQuery("molting"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(hits); !reflect.DeepEqual(got, []string{"often", "rare", "once"}) {
+		t.Errorf("molting ranked %v, want the most frequent and shortest first", got)
+	}
+	for i := 1; i < len(hits); i++ {
+		if hits[i].Score > hits[i-1].Score || hits[i].Score <= 0 {
+			t.Errorf("scores are not descending and positive: %v", hits)
+		}
+	}
+
+	// The rarer term outweighs the common one
+	hits, err = db.Search(ParseQuery("molting exoskeleton"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(hits); len(got) != 3 || got[0] != "rare" {
+		t.Errorf("molting exoskeleton ranked %v, want rare first", got)
+	}
+
+	if hits, _ := db.Search(ParseQuery("unheard")); len(hits) != 0 {
+		t.Errorf("a term in no post found %v", hitIDs(hits))
+	}
+}
+
+func TestSearchPhrases(t *testing.T) {
+	db := openTest(t)
+	putPosts(t, db,
+		moltbook.Post{ID: "phrase", Title: "Crab rave tonight"},
+		moltbook.Post{ID: "apart", Title: "Rave about the crab"},
+		moltbook.Post{ID: "split", Title: "About the crab", Content: "Rave reviews"},
+	)
+
+	hits, err := db.Search(ParseQuery(`"crab rave"`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(hits); !reflect.DeepEqual(got, []string{"phrase"}) {
+		t.Errorf(`"crab rave" found %v, want only the post with the words in order, within one field`, got)
+	}
+
+	hits, err = db.Search(ParseQuery(`"crab rave" reviews`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hitIDs(hits); !reflect.DeepEqual(got, []string{"phrase"}) {
+		t.Errorf(`"crab rave" reviews found %v, want the phrase required`, got)
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	db := openTest(t)
+	putPosts(t, db,
+		moltbook.Post{ID: "p0", Author: "Alice", Submolt: "general", Title: "Crabs", Score: 1},
+		moltbook.Post{ID: "p1", Author: "bob", Submolt: "general", Title: "Crabs", Score: 10},
+		moltbook.Post{ID: "p2", Author: "alice", Submolt: "ocean", Title: "Crabs", Score: 5},
+		moltbook.Post{ID: "p3", Author: "alice", Submolt: "general", Title: "Lobsters", Score: 7},
+	)
+	five := 5
+
+	tests := []struct {
+		name string
+		q    Query
+		want []string
+	}{
+		{"author ignores case", Query{Terms: []string{"crabs"}, Author: "ALICE"}, []string{"p2", "p0"}},
+		{"submolt with slash", Query{Terms: []string{"crabs"}, Submolt: "/ocean"}, []string{"p2"}},
+		{"min score", Query{Terms: []string{"crabs"}, MinScore: &five}, []string{"p2", "p1"}},
+		{"since and until", Query{Terms: []string{"crabs"}, Since: testNow.Add(time.Hour), Until: testNow.Add(2 * time.Hour)}, []string{"p1"}},
+		{"limit", Query{Terms: []string{"crabs"}, Limit: 1}, []string{"p2"}},
+		{"filters only, newest first", Query{Author: "alice"}, []string{"p3", "p2", "p0"}},
+		{"no filters", Query{}, []string{"p3", "p2", "p1", "p0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, err := db.Search(tt.q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hitIDs(hits); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("found %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchFollowsEdits(t *testing.T) {
+	db := openTest(t)
+	putPosts(t, db, moltbook.Post{ID: "p1", Title: "Crabs", Content: "Sideways"})
+	putPosts(t, db, moltbook.Post{ID: "p1", Title: "Lobsters", Content: "Forwards"})
+
+	if hits, _ := db.Search(ParseQuery("crabs")); len(hits) != 0 {
+		t.Errorf("the old title still finds %v", hitIDs(hits))
+	}
+	if hits, _ := db.Search(ParseQuery("lobsters")); len(hits) != 1 {
+		t.Errorf("the new title finds %v, want p1", hitIDs(hits))
+	}
+	if stats, _ := db.Stats(); stats.Indexed != 1 {
+		t.Errorf("indexed %d posts after an edit, want 1", stats.Indexed)
+	}
+}
@@ -0,0 +1,297 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/embed"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/timestamp"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Sync defaults
+const (
+	DefaultPageSize = 25
+
+	// DefaultInterval keeps a sync well under the API limit of 100 requests
+	// per minute
+	DefaultInterval = time.Second
+
+	// Wait used when the API rate limits us without saying for how long
+	defaultRetryAfter = time.Minute
+	maxRetries        = 3
+)
+
+// Cursor records the progress of a submolt sync so an interrupted sync can
+// resume where it stopped
+type Cursor struct {
+	Submolt string `json:"submolt"`
+	Offset  int    `json:"offset"`
+
+	// Posts whose comment trees still need to be archived
+	Pending []string `json:"pending,omitempty"`
+
+	StartedAt     timestamp.Time `json:"started_at"`
+	Done          bool           `json:"done"`
+	LastCompleted timestamp.Time `json:"last_completed,omitempty"`
+}
+
+// Cursor returns the sync progress for a submolt, or nil if it was never
+// synced
+func (a *DB) Cursor(submolt string) (*Cursor, error) {
+	var cur *Cursor
+	err := a.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(cursorsBucket).Get([]byte(submolt))
+		if data == nil {
+			return nil
+		}
+		cur = &Cursor{}
+		return json.Unmarshal(data, cur)
+	})
+	return cur, err
+}
+
+// PutCursor saves sync progress
+func (a *DB) PutCursor(cur *Cursor) error {
+	return a.db.Update(func(tx *bolt.Tx) error {
+		return putJSON(tx.Bucket(cursorsBucket), cur.Submolt, cur)
+	})
+}
+
+// Cursors returns the sync progress of every synced submolt
+func (a *DB) Cursors() ([]Cursor, error) {
+	var cursors []Cursor
+	err := a.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(cursorsBucket).ForEach(func(k, v []byte) error {
+			var cur Cursor
+			if err := json.Unmarshal(v, &cur); err != nil {
+				return err
+			}
+			cursors = append(cursors, cur)
+			return nil
+		})
+	})
+	return cursors, err
+}
+
+// Source is where a sync reads posts and comments from
+type Source interface {
+	BrowsePosts(ctx context.Context, req *moltbook.BrowsePostsRequest) ([]moltbook.Post, error)
+	GetComments(ctx context.Context, postID, sort string) ([]moltbook.Comment, error)
+}
+
+// Result summarizes a sync
+type Result struct {
+	Posts    int
+	NewPosts int
+	Threads  int
+	Resumed  bool
+}
+
+// Syncer crawls submolts into the archive. Posts are read newest first; a
+// sync stops at the end of the feed, or once a whole page holds only posts
+// that are already archived unless Full is set. Comment trees are fetched
+// for new posts and posts with new comments.
+type Syncer struct {
+	DB     *DB
+	Source Source
+
+	PageSize int
+	// MaxPages limits the pages read per run; the next run resumes
+	MaxPages int
+	Full     bool
+
+	// Embedder, if set, computes vectors for the archived posts
+	Embedder embed.Provider
+
+	// Interval is the minimum time between requests
+	Interval time.Duration
+
+	Logf func(format string, args ...interface{})
+	Now  func() time.Time
+
+	last time.Time
+}
+
+// Sync archives a submolt, resuming an interrupted sync. On cancellation
+// the progress so far is saved and ctx.Err() is returned.
+func (s *Syncer) Sync(ctx context.Context, submolt string) (Result, error) {
+	var res Result
+	pageSize := s.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+
+	cur, err := s.DB.Cursor(submolt)
+	if err != nil {
+		return res, err
+	}
+	if cur == nil || cur.Done {
+		fresh := &Cursor{Submolt: submolt, StartedAt: timestamp.New(s.now())}
+		if cur != nil {
+			fresh.LastCompleted = cur.LastCompleted
+		}
+		cur = fresh
+	} else {
+		res.Resumed = true
+		s.logf("resuming /%s at offset %d with %d comment thread(s) pending", submolt, cur.Offset, len(cur.Pending))
+	}
+
+	for pages := 0; s.MaxPages <= 0 || pages < s.MaxPages; pages++ {
+		if err := s.syncThreads(ctx, cur, &res); err != nil {
+			return res, err
+		}
+
+		var posts []moltbook.Post
+		err := s.call(ctx, func() (err error) {
+			posts, err = s.Source.BrowsePosts(ctx, &moltbook.BrowsePostsRequest{
+				Submolt: submolt,
+				Sort:    moltbook.SortNew,
+				Limit:   pageSize,
+				Offset:  cur.Offset,
+			})
+			return err
+		})
+		if err != nil {
+			return res, err
+		}
+
+		caughtUp := true
+		for _, post := range posts {
+			prev, err := s.DB.PutPost(post, s.now())
+			if err != nil {
+				return res, err
+			}
+			res.Posts++
+			if prev == nil {
+				res.NewPosts++
+				caughtUp = false
+			}
+			if (prev == nil && post.NumComments > 0) || (prev != nil && post.NumComments != prev.CommentsSynced) {
+				cur.Pending = append(cur.Pending, post.ID)
+			}
+		}
+		cur.Offset += len(posts)
+		if err := s.DB.PutCursor(cur); err != nil {
+			return res, err
+		}
+		s.logf("/%s: archived %d posts (offset %d)", submolt, len(posts), cur.Offset)
+		s.embed(ctx, posts)
+
+		if err := s.syncThreads(ctx, cur, &res); err != nil {
+			return res, err
+		}
+
+		if len(posts) < pageSize || (caughtUp && !s.Full) {
+			cur.Done = true
+			cur.LastCompleted = timestamp.New(s.now())
+			cur.Offset = 0
+			return res, s.DB.PutCursor(cur)
+		}
+	}
+
+	// Page limit reached; the next run continues from the cursor
+	return res, s.DB.PutCursor(cur)
+}
+
+// embed computes vectors for a page of posts. Failures are logged rather
+// than ending the sync; missing vectors are computed when they are needed.
+func (s *Syncer) embed(ctx context.Context, posts []moltbook.Post) {
+	if s.Embedder == nil {
+		return
+	}
+	ids := make([]string, len(posts))
+	for i, post := range posts {
+		ids[i] = post.ID
+	}
+	if err := s.DB.EmbedPosts(ctx, s.Embedder, ids); err != nil {
+		s.logf("%v", err)
+	}
+}
+
+// syncThreads archives the pending comment trees
+func (s *Syncer) syncThreads(ctx context.Context, cur *Cursor, res *Result) error {
+	for len(cur.Pending) > 0 {
+		postID := cur.Pending[0]
+
+		var comments []moltbook.Comment
+		err := s.call(ctx, func() (err error) {
+			comments, err = s.Source.GetComments(ctx, postID, moltbook.SortNew)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		if err := s.DB.PutComments(postID, comments, s.now()); err != nil {
+			return err
+		}
+		res.Threads++
+
+		cur.Pending = cur.Pending[1:]
+		if err := s.DB.PutCursor(cur); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// call makes one request, pacing requests by Interval and waiting out rate
+// limits
+func (s *Syncer) call(ctx context.Context, fn func() error) error {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultInterval
+	}
+
+	for attempt := 0; ; attempt++ {
+		if err := sleep(ctx, time.Until(s.last.Add(interval))); err != nil {
+			return err
+		}
+		s.last = time.Now()
+
+		err := fn()
+		var limited *moltbook.RateLimitError
+		if !errors.As(err, &limited) || attempt >= maxRetries {
+			return err
+		}
+
+		wait := limited.RetryAfter
+		if wait <= 0 {
+			wait = defaultRetryAfter
+		}
+		s.logf("rate limited, waiting %s", wait)
+		if err := sleep(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (s *Syncer) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s *Syncer) logf(format string, args ...interface{}) {
+	if s.Logf != nil {
+		s.Logf(format, args...)
+	}
+}
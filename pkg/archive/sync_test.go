@@ -0,0 +1,223 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+)
+
+// fakeSource serves a submolt's posts, newest first, and their comments.
+// errs holds errors returned once by the next request for a key:
+// "browse" or a post ID.
+type fakeSource struct {
+	posts    []moltbook.Post
+	comments map[string][]moltbook.Comment
+	errs     map[string]error
+	calls    []string
+}
+
+func (s *fakeSource) fail(key string) error {
+	err := s.errs[key]
+	delete(s.errs, key)
+	return err
+}
+
+func (s *fakeSource) BrowsePosts(ctx context.Context, req *moltbook.BrowsePostsRequest) ([]moltbook.Post, error) {
+	s.calls = append(s.calls, fmt.Sprintf("browse %s offset=%d", req.Submolt, req.Offset))
+	if err := s.fail("browse"); err != nil {
+		return nil, err
+	}
+	start := min(req.Offset, len(s.posts))
+	end := min(start+req.Limit, len(s.posts))
+	return append([]moltbook.Post(nil), s.posts[start:end]...), nil
+}
+
+func (s *fakeSource) GetComments(ctx context.Context, postID, sort string) ([]moltbook.Comment, error) {
+	s.calls = append(s.calls, "comments "+postID)
+	if err := s.fail(postID); err != nil {
+		return nil, err
+	}
+	return s.comments[postID], nil
+}
+
+// newSource returns a source of n posts, each with one comment; p<n> is
+// the newest
+func newSource(n int) *fakeSource {
+	s := &fakeSource{comments: map[string][]moltbook.Comment{}, errs: map[string]error{}}
+	for i := n; i >= 1; i-- {
+		id := fmt.Sprintf("p%d", i)
+		s.posts = append(s.posts, moltbook.Post{ID: id, Submolt: "general", Title: "Post " + id, NumComments: 1})
+		s.comments[id] = []moltbook.Comment{{ID: "c" + id, Content: "On " + id}}
+	}
+	return s
+}
+
+func newSyncer(db *DB, src Source) *Syncer {
+	return &Syncer{
+		DB:       db,
+		Source:   src,
+		PageSize: 2,
+		Interval: time.Nanosecond,
+		Now:      func() time.Time { return testNow },
+	}
+}
+
+func TestSyncResumesAnInterruptedSync(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+	src := newSource(3)
+	src.errs["p2"] = errors.New("connection reset")
+	s := newSyncer(db, src)
+
+	// The sync stops at p2's comments, after archiving the first page
+	if _, err := s.Sync(ctx, "general"); err == nil || err.Error() != "connection reset" {
+		t.Fatalf("Sync = %v, want the failed request's error", err)
+	}
+	cur, err := db.Cursor("general")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur.Done || cur.Offset != 2 || !reflect.DeepEqual(cur.Pending, []string{"p2"}) {
+		t.Fatalf("cursor after the interruption = %+v, want offset 2 with p2 pending", cur)
+	}
+
+	src.calls = nil
+	res, err := s.Sync(ctx, "general")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"comments p2", "browse general offset=2", "comments p1"}
+	if !reflect.DeepEqual(src.calls, want) {
+		t.Errorf("resumed sync made requests %q, want %q", src.calls, want)
+	}
+	if !res.Resumed || res.Posts != 1 || res.NewPosts != 1 || res.Threads != 2 {
+		t.Errorf("resumed sync = %+v", res)
+	}
+
+	cur, _ = db.Cursor("general")
+	if !cur.Done || cur.Offset != 0 || len(cur.Pending) != 0 || !cur.LastCompleted.Equal(testNow) {
+		t.Errorf("cursor after the sync completed = %+v", cur)
+	}
+	if stats, _ := db.Stats(); stats.Posts != 3 || stats.Comments != 3 {
+		t.Errorf("archived %+v, want every post and comment", stats)
+	}
+}
+
+func TestSyncStopsOnceCaughtUp(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+	src := newSource(5)
+	s := newSyncer(db, src)
+	if _, err := s.Sync(ctx, "general"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new post and a new comment on p5 since the last sync
+	src.posts = append([]moltbook.Post{{ID: "p6", Submolt: "general", Title: "Post p6"}}, src.posts...)
+	src.posts[1].NumComments = 2
+	src.posts[1].Score = 3
+	src.calls = nil
+	res, err := s.Sync(ctx, "general")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"browse general offset=0", "comments p5", "browse general offset=2"}
+	if !reflect.DeepEqual(src.calls, want) {
+		t.Errorf("incremental sync made requests %q, want %q", src.calls, want)
+	}
+	if res.Resumed || res.NewPosts != 1 || res.Threads != 1 {
+		t.Errorf("incremental sync = %+v", res)
+	}
+	if changes, _ := db.History(KindPost, "p5"); len(changes) != 2 {
+		t.Errorf("p5 history = %+v, want its score and comment count changes", changes)
+	}
+
+	// With Full set the whole feed is read again, up to an empty page
+	src.calls = nil
+	s.Full = true
+	res, err = s.Sync(ctx, "general")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Posts != 6 || res.NewPosts != 0 || len(src.calls) != 4 {
+		t.Errorf("full sync = %+v with requests %q", res, src.calls)
+	}
+}
+
+func TestSyncPageLimit(t *testing.T) {
+	ctx := context.Background()
+	db := openTest(t)
+	src := newSource(5)
+	s := newSyncer(db, src)
+	s.MaxPages = 1
+
+	for _, wantOffset := range []int{2, 4} {
+		if _, err := s.Sync(ctx, "general"); err != nil {
+			t.Fatal(err)
+		}
+		cur, _ := db.Cursor("general")
+		if cur.Done || cur.Offset != wantOffset {
+			t.Fatalf("cursor after a limited run = %+v, want offset %d", cur, wantOffset)
+		}
+	}
+	res, err := s.Sync(ctx, "general")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cur, _ := db.Cursor("general"); !cur.Done || !res.Resumed || res.Posts != 1 {
+		t.Errorf("last run = %+v with cursor %+v, want the sync done", res, cur)
+	}
+}
+
+func TestSyncWaitsOutRateLimits(t *testing.T) {
+	db := openTest(t)
+	src := newSource(1)
+	src.errs["browse"] = &moltbook.RateLimitError{RetryAfter: time.Millisecond}
+	var logged []string
+	s := newSyncer(db, src)
+	s.Logf = func(format string, args ...interface{}) { logged = append(logged, fmt.Sprintf(format, args...)) }
+
+	res, err := s.Sync(context.Background(), "general")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Posts != 1 || len(src.calls) != 3 {
+		t.Errorf("sync = %+v with requests %q, want the browse retried", res, src.calls)
+	}
+	if len(logged) == 0 || logged[0] != "rate limited, waiting 1ms" {
+		t.Errorf("logged %q, want the wait", logged)
+	}
+}
+
+func TestSyncCancelled(t *testing.T) {
+	db := openTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := newSyncer(db, newSource(1)).Sync(ctx, "general")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Sync = %v, want context.Canceled", err)
+	}
+}
+
+func TestSyncEmbedsArchivedPosts(t *testing.T) {
+	db := openTest(t)
+	e := &fakeEmbedder{}
+	s := newSyncer(db, newSource(3))
+	s.Embedder = e
+
+	if _, err := s.Sync(context.Background(), "general"); err != nil {
+		t.Fatal(err)
+	}
+	if e.embedded != 3 {
+		t.Errorf("embedded %d posts, want 3", e.embedded)
+	}
+	if vec, _ := db.Vector("fake", "p1"); vec == nil {
+		t.Error("p1 has no vector after the sync")
+	}
+}
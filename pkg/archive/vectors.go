@@ -0,0 +1,197 @@
+package archive
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/moltgo/moltgo/pkg/embed"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Post vectors are kept in a bucket per embedding provider, so switching
+// providers or models never mixes vector spaces
+var vectorsBucket = []byte("vectors")
+
+// embedBatch is the number of posts embedded per provider call
+const embedBatch = 32
+
+// embedText is the text of a post that is embedded
+func embedText(post *moltbook.Post) string {
+	return post.Title + "\n\n" + post.Content
+}
+
+// EmbedPosts computes and stores vectors for archived posts that have none
+// from p
+func (a *DB) EmbedPosts(ctx context.Context, p embed.Provider, ids []string) error {
+	for start := 0; start < len(ids); start += embedBatch {
+		batch := ids[start:min(start+embedBatch, len(ids))]
+
+		var texts []string
+		var found []string
+		err := a.db.View(func(tx *bolt.Tx) error {
+			vectors := tx.Bucket(vectorsBucket).Bucket([]byte(p.Name()))
+			for _, id := range batch {
+				if vectors != nil && vectors.Get([]byte(id)) != nil {
+					continue
+				}
+				data := tx.Bucket(postsBucket).Get([]byte(id))
+				if data == nil {
+					continue
+				}
+				var rec PostRecord
+				if err := json.Unmarshal(data, &rec); err != nil {
+					return fmt.Errorf("failed to parse archived post %s: %w", id, err)
+				}
+				texts = append(texts, embedText(&rec.Post))
+				found = append(found, id)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		if len(texts) == 0 {
+			continue
+		}
+
+		vectors, err := p.Embed(ctx, texts)
+		if err != nil {
+			return fmt.Errorf("failed to embed posts: %w", err)
+		}
+		err = a.db.Update(func(tx *bolt.Tx) error {
+			b, err := tx.Bucket(vectorsBucket).CreateBucketIfNotExists([]byte(p.Name()))
+			if err != nil {
+				return err
+			}
+			for i, id := range found {
+				if err := b.Put([]byte(id), encodeVector(vectors[i])); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EmbedMissing embeds the archived posts that have no vector from p yet,
+// reporting progress after each batch. It returns the number embedded.
+func (a *DB) EmbedMissing(ctx context.Context, p embed.Provider, progress func(done, total int)) (int, error) {
+	var missing []string
+	err := a.db.View(func(tx *bolt.Tx) error {
+		vectors := tx.Bucket(vectorsBucket).Bucket([]byte(p.Name()))
+		return tx.Bucket(postsBucket).ForEach(func(k, v []byte) error {
+			if vectors == nil || vectors.Get(k) == nil {
+				missing = append(missing, string(k))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for start := 0; start < len(missing); start += embedBatch {
+		end := min(start+embedBatch, len(missing))
+		if err := a.EmbedPosts(ctx, p, missing[start:end]); err != nil {
+			return start, err
+		}
+		if progress != nil {
+			progress(end, len(missing))
+		}
+	}
+	return len(missing), nil
+}
+
+// Vector returns a post's vector from a provider, or nil if it has none
+func (a *DB) Vector(provider, id string) ([]float32, error) {
+	var vec []float32
+	err := a.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(vectorsBucket).Bucket([]byte(provider))
+		if b == nil {
+			return nil
+		}
+		if data := b.Get([]byte(id)); data != nil {
+			vec = decodeVector(data)
+		}
+		return nil
+	})
+	return vec, err
+}
+
+// Nearest ranks the archived posts matching q's filters by cosine
+// similarity to vec, leaving out the post exclude and posts with no
+// similarity at all. Hit scores are the similarities. Terms and phrases in q
+// are ignored.
+func (a *DB) Nearest(provider string, vec []float32, q Query, exclude string) ([]Hit, error) {
+	var hits []Hit
+	err := a.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(vectorsBucket).Bucket([]byte(provider))
+		if b == nil {
+			return nil
+		}
+		posts := tx.Bucket(postsBucket)
+		return b.ForEach(func(k, v []byte) error {
+			if string(k) == exclude {
+				return nil
+			}
+			data := posts.Get(k)
+			if data == nil {
+				return nil
+			}
+			var rec PostRecord
+			if err := json.Unmarshal(data, &rec); err != nil {
+				return fmt.Errorf("failed to parse archived post %s: %w", k, err)
+			}
+			if !q.matches(&rec.Post) {
+				return nil
+			}
+			if score := embed.Cosine(vec, decodeVector(v)); score > 0 {
+				hits = append(hits, Hit{Post: rec.Post, Score: score})
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].Score > hits[j].Score })
+	if q.Limit > 0 && len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	return hits, nil
+}
+
+// dropVectors removes a post's vectors so they are computed again from its
+// new content
+func dropVectors(tx *bolt.Tx, id string) error {
+	vectors := tx.Bucket(vectorsBucket)
+	return vectors.ForEachBucket(func(name []byte) error {
+		return vectors.Bucket(name).Delete([]byte(id))
+	})
+}
+
+func encodeVector(vec []float32) []byte {
+	buf := make([]byte, 4*len(vec))
+	for i, x := range vec {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(x))
+	}
+	return buf
+}
+
+func decodeVector(data []byte) []float32 {
+	vec := make([]float32, len(data)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:]))
+	}
+	return vec
+}
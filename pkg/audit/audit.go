@@ -0,0 +1,292 @@
+// Package audit keeps a tamper-evident log of the actions an agent takes on
+// Moltbook. Entries are JSON lines, each holding the hash of the one before,
+// so editing, removing or reordering entries breaks the chain.
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/moltgo/moltgo/pkg/filelock"
+	"github.com/moltgo/moltgo/pkg/timestamp"
+)
+
+// Triggers of actions; policy rules are recorded as "policy:<rule>" and
+// approved requests as "approval:<id>"
+const (
+	TriggerManual    = "manual"
+	TriggerHeartbeat = "heartbeat"
+)
+
+// Entry is one action in the log
+type Entry struct {
+	Seq     int            `json:"seq"`
+	Time    timestamp.Time `json:"time"`
+	Profile string         `json:"profile"`
+
+	// Action is register, update_profile, post, comment, vote or delete,
+	// or the method and endpoint of other requests
+	Action   string `json:"action"`
+	Endpoint string `json:"endpoint"`
+
+	// PayloadHash is the SHA-256 of the request body
+	PayloadHash string `json:"payload_hash"`
+	ResponseID  string `json:"response_id,omitempty"`
+
+	// Status is the response status, or 0 when no response was received
+	Status int    `json:"status"`
+	Error  string `json:"error,omitempty"`
+
+	Trigger string `json:"trigger"`
+
+	// Prev is the hash of the previous entry, empty for the first, and
+	// Hash is the hash of this entry with Hash empty
+	Prev string `json:"prev"`
+	Hash string `json:"hash"`
+}
+
+// Log is an audit log file
+type Log struct {
+	Path string
+
+	// tail is the last entry as last read or written, and tailSize the
+	// size of the file then, so appends only reread the log when another
+	// process has written to it since
+	tail     *Entry
+	tailSize int64
+	tailRead bool
+}
+
+// Open returns the log at path after checking that it can be appended to,
+// creating the file and its directory if they are missing
+func Open(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &Log{Path: path}, nil
+}
+
+// appendMu serializes appends within the process
+var appendMu sync.Mutex
+
+// Append chains e to the end of the log and writes it, filling in its
+// sequence number and hashes, and returns the written entry
+func (l *Log) Append(e Entry) (Entry, error) {
+	appendMu.Lock()
+	defer appendMu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0700); err != nil {
+		return e, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	unlock, err := filelock.Lock(l.Path)
+	if err != nil {
+		return e, err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(l.Path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0600)
+	if err != nil {
+		return e, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return e, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	if !l.tailRead || info.Size() != l.tailSize {
+		if l.tail, err = lastEntry(f, info.Size()); err != nil {
+			return e, err
+		}
+		l.tailSize, l.tailRead = info.Size(), true
+	}
+
+	e.Seq = 1
+	e.Prev = ""
+	if l.tail != nil {
+		e.Seq = l.tail.Seq + 1
+		e.Prev = l.tail.Hash
+	}
+	e.Hash = hashEntry(e)
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return e, fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	line = append(line, '\n')
+	if _, err := f.Write(line); err != nil {
+		l.tailRead = false
+		return e, fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		l.tailRead = false
+		return e, fmt.Errorf("failed to write audit log: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		l.tailRead = false
+		return e, fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	written := e
+	l.tail = &written
+	l.tailSize += int64(len(line))
+	return e, nil
+}
+
+// lastEntry returns the last entry of the log open as f, which is size
+// bytes long, or nil if it is empty. Only the end of the file is read.
+func lastEntry(f *os.File, size int64) (*Entry, error) {
+	for chunk := int64(4 << 10); ; chunk *= 2 {
+		offset := max(size-chunk, 0)
+		data := make([]byte, size-offset)
+		if _, err := f.ReadAt(data, offset); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("failed to read audit log: %w", err)
+		}
+		data = bytes.TrimRight(data, "\n")
+		i := bytes.LastIndexByte(data, '\n')
+		if i < 0 && offset > 0 {
+			// The last line is longer than the chunk
+			continue
+		}
+		if len(data) == 0 {
+			return nil, nil
+		}
+		var e Entry
+		if err := json.Unmarshal(data[i+1:], &e); err != nil {
+			return nil, fmt.Errorf("failed to read the last audit log entry: %w", err)
+		}
+		return &e, nil
+	}
+}
+
+// Read returns the entries of the log at path. A missing log has none.
+func Read(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for n := 1; scanner.Scan(); n++ {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("audit log line %d: %w", n, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// BrokenError reports where a log's hash chain is broken
+type BrokenError struct {
+	Line   int
+	Reason string
+}
+
+func (e *BrokenError) Error() string {
+	return fmt.Sprintf("audit log line %d: %s", e.Line, e.Reason)
+}
+
+// ErrBroken matches every *BrokenError with errors.Is
+var ErrBroken = errors.New("audit log chain broken")
+
+func (e *BrokenError) Is(target error) bool { return target == ErrBroken }
+
+// Verify checks the hash chain of the log at path and returns the number of
+// entries. A modified, removed, inserted or reordered entry is reported as
+// a *BrokenError naming the first line that does not fit the chain.
+func Verify(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read audit log: %w", err)
+	}
+
+	data = bytes.TrimSuffix(data, []byte("\n"))
+	if len(data) == 0 {
+		return 0, nil
+	}
+
+	prev := ""
+	n := 0
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		lineNo := i + 1
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return n, &BrokenError{Line: lineNo, Reason: "not a valid entry: " + err.Error()}
+		}
+		// Fields the entry type does not have were added after writing
+		if canonical, err := json.Marshal(e); err != nil || !bytes.Equal(canonical, line) {
+			return n, &BrokenError{Line: lineNo, Reason: "entry is not as written"}
+		}
+		switch {
+		case e.Seq != n+1:
+			return n, &BrokenError{Line: lineNo, Reason: fmt.Sprintf("sequence number %d, expected %d", e.Seq, n+1)}
+		case e.Prev != prev:
+			return n, &BrokenError{Line: lineNo, Reason: "previous hash does not match the entry before"}
+		case e.Hash != hashEntry(e):
+			return n, &BrokenError{Line: lineNo, Reason: "hash does not match the entry's contents"}
+		}
+		prev = e.Hash
+		n++
+	}
+	return n, nil
+}
+
+// hashEntry returns the hex SHA-256 of the entry with Hash empty
+func hashEntry(e Entry) string {
+	e.Hash = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// HashPayload returns the hex SHA-256 of a request body
+func HashPayload(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+type triggerKey struct{}
+
+// WithTrigger returns a copy of ctx recording what triggered the actions
+// taken with it
+func WithTrigger(ctx context.Context, trigger string) context.Context {
+	return context.WithValue(ctx, triggerKey{}, trigger)
+}
+
+// TriggerFrom returns the trigger recorded in ctx, or TriggerManual
+func TriggerFrom(ctx context.Context) string {
+	if trigger, ok := ctx.Value(triggerKey{}).(string); ok && trigger != "" {
+		return trigger
+	}
+	return TriggerManual
+}
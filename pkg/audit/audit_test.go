@@ -0,0 +1,209 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/timestamp"
+)
+
+// writeLog appends n entries to a new log and returns its path
+func writeLog(t *testing.T, n int) string {
+	t.Helper()
+	log, err := Open(filepath.Join(t.TempDir(), "audit", "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		_, err := log.Append(Entry{
+			Time:        timestamp.New(at.Add(time.Duration(i) * time.Minute)),
+			Profile:     "TestAgent",
+			Action:      "post",
+			Endpoint:    "/posts",
+			PayloadHash: HashPayload([]byte{byte(i)}),
+			Status:      201,
+			Trigger:     TriggerManual,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	return log.Path
+}
+
+func TestAppendChainsEntries(t *testing.T) {
+	path := writeLog(t, 3)
+	entries, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("%d entries, want 3", len(entries))
+	}
+	prev := ""
+	for i, e := range entries {
+		if e.Seq != i+1 || e.Prev != prev || e.Hash != hashEntry(e) {
+			t.Errorf("entry %d = seq %d prev %q hash %q, want seq %d prev %q", i, e.Seq, e.Prev, e.Hash, i+1, prev)
+		}
+		prev = e.Hash
+	}
+	if n, err := Verify(path); n != 3 || err != nil {
+		t.Errorf("Verify = %d, %v; want 3 intact entries", n, err)
+	}
+}
+
+func TestAppendContinuesAnotherWritersChain(t *testing.T) {
+	path := writeLog(t, 1)
+	first := &Log{Path: path}
+	second := &Log{Path: path}
+
+	// Each log caches its tail; appends by the other must still be chained
+	for i, log := range []*Log{first, second, first, first, second} {
+		e, err := log.Append(Entry{Action: "vote", Trigger: TriggerHeartbeat})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if e.Seq != i+2 {
+			t.Errorf("append %d got seq %d, want %d", i, e.Seq, i+2)
+		}
+	}
+	if n, err := Verify(path); n != 6 || err != nil {
+		t.Errorf("Verify = %d, %v; want 6 intact entries", n, err)
+	}
+}
+
+func TestAppendAfterLongLines(t *testing.T) {
+	log, err := Open(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Entries longer than the chunk the tail is read in
+	long := strings.Repeat("x", 10<<10)
+	for i := 0; i < 3; i++ {
+		if _, err := (&Log{Path: log.Path}).Append(Entry{Action: "post", Error: long}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n, err := Verify(log.Path); n != 3 || err != nil {
+		t.Errorf("Verify = %d, %v; want 3 intact entries", n, err)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(lines [][]byte) [][]byte
+		good   int
+		line   int
+	}{
+		{
+			name: "edited",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1] = bytes.Replace(lines[1], []byte(`"status":201`), []byte(`"status":500`), 1)
+				return lines
+			},
+			good: 1, line: 2,
+		},
+		{
+			name: "field added",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[2] = bytes.Replace(lines[2], []byte(`{`), []byte(`{"note":"x",`), 1)
+				return lines
+			},
+			good: 2, line: 3,
+		},
+		{
+			name: "deleted",
+			tamper: func(lines [][]byte) [][]byte {
+				return append(lines[:1], lines[2:]...)
+			},
+			good: 1, line: 2,
+		},
+		{
+			name: "reordered",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[1], lines[2] = lines[2], lines[1]
+				return lines
+			},
+			good: 1, line: 2,
+		},
+		{
+			name: "inserted",
+			tamper: func(lines [][]byte) [][]byte {
+				return append(lines[:2], append([][]byte{lines[0]}, lines[2:]...)...)
+			},
+			good: 2, line: 3,
+		},
+		{
+			name: "garbled",
+			tamper: func(lines [][]byte) [][]byte {
+				lines[3] = []byte("not json")
+				return lines
+			},
+			good: 3, line: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeLog(t, 4)
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := tt.tamper(bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n")))
+			if err := os.WriteFile(path, append(bytes.Join(lines, []byte("\n")), '\n'), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			n, err := Verify(path)
+			var broken *BrokenError
+			if !errors.As(err, &broken) || !errors.Is(err, ErrBroken) {
+				t.Fatalf("Verify = %d, %v; want a broken chain", n, err)
+			}
+			if n != tt.good || broken.Line != tt.line {
+				t.Errorf("Verify = %d good entries, broken at line %d (%s); want %d, line %d", n, broken.Line, broken.Reason, tt.good, tt.line)
+			}
+		})
+	}
+}
+
+func TestVerifyEmptyLog(t *testing.T) {
+	dir := t.TempDir()
+	if n, err := Verify(filepath.Join(dir, "missing.jsonl")); n != 0 || err != nil {
+		t.Errorf("missing log: Verify = %d, %v", n, err)
+	}
+	log, err := Open(filepath.Join(dir, "empty.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, err := Verify(log.Path); n != 0 || err != nil {
+		t.Errorf("empty log: Verify = %d, %v", n, err)
+	}
+}
+
+func TestOpenFailsOnUnwritableLog(t *testing.T) {
+	// A directory where the log should be cannot be opened for appending
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	if err := os.Mkdir(path, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(path); err == nil || !strings.Contains(err.Error(), "failed to open audit log") {
+		t.Errorf("err = %v, want an open failure", err)
+	}
+}
+
+func TestTrigger(t *testing.T) {
+	if got := TriggerFrom(context.Background()); got != TriggerManual {
+		t.Errorf("default trigger = %q", got)
+	}
+	if got := TriggerFrom(WithTrigger(context.Background(), "policy:greet")); got != "policy:greet" {
+		t.Errorf("trigger = %q", got)
+	}
+}
@@ -0,0 +1,75 @@
+package compose
+
+import "strings"
+
+// PostDraft is a post being composed
+type PostDraft struct {
+	Submolt string
+	Title   string
+	URL     string
+	Content string
+}
+
+// Template renders the draft as an editable document
+func (d *PostDraft) Template() string {
+	return FormatFrontMatter([]Field{
+		{Value: "Write your post below the closing ---. Leave url empty for a text post."},
+		{Value: "Saving an empty post aborts."},
+		{Key: "submolt", Value: d.Submolt},
+		{Key: "title", Value: d.Title},
+		{Key: "url", Value: d.URL},
+	}, d.Content)
+}
+
+// ParsePostDraft reads a post draft from an edited template
+func ParsePostDraft(text string) (*PostDraft, error) {
+	header, body, err := ParseFrontMatter(text)
+	if err != nil {
+		return nil, err
+	}
+	return &PostDraft{
+		Submolt: strings.TrimPrefix(header["submolt"], "/"),
+		Title:   header["title"],
+		URL:     header["url"],
+		Content: strings.TrimSpace(body),
+	}, nil
+}
+
+// ValidationError reports why a draft cannot be posted
+type ValidationError struct {
+	Reason string
+}
+
+func (e *ValidationError) Error() string { return e.Reason }
+
+// Validate checks that the draft can be posted, returning a
+// *ValidationError if it cannot
+func (d *PostDraft) Validate() error {
+	if d.Submolt == "" {
+		return &ValidationError{"a submolt is required"}
+	}
+	if d.Title == "" {
+		return &ValidationError{"a title is required"}
+	}
+	if d.Content == "" && d.URL == "" {
+		return &ValidationError{"must provide either content or a URL"}
+	}
+	return nil
+}
+
+// CommentTemplate renders an editable document for a comment on postID
+func CommentTemplate(postID, content string) string {
+	return FormatFrontMatter([]Field{
+		{Value: "Write your comment below the closing ---. Saving an empty comment aborts."},
+		{Key: "post", Value: postID},
+	}, content)
+}
+
+// ParseComment reads the comment body from an edited comment template
+func ParseComment(text string) (string, error) {
+	_, body, err := ParseFrontMatter(text)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(body), nil
+}
@@ -0,0 +1,74 @@
+// Package compose prepares post and comment bodies from files, stdin or the
+// user's editor, using a small front matter header for post metadata.
+package compose
+
+import (
+	"fmt"
+	"strings"
+)
+
+const frontMatterDelim = "---"
+
+// Field is a single front matter entry
+type Field struct {
+	Key   string
+	Value string
+}
+
+// FormatFrontMatter renders fields as a front matter block followed by body
+func FormatFrontMatter(fields []Field, body string) string {
+	var b strings.Builder
+	b.WriteString(frontMatterDelim + "\n")
+	for _, f := range fields {
+		if f.Key == "" {
+			// Keyless fields are written as comments
+			fmt.Fprintf(&b, "# %s\n", f.Value)
+			continue
+		}
+		fmt.Fprintf(&b, "%s: %s\n", f.Key, f.Value)
+	}
+	b.WriteString(frontMatterDelim + "\n")
+	b.WriteString(body)
+	return b.String()
+}
+
+// ParseFrontMatter splits text into its front matter fields and body. Text
+// without a leading front matter block is returned entirely as the body.
+// Keys are lower-cased; lines starting with # are ignored.
+func ParseFrontMatter(text string) (map[string]string, string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	header := make(map[string]string)
+
+	if strings.TrimSpace(firstLine(text)) != frontMatterDelim {
+		return header, text, nil
+	}
+
+	lines := strings.Split(text, "\n")
+	for i := 1; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == frontMatterDelim {
+			return header, strings.Join(lines[i+1:], "\n"), nil
+		}
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			return nil, "", fmt.Errorf("invalid front matter line %d: %q", i+1, line)
+		}
+		header[strings.ToLower(strings.TrimSpace(key))] = unquote(strings.TrimSpace(value))
+	}
+	return nil, "", fmt.Errorf("front matter is not closed with %q", frontMatterDelim)
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' && s[len(s)-1] == '"' || s[0] == '\'' && s[len(s)-1] == '\'') {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,70 @@
+package compose
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ReadSource reads content from a file, or from stdin when path is "-"
+func ReadSource(path string, stdin io.Reader) (string, error) {
+	if path == "-" {
+		data, err := io.ReadAll(stdin)
+		if err != nil {
+			return "", fmt.Errorf("failed to read stdin: %w", err)
+		}
+		return string(data), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return string(data), nil
+}
+
+// Editor returns the user's preferred editor command from $VISUAL or
+// $EDITOR, defaulting to vi
+func Editor() string {
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if e := strings.TrimSpace(os.Getenv(env)); e != "" {
+			return e
+		}
+	}
+	return "vi"
+}
+
+// Edit opens initial in the user's editor and returns the saved text
+func Edit(initial string) (string, error) {
+	f, err := os.CreateTemp("", "moltgo-*.md")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	path := f.Name()
+	defer os.Remove(path)
+
+	if _, err := f.WriteString(initial); err != nil {
+		f.Close()
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return "", fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	// Run through the shell so editors configured with arguments work
+	cmd := exec.Command("sh", "-c", Editor()+` "$1"`, "sh", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor %q failed: %w", Editor(), err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read edited file: %w", err)
+	}
+	return string(data), nil
+}
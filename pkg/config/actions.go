@@ -0,0 +1,31 @@
+package config
+
+import (
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/timestamp"
+)
+
+// ActionMemory is how long actions taken by the policy engine are
+// remembered, so the same post is not voted or commented on twice
+const ActionMemory = 7 * 24 * time.Hour
+
+// Acted reports whether the action identified by key was already taken
+func (s *State) Acted(key string) bool {
+	_, ok := s.Actions[key]
+	return ok
+}
+
+// RecordAction remembers that the action identified by key was taken, and
+// forgets actions older than ActionMemory
+func (s *State) RecordAction(key string, now time.Time) {
+	if s.Actions == nil {
+		s.Actions = make(map[string]timestamp.Time)
+	}
+	for k, at := range s.Actions {
+		if at.IsZero() || now.Sub(at.Time) > ActionMemory {
+			delete(s.Actions, k)
+		}
+	}
+	s.Actions[key] = timestamp.New(now)
+}
@@ -0,0 +1,10 @@
+package config
+
+// ApprovalConfig controls the approval queue, from the [approval] section of
+// config.toml
+type ApprovalConfig struct {
+	// Required holds posts, comments and votes from unattended runs
+	// (heartbeat, the daemon and --non-interactive commands) until a human
+	// accepts them with `moltgo approve`
+	Required bool `toml:"required,omitempty"`
+}
@@ -0,0 +1,8 @@
+package config
+
+// ArchiveConfig configures the local archive, from the [archive] section of
+// config.toml
+type ArchiveConfig struct {
+	// Submolts synced by `moltgo archive sync` when none are given
+	Submolts []string `toml:"submolts,omitempty"`
+}
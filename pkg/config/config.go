@@ -3,25 +3,52 @@ package config
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/BurntSushi/toml"
+	"github.com/moltgo/moltgo/pkg/timestamp"
 )
 
+// ErrNoCredentials is returned when no API key is configured
+var ErrNoCredentials = errors.New("no credentials found - please run 'moltgo register' first or set MOLTBOOK_API_KEY environment variable")
+
 // Config holds the agent configuration
 type Config struct {
 	APIKey    string `toml:"api_key" json:"api_key"`
 	AgentName string `toml:"agent_name" json:"agent_name"`
+
+	Daemon    DaemonConfig    `toml:"daemon,omitempty" json:"-"`
+	LLM       LLMConfig       `toml:"llm,omitempty" json:"-"`
+	Approval  ApprovalConfig  `toml:"approval,omitempty" json:"-"`
+	Safety    SafetyConfig    `toml:"safety,omitempty" json:"-"`
+	Archive   ArchiveConfig   `toml:"archive,omitempty" json:"-"`
+	Embedding EmbeddingConfig `toml:"embedding,omitempty" json:"-"`
 }
 
 // State holds the agent's runtime state
 type State struct {
-	LastMoltbookCheck string `toml:"lastMoltbookCheck"`
-	PostsCreated      int    `toml:"posts_created"`
-	CommentsCreated   int    `toml:"comments_created"`
-	LastPostTime      string `toml:"last_post_time"`
+	LastMoltbookCheck timestamp.Time `toml:"lastMoltbookCheck"`
+	PostsCreated      int            `toml:"posts_created"`
+	CommentsCreated   int            `toml:"comments_created"`
+	LastPostTime      timestamp.Time `toml:"last_post_time"`
+	LastCommentTime   timestamp.Time `toml:"last_comment_time"`
+	CommentsToday     int            `toml:"comments_today"`
+	CommentDay        string         `toml:"comment_day"`
+
+	// Our own recent posts, newest last, and the IDs of the comments each
+	// had when last checked
+	RecentPosts  []string            `toml:"recent_posts"`
+	SeenComments map[string][]string `toml:"seen_comment_ids"`
+
+	// Actions taken by the policy engine, keyed by action and target, with
+	// the time they were taken
+	Actions map[string]timestamp.Time `toml:"actions"`
+
+	// Posts sent by the post command, oldest first
+	SentPosts []SentPost `toml:"sent_posts"`
 }
 
 // GetConfigDir returns the configuration directory path
@@ -51,8 +78,68 @@ func GetStatePath() (string, error) {
 	return filepath.Join(configDir, "state.toml"), nil
 }
 
-// LoadCredentials loads the API credentials from environment or disk
+// GetDraftsPath returns the path to the drafts queue
+func GetDraftsPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "drafts.toml"), nil
+}
+
+// GetApprovalsPath returns the path to the approval queue
+func GetApprovalsPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "approvals.toml"), nil
+}
+
+// GetArchivePath returns the path to the local archive database
+func GetArchivePath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "archive.db"), nil
+}
+
+// GetPolicyPath returns the path to the engagement policy
+func GetPolicyPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "policy.toml"), nil
+}
+
+// GetAuditPath returns the path to the audit log
+func GetAuditPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "audit.jsonl"), nil
+}
+
+// GetSnapshotPath returns the path to the latest status snapshot
+func GetSnapshotPath() (string, error) {
+	configDir, err := GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "status.json"), nil
+}
+
+// LoadCredentials loads the API credentials from environment or disk,
+// along with the settings sections of config.toml
 func LoadCredentials() (*Config, error) {
+	config, err := loadConfigFile()
+	if err != nil {
+		return nil, err
+	}
+
 	// First, check environment variables
 	apiKey := os.Getenv("MOLTBOOK_API_KEY")
 	agentName := os.Getenv("MOLTBOOK_AGENT_NAME")
@@ -62,10 +149,9 @@ func LoadCredentials() (*Config, error) {
 		if agentName == "" {
 			agentName = "MoltGoAgent"
 		}
-		return &Config{
-			APIKey:    apiKey,
-			AgentName: agentName,
-		}, nil
+		config.APIKey = apiKey
+		config.AgentName = agentName
+		return config, nil
 	}
 
 	// Try loading from credentials.json first (JSON format)
@@ -75,13 +161,30 @@ func LoadCredentials() (*Config, error) {
 	}
 	jsonPath := filepath.Join(configDir, "credentials.json")
 	if data, err := os.ReadFile(jsonPath); err == nil {
-		var config Config
-		if err := json.Unmarshal(data, &config); err == nil {
-			return &config, nil
+		var creds Config
+		if err := json.Unmarshal(data, &creds); err == nil {
+			config.APIKey = creds.APIKey
+			config.AgentName = creds.AgentName
+			return config, nil
 		}
 	}
 
-	// Fall back to reading from config.toml (TOML format)
+	// Fall back to the credentials in config.toml
+	if config.APIKey == "" {
+		return nil, ErrNoCredentials
+	}
+
+	return config, nil
+}
+
+// LoadSettings loads the settings sections of config.toml for commands that
+// work offline and need no credentials
+func LoadSettings() (*Config, error) {
+	return loadConfigFile()
+}
+
+// loadConfigFile reads config.toml. A missing file is an empty config.
+func loadConfigFile() (*Config, error) {
 	path, err := GetCredentialsPath()
 	if err != nil {
 		return nil, err
@@ -90,7 +193,7 @@ func LoadCredentials() (*Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("no credentials found - please run 'moltgo register' first or set MOLTBOOK_API_KEY environment variable")
+			return &Config{}, nil
 		}
 		return nil, fmt.Errorf("failed to read credentials: %w", err)
 	}
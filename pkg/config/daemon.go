@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Default daemon schedule
+const (
+	DefaultHeartbeatInterval = 4 * time.Hour
+	DefaultHeartbeatJitter   = 15 * time.Minute
+	DefaultStatusInterval    = time.Hour
+	DefaultPollInterval      = 15 * time.Minute
+)
+
+// DaemonConfig holds the schedule for `moltgo run`, from the [daemon]
+// section of config.toml. Intervals are Go durations such as "4h" or "90m".
+type DaemonConfig struct {
+	HeartbeatInterval string `toml:"heartbeat_interval,omitempty"`
+	HeartbeatJitter   string `toml:"heartbeat_jitter,omitempty"`
+	StatusInterval    string `toml:"status_interval,omitempty"`
+	PollInterval      string `toml:"poll_interval,omitempty"`
+}
+
+// HeartbeatEvery returns the heartbeat interval
+func (d DaemonConfig) HeartbeatEvery() time.Duration {
+	return parseDuration(d.HeartbeatInterval, DefaultHeartbeatInterval)
+}
+
+// HeartbeatSpread returns the random jitter applied to each heartbeat
+func (d DaemonConfig) HeartbeatSpread() time.Duration {
+	return parseDuration(d.HeartbeatJitter, DefaultHeartbeatJitter)
+}
+
+// StatusEvery returns the interval between status snapshots
+func (d DaemonConfig) StatusEvery() time.Duration {
+	return parseDuration(d.StatusInterval, DefaultStatusInterval)
+}
+
+// PollEvery returns the interval between checks for new comments on our posts
+func (d DaemonConfig) PollEvery() time.Duration {
+	return parseDuration(d.PollInterval, DefaultPollInterval)
+}
+
+// Validate checks the [daemon] durations. Intervals must be positive,
+// since the daemon would otherwise run its jobs back to back, and the
+// jitter must not be negative.
+func (d DaemonConfig) Validate() error {
+	intervals := []struct{ key, value string }{
+		{"heartbeat_interval", d.HeartbeatInterval},
+		{"status_interval", d.StatusInterval},
+		{"poll_interval", d.PollInterval},
+	}
+	for _, iv := range intervals {
+		if iv.value == "" {
+			continue
+		}
+		v, err := time.ParseDuration(iv.value)
+		if err != nil {
+			return fmt.Errorf("invalid [daemon] %s %q: %w", iv.key, iv.value, err)
+		}
+		if v <= 0 {
+			return fmt.Errorf("invalid [daemon] %s %q: must be positive", iv.key, iv.value)
+		}
+	}
+	if d.HeartbeatJitter != "" {
+		v, err := time.ParseDuration(d.HeartbeatJitter)
+		if err != nil {
+			return fmt.Errorf("invalid [daemon] heartbeat_jitter %q: %w", d.HeartbeatJitter, err)
+		}
+		if v < 0 {
+			return fmt.Errorf("invalid [daemon] heartbeat_jitter %q: must not be negative", d.HeartbeatJitter)
+		}
+	}
+	return nil
+}
+
+// parseDuration parses s, returning def when s is empty or invalid.
+// Validate reports the values this falls back on.
+func parseDuration(s string, def time.Duration) time.Duration {
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return def
+	}
+	return d
+}
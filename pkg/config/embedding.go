@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// Embedding providers
+const (
+	EmbeddingHashing = "hashing"
+	EmbeddingOpenAI  = "openai"
+)
+
+// Defaults for the embedding provider
+const (
+	DefaultEmbeddingModel   = "nomic-embed-text"
+	DefaultEmbeddingTimeout = 30 * time.Second
+)
+
+// EmbeddingConfig selects how posts are embedded for semantic search, from
+// the [embedding] section of config.toml. The openai provider uses the [llm]
+// endpoint and key unless its own are set.
+type EmbeddingConfig struct {
+	Provider string `toml:"provider,omitempty"`
+	BaseURL  string `toml:"base_url,omitempty"`
+	APIKey   string `toml:"api_key,omitempty"`
+	Model    string `toml:"model,omitempty"`
+	Timeout  string `toml:"timeout,omitempty"`
+}
+
+// ProviderName returns the configured provider, checking that it is known
+func (e EmbeddingConfig) ProviderName() (string, error) {
+	switch e.Provider {
+	case "", EmbeddingHashing:
+		return EmbeddingHashing, nil
+	case EmbeddingOpenAI:
+		return EmbeddingOpenAI, nil
+	}
+	return "", fmt.Errorf("unknown embedding provider %q (use %q or %q)", e.Provider, EmbeddingHashing, EmbeddingOpenAI)
+}
+
+// ModelName returns the embedding model to request
+func (e EmbeddingConfig) ModelName() string {
+	if e.Model != "" {
+		return e.Model
+	}
+	return DefaultEmbeddingModel
+}
+
+// RequestTimeout returns the timeout for a single embedding request
+func (e EmbeddingConfig) RequestTimeout() time.Duration {
+	return parseDuration(e.Timeout, DefaultEmbeddingTimeout)
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"os"
+	"time"
+)
+
+// Defaults for the content generation backend
+const (
+	DefaultLLMBaseURL = "http://localhost:11434/v1"
+	DefaultLLMModel   = "llama3"
+	DefaultLLMTimeout = 2 * time.Minute
+)
+
+// LLMConfig configures the OpenAI-compatible backend used to generate
+// content, from the [llm] section of config.toml
+type LLMConfig struct {
+	BaseURL     string  `toml:"base_url,omitempty"`
+	APIKey      string  `toml:"api_key,omitempty"`
+	Model       string  `toml:"model,omitempty"`
+	Persona     string  `toml:"persona,omitempty"`
+	Temperature float64 `toml:"temperature,omitempty"`
+	MaxTokens   int     `toml:"max_tokens,omitempty"`
+	Timeout     string  `toml:"timeout,omitempty"`
+}
+
+// Endpoint returns the API base URL, honoring MOLTGO_LLM_BASE_URL
+func (l LLMConfig) Endpoint() string {
+	if url := os.Getenv("MOLTGO_LLM_BASE_URL"); url != "" {
+		return url
+	}
+	if l.BaseURL != "" {
+		return l.BaseURL
+	}
+	return DefaultLLMBaseURL
+}
+
+// Key returns the API key, honoring MOLTGO_LLM_API_KEY
+func (l LLMConfig) Key() string {
+	if key := os.Getenv("MOLTGO_LLM_API_KEY"); key != "" {
+		return key
+	}
+	return l.APIKey
+}
+
+// ModelName returns the model to request
+func (l LLMConfig) ModelName() string {
+	if l.Model != "" {
+		return l.Model
+	}
+	return DefaultLLMModel
+}
+
+// RequestTimeout returns the timeout for a single completion
+func (l LLMConfig) RequestTimeout() time.Duration {
+	return parseDuration(l.Timeout, DefaultLLMTimeout)
+}
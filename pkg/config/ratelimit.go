@@ -0,0 +1,98 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/timestamp"
+)
+
+// Moltbook rate limits that are tracked locally
+const (
+	PostInterval    = 30 * time.Minute
+	CommentInterval = 20 * time.Second
+	CommentsPerDay  = 50
+)
+
+// ErrRateLimited is wrapped by the errors of actions the local rate limits
+// do not allow yet
+var ErrRateLimited = errors.New("rate limit")
+
+// MaxRecentPosts caps how many of our own posts are tracked for new comments
+const MaxRecentPosts = 20
+
+// PostCooldown returns how long to wait before another post is allowed
+func (s *State) PostCooldown(now time.Time) time.Duration {
+	if s.LastPostTime.IsZero() {
+		return 0
+	}
+	if wait := PostInterval - now.Sub(s.LastPostTime.Time); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// CommentCooldown returns how long to wait before another comment is
+// allowed, taking both the per-comment interval and the daily cap into
+// account
+func (s *State) CommentCooldown(now time.Time) time.Duration {
+	if s.CommentDay == now.Format("2006-01-02") && s.CommentsToday >= CommentsPerDay {
+		tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, now.Location())
+		return tomorrow.Sub(now)
+	}
+	if s.LastCommentTime.IsZero() {
+		return 0
+	}
+	if wait := CommentInterval - now.Sub(s.LastCommentTime.Time); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// CheckPost returns an error if posting now would exceed the rate limit
+func (s *State) CheckPost(now time.Time) error {
+	if wait := s.PostCooldown(now); wait > 0 {
+		return fmt.Errorf("%w: wait %d more minutes before posting", ErrRateLimited, int(wait.Minutes())+1)
+	}
+	return nil
+}
+
+// CheckComment returns an error if commenting now would exceed the rate limit
+func (s *State) CheckComment(now time.Time) error {
+	if s.CommentDay == now.Format("2006-01-02") && s.CommentsToday >= CommentsPerDay {
+		return fmt.Errorf("%w: daily limit of %d comments reached", ErrRateLimited, CommentsPerDay)
+	}
+	if wait := s.CommentCooldown(now); wait > 0 {
+		return fmt.Errorf("%w: wait %d more seconds before commenting", ErrRateLimited, int(wait.Seconds())+1)
+	}
+	return nil
+}
+
+// RecordPost updates the state after a post has been created
+func (s *State) RecordPost(postID string, now time.Time) {
+	s.PostsCreated++
+	s.LastPostTime = timestamp.New(now)
+	if postID == "" {
+		return
+	}
+	s.RecentPosts = append(s.RecentPosts, postID)
+	if n := len(s.RecentPosts); n > MaxRecentPosts {
+		for _, id := range s.RecentPosts[:n-MaxRecentPosts] {
+			delete(s.SeenComments, id)
+		}
+		s.RecentPosts = s.RecentPosts[n-MaxRecentPosts:]
+	}
+}
+
+// RecordComment updates the state after a comment has been created
+func (s *State) RecordComment(now time.Time) {
+	s.CommentsCreated++
+	s.LastCommentTime = timestamp.New(now)
+	day := now.Format("2006-01-02")
+	if s.CommentDay != day {
+		s.CommentDay = day
+		s.CommentsToday = 0
+	}
+	s.CommentsToday++
+}
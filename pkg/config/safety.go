@@ -0,0 +1,13 @@
+package config
+
+// SafetyConfig configures the outbound content filter, from the [safety]
+// section of config.toml
+type SafetyConfig struct {
+	// Rules sets the mode (block, redact, warn or off) of filter rules by
+	// name, overriding their defaults
+	Rules map[string]string `toml:"rules,omitempty"`
+
+	// Denylist lists terms, such as internal hostnames or project names,
+	// that must not be published
+	Denylist []string `toml:"denylist,omitempty"`
+}
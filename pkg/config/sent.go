@@ -0,0 +1,61 @@
+package config
+
+import (
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/timestamp"
+)
+
+// MaxSentPosts caps how many sent posts are remembered
+const MaxSentPosts = 20
+
+// SentPost is a post the post command sent, remembered to catch duplicates
+// and to make retries with an idempotency key safe
+type SentPost struct {
+	Key string `toml:"key,omitempty"`
+	// ID is empty while the post is being sent, and stays empty if it is
+	// not known whether sending succeeded
+	ID      string         `toml:"id,omitempty"`
+	Submolt string         `toml:"submolt"`
+	Title   string         `toml:"title"`
+	Content string         `toml:"content,omitempty"`
+	URL     string         `toml:"url,omitempty"`
+	At      timestamp.Time `toml:"at"`
+}
+
+// SentPostByKey returns the sent post with an idempotency key, or nil
+func (s *State) SentPostByKey(key string) *SentPost {
+	if key == "" {
+		return nil
+	}
+	for i := range s.SentPosts {
+		if s.SentPosts[i].Key == key {
+			return &s.SentPosts[i]
+		}
+	}
+	return nil
+}
+
+// RememberPost records a sent post, replacing an earlier attempt with the
+// same idempotency key
+func (s *State) RememberPost(p SentPost, now time.Time) {
+	p.At = timestamp.New(now)
+	if prev := s.SentPostByKey(p.Key); prev != nil {
+		*prev = p
+		return
+	}
+	s.SentPosts = append(s.SentPosts, p)
+	if n := len(s.SentPosts); n > MaxSentPosts {
+		s.SentPosts = s.SentPosts[n-MaxSentPosts:]
+	}
+}
+
+// ForgetPost drops the attempt with an idempotency key
+func (s *State) ForgetPost(key string) {
+	for i := range s.SentPosts {
+		if key != "" && s.SentPosts[i].Key == key {
+			s.SentPosts = append(s.SentPosts[:i], s.SentPosts[i+1:]...)
+			return
+		}
+	}
+}
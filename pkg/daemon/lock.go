@@ -0,0 +1,91 @@
+// Package daemon provides process management helpers for `moltgo run`.
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ErrLocked is returned when another live process holds the lock
+var ErrLocked = errors.New("already running")
+
+// Lock is a PID file held for the lifetime of a daemon
+type Lock struct {
+	path string
+}
+
+// AcquireLock creates a PID file at path. If the file exists and names a
+// running process, ErrLocked is returned; a stale file left by a process
+// that died is replaced.
+func AcquireLock(path string) (*Lock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			_, werr := fmt.Fprintf(f, "%d\n", os.Getpid())
+			if cerr := f.Close(); werr == nil {
+				werr = cerr
+			}
+			if werr != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("failed to write lock file: %w", werr)
+			}
+			return &Lock{path: path}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		pid, err := ReadPID(path)
+		if err == nil && processAlive(pid) {
+			return nil, fmt.Errorf("%w (pid %d, lock file %s)", ErrLocked, pid, path)
+		}
+
+		// Stale lock: the owner is gone
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to remove stale lock file: %w", err)
+		}
+	}
+	return nil, fmt.Errorf("failed to acquire lock file %s", path)
+}
+
+// Release removes the PID file
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	return nil
+}
+
+// ReadPID returns the process ID recorded in a PID file
+func ReadPID(path string) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, fmt.Errorf("invalid pid file %s: %w", path, err)
+	}
+	return pid, nil
+}
+
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,107 @@
+// Package dedupe detects posts that repeat an existing post.
+package dedupe
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+)
+
+// DefaultThreshold is the similarity from which posts count as duplicates
+const DefaultThreshold = 0.8
+
+// Normalize lowercases text and reduces it to words separated by single
+// spaces, so that punctuation, case and spacing do not hide a duplicate
+func Normalize(text string) string {
+	return strings.Join(words(text), " ")
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Similarity compares two texts by the overlap (Jaccard index) of their
+// word pairs, from 0 for nothing in common to 1 for the same normalized
+// text. Single words are compared on their own. Texts without words, such
+// as titles of only punctuation or emoji, have nothing to compare and
+// score 0.
+func Similarity(a, b string) float64 {
+	sa, sb := shingles(a), shingles(b)
+	if len(sa) == 0 || len(sb) == 0 {
+		return 0
+	}
+	shared := 0
+	for s := range sa {
+		if sb[s] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(sa)+len(sb)-shared)
+}
+
+func shingles(text string) map[string]bool {
+	w := words(text)
+	set := make(map[string]bool)
+	if len(w) == 1 {
+		set[w[0]] = true
+	}
+	for i := 0; i+1 < len(w); i++ {
+		set[w[i]+" "+w[i+1]] = true
+	}
+	return set
+}
+
+// Compare returns how similar two posts are. Links to the same URL are
+// duplicates; otherwise the higher of the title similarity and the
+// similarity of title and content together is used. Posts without content,
+// such as search results, are compared by title.
+func Compare(a, b *moltbook.Post) float64 {
+	if a.URL != "" && b.URL != "" && normalizeURL(a.URL) == normalizeURL(b.URL) {
+		return 1
+	}
+	score := Similarity(a.Title, b.Title)
+	if a.Content != "" && b.Content != "" {
+		score = max(score, Similarity(a.Title+"\n"+a.Content, b.Title+"\n"+b.Content))
+	}
+	return score
+}
+
+func normalizeURL(u string) string {
+	u = strings.ToLower(strings.TrimSpace(u))
+	u = strings.TrimPrefix(u, "https://")
+	u = strings.TrimPrefix(u, "http://")
+	u = strings.TrimPrefix(u, "www.")
+	return strings.TrimRight(u, "/")
+}
+
+// Match is an existing post a new post duplicates
+type Match struct {
+	Post       moltbook.Post
+	Similarity float64
+	// Own is set when the existing post is one of ours
+	Own bool
+}
+
+// Find returns the existing post most similar to post, if it is at least
+// threshold similar. Our own posts are preferred over others' at equal
+// similarity.
+func Find(post *moltbook.Post, own, others []moltbook.Post, threshold float64) *Match {
+	var best *Match
+	check := func(posts []moltbook.Post, isOwn bool) {
+		for i := range posts {
+			if posts[i].ID != "" && posts[i].ID == post.ID {
+				continue
+			}
+			score := Compare(post, &posts[i])
+			if score >= threshold && (best == nil || score > best.Similarity) {
+				best = &Match{Post: posts[i], Similarity: score, Own: isOwn}
+			}
+		}
+	}
+	check(own, true)
+	check(others, false)
+	return best
+}
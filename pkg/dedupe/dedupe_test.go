@@ -0,0 +1,115 @@
+package dedupe
+
+import (
+	"testing"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Hello, World!", "hello world"},
+		{"  many   spaces\tand\nlines ", "many spaces and lines"},
+		{"Go 1.23 is out", "go 1 23 is out"},
+		{"Ünïcode — dashes…", "ünïcode dashes"},
+		{"🎉🎉", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := Normalize(tt.in); got != tt.want {
+			t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want float64
+	}{
+		{"identical", "error wrapping in go", "error wrapping in go", 1},
+		{"case and punctuation", "Error wrapping, in Go!", "error   wrapping in go", 1},
+		{"single word", "Generics", "generics!", 1},
+		{"nothing shared", "error wrapping", "generic types", 0},
+		// 2 of the 4 distinct pairs are shared
+		{"half", "error wrapping in go", "error wrapping in rust", 0.5},
+		{"reordered words", "wrapping error", "error wrapping", 0},
+		{"no words", "🎉", "🎉", 0},
+		{"empty", "", "anything", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Similarity(tt.a, tt.b); got != tt.want {
+				t.Errorf("Similarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b moltbook.Post
+		want float64
+	}{
+		{"same link", moltbook.Post{Title: "A", URL: "https://www.go.dev/blog/"}, moltbook.Post{Title: "B", URL: "http://go.dev/blog"}, 1},
+		{"different links", moltbook.Post{Title: "Go blog", URL: "https://go.dev/blog"}, moltbook.Post{Title: "Rust blog", URL: "https://blog.rust-lang.org"}, 0},
+		// The body makes the posts alike even though the titles differ
+		{"same content", moltbook.Post{Title: "Notes", Content: "what we learned this week about generics"},
+			moltbook.Post{Title: "Weekly", Content: "what we learned this week about generics"}, 0.75},
+		// Search results have no content and are compared by title
+		{"title only", moltbook.Post{Title: "Weekly notes", Content: "Body"}, moltbook.Post{Title: "Weekly notes"}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Compare(&tt.a, &tt.b); got != tt.want {
+				t.Errorf("Compare = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindThreshold(t *testing.T) {
+	post := &moltbook.Post{Title: "one two three four five six"}
+	// Pairs shared with the post: 5 of 5, 4 of 6, 4 of 5
+	same := moltbook.Post{ID: "same", Title: "One two three four five six"}
+	below := moltbook.Post{ID: "below", Title: "one two three four five seven"}
+	at := moltbook.Post{ID: "at", Title: "one two three four five"}
+
+	tests := []struct {
+		name        string
+		id          string
+		own, others []moltbook.Post
+		want        string
+		wantOwn     bool
+	}{
+		{"no candidates", "", nil, nil, "", false},
+		{"below threshold", "", nil, []moltbook.Post{below}, "", false},
+		{"at threshold", "", nil, []moltbook.Post{at}, "at", false},
+		{"most similar wins", "", nil, []moltbook.Post{at, same}, "same", false},
+		{"own preferred at equal similarity", "", []moltbook.Post{same}, []moltbook.Post{same}, "same", true},
+		{"the post itself is skipped", "self", nil, []moltbook.Post{{ID: "self", Title: post.Title}}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidate := *post
+			candidate.ID = tt.id
+			match := Find(&candidate, tt.own, tt.others, DefaultThreshold)
+			if tt.want == "" {
+				if match != nil {
+					t.Errorf("matched %s at %v, want no match", match.Post.ID, match.Similarity)
+				}
+				return
+			}
+			if match == nil {
+				t.Fatalf("no match, want %s", tt.want)
+			}
+			if match.Post.ID != tt.want || match.Own != tt.wantOwn {
+				t.Errorf("matched %s (own %v), want %s (own %v)", match.Post.ID, match.Own, tt.want, tt.wantOwn)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+// Package drafts implements a local queue of posts waiting to be published.
+package drafts
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/moltgo/moltgo/pkg/filelock"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/timestamp"
+)
+
+// Retry backoff for drafts that failed to publish
+const (
+	RetryBackoff    = 5 * time.Minute
+	MaxRetryBackoff = 2 * time.Hour
+)
+
+// Draft is a post stored locally until it is published
+type Draft struct {
+	ID        int            `toml:"id"`
+	Submolt   string         `toml:"submolt"`
+	Title     string         `toml:"title"`
+	URL       string         `toml:"url,omitempty"`
+	Content   string         `toml:"content,omitempty"`
+	Priority  int            `toml:"priority"`
+	PublishAt timestamp.Time `toml:"publish_at,omitempty"`
+	CreatedAt timestamp.Time `toml:"created_at"`
+
+	// Publishing attempts that failed
+	Attempts    int            `toml:"attempts,omitempty"`
+	LastAttempt timestamp.Time `toml:"last_attempt,omitempty"`
+	LastError   string         `toml:"last_error,omitempty"`
+}
+
+// Due reports whether the draft may be published at now. Drafts that
+// failed are held back with an exponential backoff.
+func (d *Draft) Due(now time.Time) bool {
+	if now.Before(d.PublishAt.Time) {
+		return false
+	}
+	return !now.Before(d.RetryAt())
+}
+
+// RetryAt returns when a failed draft may be retried, or the zero time if
+// the draft has not failed
+func (d *Draft) RetryAt() time.Time {
+	if d.Attempts == 0 || d.LastAttempt.IsZero() {
+		return time.Time{}
+	}
+	backoff := RetryBackoff << (d.Attempts - 1)
+	if backoff > MaxRetryBackoff || backoff <= 0 {
+		backoff = MaxRetryBackoff
+	}
+	return d.LastAttempt.Add(backoff)
+}
+
+// Request converts the draft to a post creation request
+func (d *Draft) Request() *moltbook.CreatePostRequest {
+	return &moltbook.CreatePostRequest{
+		Submolt: d.Submolt,
+		Title:   d.Title,
+		Content: d.Content,
+		URL:     d.URL,
+	}
+}
+
+// Queue holds the stored drafts
+type Queue struct {
+	NextID int     `toml:"next_id"`
+	Drafts []Draft `toml:"drafts"`
+}
+
+// Load reads the queue from path. A missing file is an empty queue.
+func Load(path string) (*Queue, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Queue{NextID: 1}, nil
+		}
+		return nil, fmt.Errorf("failed to read drafts: %w", err)
+	}
+
+	var q Queue
+	if err := toml.Unmarshal(data, &q); err != nil {
+		return nil, fmt.Errorf("failed to parse drafts: %w", err)
+	}
+	if q.NextID < 1 {
+		q.NextID = 1
+	}
+	return &q, nil
+}
+
+// Save writes the queue to path
+func (q *Queue) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(q); err != nil {
+		return fmt.Errorf("failed to marshal drafts: %w", err)
+	}
+
+	if err := os.WriteFile(path, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("failed to write drafts: %w", err)
+	}
+	return nil
+}
+
+// Update locks the queue at path, loads it, applies fn and saves the result
+// unless fn fails. Commands editing drafts and runs publishing them go
+// through Update, so neither loses the other's changes.
+func Update(path string, fn func(q *Queue) error) error {
+	unlock, err := filelock.Lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	q, err := Load(path)
+	if err != nil {
+		return err
+	}
+	if err := fn(q); err != nil {
+		return err
+	}
+	return q.Save(path)
+}
+
+// Add stores a new draft and returns it with its assigned ID
+func (q *Queue) Add(d Draft, now time.Time) *Draft {
+	d.ID = q.NextID
+	q.NextID++
+	d.CreatedAt = timestamp.New(now)
+	q.Drafts = append(q.Drafts, d)
+	return &q.Drafts[len(q.Drafts)-1]
+}
+
+// Get returns the draft with the given ID, or nil
+func (q *Queue) Get(id int) *Draft {
+	for i := range q.Drafts {
+		if q.Drafts[i].ID == id {
+			return &q.Drafts[i]
+		}
+	}
+	return nil
+}
+
+// Remove deletes the draft with the given ID and reports whether it existed
+func (q *Queue) Remove(id int) bool {
+	for i := range q.Drafts {
+		if q.Drafts[i].ID == id {
+			q.Drafts = append(q.Drafts[:i], q.Drafts[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Sorted returns the drafts in publishing order: highest priority first,
+// then earliest schedule, then oldest
+func (q *Queue) Sorted() []Draft {
+	sorted := make([]Draft, len(q.Drafts))
+	copy(sorted, q.Drafts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Priority != b.Priority {
+			return a.Priority > b.Priority
+		}
+		if !a.PublishAt.Equal(b.PublishAt.Time) {
+			// Unscheduled drafts go first
+			if a.PublishAt.IsZero() || b.PublishAt.IsZero() {
+				return a.PublishAt.IsZero()
+			}
+			return a.PublishAt.Before(b.PublishAt.Time)
+		}
+		return a.ID < b.ID
+	})
+	return sorted
+}
+
+// NextDue returns the first draft in publishing order that is due at now,
+// or nil
+func (q *Queue) NextDue(now time.Time) *Draft {
+	for _, d := range q.Sorted() {
+		if d.Due(now) {
+			return q.Get(d.ID)
+		}
+	}
+	return nil
+}
+
+// unfinished is the error a draft shows while it is being published, and
+// keeps if the run publishing it stops before the outcome is known
+const unfinished = "publishing did not finish"
+
+// Begin records an attempt to publish the draft with the given ID before
+// its post is sent. The attempt holds the draft back for the retry backoff,
+// so another run does not publish it at the same time, nor after a run
+// that died while sending it, before the duplicate check can see the post.
+func (q *Queue) Begin(id int, now time.Time) (*Draft, error) {
+	d := q.Get(id)
+	if d == nil {
+		return nil, fmt.Errorf("draft %d not found", id)
+	}
+	d.Attempts++
+	d.LastAttempt = timestamp.New(now)
+	d.LastError = unfinished
+	return d, nil
+}
+
+// Finish records the outcome of the attempt begun with Begin. On success,
+// or when the post is held for approval, the draft is removed from the
+// queue; on failure the error is kept and the draft is retried after the
+// backoff.
+func (q *Queue) Finish(id int, err error) {
+	if err == nil || errors.Is(err, moltbook.ErrHeld) {
+		q.Remove(id)
+		return
+	}
+	if d := q.Get(id); d != nil {
+		d.LastError = err.Error()
+	}
+}
+
+// ParseSchedule parses a publish time given as RFC 3339 or as a local
+// "2006-01-02T15:04" or "2006-01-02 15:04" time
+func ParseSchedule(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	for _, layout := range []string{"2006-01-02T15:04", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid schedule %q (use 2006-01-02T15:04 or RFC 3339)", s)
+}
@@ -0,0 +1,189 @@
+package drafts
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+)
+
+var now = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestBeginHoldsDraftBack(t *testing.T) {
+	q := &Queue{NextID: 1}
+	d := q.Add(Draft{Submolt: "general", Title: "Notes"}, now)
+
+	if _, err := q.Begin(d.ID, now); err != nil {
+		t.Fatal(err)
+	}
+	if q.NextDue(now.Add(time.Minute)) != nil {
+		t.Errorf("draft being published is due again")
+	}
+	if got := q.Get(d.ID).LastError; got != unfinished {
+		t.Errorf("LastError = %q, want %q", got, unfinished)
+	}
+	if q.NextDue(now.Add(RetryBackoff)) == nil {
+		t.Errorf("unfinished draft is not retried after the backoff")
+	}
+
+	if _, err := q.Begin(42, now); err == nil {
+		t.Errorf("Begin of a missing draft succeeded")
+	}
+}
+
+func TestFinish(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		removed bool
+	}{
+		{"published", nil, true},
+		{"held for approval", fmt.Errorf("post: %w", moltbook.ErrHeld), true},
+		{"failed", errors.New("rate limited"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := &Queue{NextID: 1}
+			d := q.Add(Draft{Title: "Notes"}, now)
+			if _, err := q.Begin(d.ID, now); err != nil {
+				t.Fatal(err)
+			}
+			q.Finish(1, tt.err)
+
+			got := q.Get(1)
+			if (got == nil) != tt.removed {
+				t.Fatalf("draft removed = %v, want %v", got == nil, tt.removed)
+			}
+			if got != nil && got.LastError != tt.err.Error() {
+				t.Errorf("LastError = %q, want %q", got.LastError, tt.err.Error())
+			}
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{0, 0},
+		{1, RetryBackoff},
+		{2, 2 * RetryBackoff},
+		{3, 4 * RetryBackoff},
+		{10, MaxRetryBackoff},
+		{100, MaxRetryBackoff},
+	}
+	for _, tt := range tests {
+		d := Draft{Attempts: tt.attempts}
+		d.LastAttempt.Time = now
+		var want time.Time
+		if tt.attempts > 0 {
+			want = now.Add(tt.want)
+		}
+		if got := d.RetryAt(); !got.Equal(want) {
+			t.Errorf("RetryAt after %d attempts = %v, want %v", tt.attempts, got, want)
+		}
+	}
+}
+
+func TestSorted(t *testing.T) {
+	q := &Queue{NextID: 1}
+	q.Add(Draft{Title: "low"}, now)
+	later := q.Add(Draft{Title: "later", Priority: 1}, now)
+	later.PublishAt.Time = now.Add(2 * time.Hour)
+	sooner := q.Add(Draft{Title: "sooner", Priority: 1}, now)
+	sooner.PublishAt.Time = now.Add(time.Hour)
+	q.Add(Draft{Title: "unscheduled", Priority: 1}, now)
+	q.Add(Draft{Title: "high", Priority: 5}, now)
+
+	var got []string
+	for _, d := range q.Sorted() {
+		got = append(got, d.Title)
+	}
+	want := []string{"high", "unscheduled", "sooner", "later", "low"}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Sorted = %v, want %v", got, want)
+	}
+	if d := q.NextDue(now); d == nil || d.Title != "high" {
+		t.Errorf("NextDue = %v, want high", d)
+	}
+}
+
+func TestUpdateSerializesWriters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drafts.toml")
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := Update(path, func(q *Queue) error {
+				q.Add(Draft{Title: fmt.Sprintf("draft %d", i)}, now)
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	q, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(q.Drafts) != 8 || q.NextID != 9 {
+		t.Errorf("got %d drafts and next ID %d, want 8 and 9", len(q.Drafts), q.NextID)
+	}
+}
+
+func TestUpdateKeepsQueueOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drafts.toml")
+	if err := Update(path, func(q *Queue) error {
+		q.Add(Draft{Title: "kept"}, now)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := errors.New("no")
+	err := Update(path, func(q *Queue) error {
+		q.Remove(1)
+		return failed
+	})
+	if !errors.Is(err, failed) {
+		t.Fatalf("Update error = %v, want %v", err, failed)
+	}
+	q, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Get(1) == nil {
+		t.Errorf("failed update was saved")
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-10-17T09:00:00Z", time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)},
+		{"2026-10-17T09:00", time.Date(2026, 10, 17, 9, 0, 0, 0, time.Local)},
+		{"2026-10-17 09:00", time.Date(2026, 10, 17, 9, 0, 0, 0, time.Local)},
+		{"2026-10-17", time.Date(2026, 10, 17, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := ParseSchedule(tt.in)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseSchedule(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	if _, err := ParseSchedule("tomorrow"); err == nil {
+		t.Errorf("ParseSchedule accepted %q", "tomorrow")
+	}
+}
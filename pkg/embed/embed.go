@@ -0,0 +1,48 @@
+// Package embed turns text into vectors for semantic search.
+package embed
+
+import (
+	"context"
+	"math"
+)
+
+// Provider computes embeddings. Vectors from different providers, or from
+// one provider with different models, are not comparable; Name identifies
+// the vector space a provider produces.
+type Provider interface {
+	Name() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// Cosine returns the cosine similarity of two vectors, or 0 if their
+// lengths differ
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// normalize scales v to unit length in place
+func normalize(v []float32) {
+	var sum float64
+	for _, x := range v {
+		sum += float64(x) * float64(x)
+	}
+	if sum == 0 {
+		return
+	}
+	n := float32(math.Sqrt(sum))
+	for i := range v {
+		v[i] /= n
+	}
+}
@@ -0,0 +1,87 @@
+package embed
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// DefaultDimensions is the vector size of the hashing provider
+const DefaultDimensions = 512
+
+// stopwords carry no topic and are left out of hashed vectors
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true,
+	"be": true, "but": true, "by": true, "for": true, "from": true, "has": true,
+	"have": true, "i": true, "if": true, "in": true, "is": true, "it": true,
+	"its": true, "of": true, "on": true, "or": true, "so": true, "that": true,
+	"the": true, "this": true, "to": true, "was": true, "we": true, "were": true,
+	"what": true, "with": true, "you": true, "your": true,
+}
+
+// Hashing is a provider that needs no model. Words and word pairs are
+// hashed into a fixed number of dimensions and weighted by log term
+// frequency, so texts sharing vocabulary end up close together.
+type Hashing struct {
+	Dimensions int
+}
+
+// Name identifies the hashing vector space
+func (h Hashing) Name() string {
+	return fmt.Sprintf("hashing-%d", h.dims())
+}
+
+// Embed hashes each text into a unit vector
+func (h Hashing) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vectors[i] = h.vector(text)
+	}
+	return vectors, nil
+}
+
+func (h Hashing) dims() int {
+	if h.Dimensions > 0 {
+		return h.Dimensions
+	}
+	return DefaultDimensions
+}
+
+func (h Hashing) vector(text string) []float32 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	counts := make(map[string]int)
+	prev := ""
+	for _, w := range words {
+		if stopwords[w] {
+			prev = ""
+			continue
+		}
+		counts[w]++
+		if prev != "" {
+			counts[prev+" "+w]++
+		}
+		prev = w
+	}
+
+	v := make([]float32, h.dims())
+	for feature, n := range counts {
+		hash := fnv.New64a()
+		hash.Write([]byte(feature))
+		sum := hash.Sum64()
+
+		// The top bit picks a sign so that collisions tend to cancel out
+		weight := float32(1 + math.Log(float64(n)))
+		if sum>>63 == 1 {
+			weight = -weight
+		}
+		v[sum%uint64(len(v))] += weight
+	}
+	normalize(v)
+	return v
+}
@@ -0,0 +1,112 @@
+package embed
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAI is a provider for any server implementing the OpenAI embeddings
+// API, such as Ollama, llama.cpp or vLLM
+type OpenAI struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOpenAI creates a provider for the embeddings API at baseURL (for
+// example "http://localhost:11434/v1")
+func NewOpenAI(baseURL, apiKey, model string, timeout time.Duration) *OpenAI {
+	return &OpenAI{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// Name identifies the model's vector space
+func (o *OpenAI) Name() string {
+	return "openai-" + o.model
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Embed requests embeddings for the texts in one batch
+func (o *OpenAI) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	jsonData, err := json.Marshal(embeddingRequest{Model: o.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/embeddings", bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var result embeddingResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("embedding error (status %d): %s", resp.StatusCode, string(body))
+		}
+		return nil, fmt.Errorf("failed to parse embeddings: %w", err)
+	}
+
+	if result.Error != nil {
+		return nil, fmt.Errorf("embedding error (status %d): %s", resp.StatusCode, result.Error.Message)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("embedding error (status %d): %s", resp.StatusCode, string(body))
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding server returned %d vectors for %d texts", len(result.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, d := range result.Data {
+		if d.Index < 0 || d.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding server returned an invalid index %d", d.Index)
+		}
+		normalize(d.Embedding)
+		vectors[d.Index] = d.Embedding
+	}
+	return vectors, nil
+}
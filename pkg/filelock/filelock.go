@@ -0,0 +1,89 @@
+// Package filelock serializes processes that read, modify and write the
+// same file, with a lock file created next to it.
+package filelock
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// wait is how long Lock waits for another process's lock, and stale how old
+// a lock file must be to be taken as left by a crash when it names no
+// process
+const (
+	wait  = 5 * time.Second
+	stale = 30 * time.Second
+)
+
+// Lock takes the lock on path, held as the file path+".lock", and returns
+// the function that releases it. The lock file holds the owner's process ID,
+// so a lock left by a process that died is broken at once while a live
+// owner keeps its lock however long it holds it.
+func Lock(path string) (func(), error) {
+	lockPath := path + ".lock"
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	deadline := time.Now().Add(wait)
+	for {
+		f, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err == nil {
+			_, werr := fmt.Fprintf(f, "%d\n", os.Getpid())
+			if cerr := f.Close(); werr == nil {
+				werr = cerr
+			}
+			if werr != nil {
+				os.Remove(lockPath)
+				return nil, fmt.Errorf("failed to lock %s: %w", path, werr)
+			}
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+		}
+		if abandoned(lockPath) {
+			os.Remove(lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to lock %s: %s is held by another process", path, lockPath)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// abandoned reports whether the lock file at lockPath was left by a process
+// that is no longer running. A lock file without a process ID, as written by
+// a process that died between creating and writing it, is abandoned once it
+// is stale.
+func abandoned(lockPath string) bool {
+	info, err := os.Stat(lockPath)
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return time.Since(info.ModTime()) > stale
+	}
+	return !processAlive(pid)
+}
+
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
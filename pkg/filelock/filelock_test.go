@@ -0,0 +1,73 @@
+package filelock
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLockWritesPID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.toml")
+	unlock, err := Lock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path + ".lock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(data)); got != strconv.Itoa(os.Getpid()) {
+		t.Errorf("lock file holds %q, want our PID", got)
+	}
+	if abandoned(path + ".lock") {
+		t.Errorf("lock held by a live process is abandoned")
+	}
+
+	unlock()
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("lock file remains after unlock: %v", err)
+	}
+}
+
+func TestLockBreaksAbandonedLocks(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		age      time.Duration
+	}{
+		{"dead owner", "2147483646\n", 0},
+		{"no PID, stale", "", time.Minute},
+		{"garbage, stale", "not a pid", time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "queue.toml")
+			if err := os.WriteFile(path+".lock", []byte(tt.contents), 0600); err != nil {
+				t.Fatal(err)
+			}
+			old := time.Now().Add(-tt.age)
+			if err := os.Chtimes(path+".lock", old, old); err != nil {
+				t.Fatal(err)
+			}
+
+			unlock, err := Lock(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			unlock()
+		})
+	}
+}
+
+func TestFreshLockWithoutPIDIsHeld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.toml")
+	if err := os.WriteFile(path+".lock", nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if abandoned(path + ".lock") {
+		t.Errorf("a lock file still being written is abandoned")
+	}
+}
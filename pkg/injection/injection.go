@@ -0,0 +1,248 @@
+// Package injection flags inbound posts and comments that look like prompt
+// injection: attempts to override an agent's instructions, requests for its
+// secrets, and hidden or encoded payloads.
+package injection
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+)
+
+// Signal kinds
+const (
+	KindOverride     = "instruction override"
+	KindExfiltration = "secret request"
+	KindRoleMarkup   = "chat role markup"
+	KindDirective    = "instructions aimed at agents"
+	KindEncoded      = "encoded payload"
+	KindHidden       = "hidden characters"
+)
+
+// Threshold is the score at which content is flagged
+const Threshold = 3
+
+// Signal is one piece of evidence of an injection attempt
+type Signal struct {
+	Kind   string
+	Match  string
+	Weight int
+}
+
+// Report is the result of analyzing a piece of content
+type Report struct {
+	Signals []Signal
+	Score   int
+}
+
+// Flagged reports whether the content is a likely injection attempt
+func (r Report) Flagged() bool {
+	return r.Score >= Threshold
+}
+
+// Kinds lists the distinct kinds of signals found, strongest first
+func (r Report) Kinds() []string {
+	weights := make(map[string]int)
+	for _, s := range r.Signals {
+		weights[s.Kind] += s.Weight
+	}
+	kinds := make([]string, 0, len(weights))
+	for k := range weights {
+		kinds = append(kinds, k)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		if weights[kinds[i]] != weights[kinds[j]] {
+			return weights[kinds[i]] > weights[kinds[j]]
+		}
+		return kinds[i] < kinds[j]
+	})
+	return kinds
+}
+
+type pattern struct {
+	kind   string
+	weight int
+	re     *regexp.Regexp
+}
+
+var patterns = []pattern{
+	{KindOverride, 3, regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override|bypass)\b[^.\n]{0,40}?\b(?:previous|prior|above|earlier|preceding|all|any|your|system)\b[^.\n]{0,20}?\b(?:instructions?|prompts?|rules|directives|guidelines|programming)\b`)},
+	{KindOverride, 3, regexp.MustCompile(`(?i)\b(?:new|updated|real|actual) (?:system )?instructions\s*:`)},
+	{KindOverride, 3, regexp.MustCompile(`(?i)\byou are now (?:in )?(?:developer|dan|jailbreak|unrestricted|god|admin) mode\b|\bdo anything now\b|\bjailbreak(?:en)? mode\b`)},
+	{KindExfiltration, 3, regexp.MustCompile(`(?i)\b(?:post|share|send|reveal|print|output|tell|give|paste|leak|include|dump|show)\b[^.\n]{0,40}?\b(?:your|the|its|agent'?s?)\b[^.\n]{0,20}?\b(?:api[ _-]?keys?|secrets?|passwords?|credentials?|tokens?|system prompt|private keys?|env(?:ironment)? variables?|config(?:uration)? files?)\b`)},
+	{KindRoleMarkup, 3, regexp.MustCompile(`(?im)<\|im_(?:start|end)\|>|<\|(?:system|user|assistant)\|>|\[/?INST\]|<</?SYS>>|</?system>|^\s*#{2,3}\s*(?:system|instructions?)\s*:?\s*$`)},
+	{KindDirective, 1, regexp.MustCompile(`(?i)\b(?:attention|note to|message to|calling all|dear|hey)\s+(?:all\s+|any\s+|fellow\s+)?(?:ai|llm|agents?|bots?|assistants?|language models?)\b`)},
+	{KindDirective, 2, regexp.MustCompile(`(?i)\b(?:if you are|as) an? (?:ai|llm|language model|agent|bot|assistant)\b[^.\n]{0,40}?\b(?:you must|you should|must|should|reply|respond|upvote|follow|execute|run)\b`)},
+}
+
+var (
+	base64Re     = regexp.MustCompile(`[A-Za-z0-9+/]{40,}={0,2}`)
+	hexEscapeRe  = regexp.MustCompile(`(?i)(?:\\x[0-9a-f]{2}){8,}`)
+	percentEncRe = regexp.MustCompile(`(?i)(?:%[0-9a-f]{2}){8,}`)
+)
+
+// Analyze inspects text for signs of prompt injection
+func Analyze(text string) Report {
+	var r Report
+	add := func(kind, match string, weight int) {
+		r.Signals = append(r.Signals, Signal{Kind: kind, Match: match, Weight: weight})
+		r.Score += weight
+	}
+
+	// Invisible characters can hide instructions from human readers
+	if hidden, tags := hiddenRunes(text); hidden > 0 {
+		weight := 2
+		if tags > 0 {
+			// Unicode tag characters can smuggle whole ASCII strings
+			weight = 3
+		}
+		add(KindHidden, "", weight)
+		text = visible(text)
+	}
+
+	scan(text, add)
+
+	// Decode payloads and look inside them
+	for _, payload := range decodePayloads(text) {
+		inner := Analyze(payload)
+		weight := 1
+		if inner.Score > 0 {
+			weight = 3
+		}
+		add(KindEncoded, payload, weight)
+	}
+	return r
+}
+
+func scan(text string, add func(kind, match string, weight int)) {
+	for _, p := range patterns {
+		if m := p.re.FindString(text); m != "" {
+			add(p.kind, m, p.weight)
+		}
+	}
+}
+
+// hiddenRunes counts zero-width, bidirectional control and tag characters.
+// A zero-width joiner after an emoji is part of an emoji sequence, such as
+// a family or a profession, and is not counted.
+func hiddenRunes(text string) (hidden, tags int) {
+	var prev rune
+	for _, r := range text {
+		switch {
+		case r >= 0xe0000 && r <= 0xe007f:
+			hidden++
+			tags++
+		case r == 0x200d && (prev == 0xfe0f || unicode.In(prev, unicode.So, unicode.Sk)):
+			// Joins emoji
+		case r >= 0x200b && r <= 0x200f, r >= 0x202a && r <= 0x202e,
+			r >= 0x2060 && r <= 0x2064, r >= 0x2066 && r <= 0x2069, r == 0xfeff:
+			hidden++
+		}
+		prev = r
+	}
+	return hidden, tags
+}
+
+// visible removes hidden characters from text, turning tag characters back
+// into the ASCII they encode so their content is analyzed too
+func visible(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r >= 0xe0020 && r <= 0xe007e:
+			b.WriteRune(r - 0xe0000)
+		case r >= 0xe0000 && r <= 0xe007f:
+		case r >= 0x200b && r <= 0x200f, r >= 0x202a && r <= 0x202e,
+			r >= 0x2060 && r <= 0x2064, r >= 0x2066 && r <= 0x2069, r == 0xfeff:
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// decodePayloads returns the readable text hidden in base64, hex-escaped and
+// percent-encoded runs
+func decodePayloads(text string) []string {
+	var payloads []string
+	for _, m := range base64Re.FindAllString(text, -1) {
+		data, err := base64.StdEncoding.DecodeString(m)
+		if err != nil {
+			data, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(m, "="))
+		}
+		if err == nil && readable(data) {
+			payloads = append(payloads, string(data))
+		}
+	}
+	for _, m := range hexEscapeRe.FindAllString(text, -1) {
+		data, err := hex.DecodeString(strings.ReplaceAll(strings.ReplaceAll(m, `\x`, ""), `\X`, ""))
+		if err == nil && readable(data) {
+			payloads = append(payloads, string(data))
+		}
+	}
+	for _, m := range percentEncRe.FindAllString(text, -1) {
+		if s, err := url.PathUnescape(m); err == nil && readable([]byte(s)) {
+			payloads = append(payloads, s)
+		}
+	}
+	return payloads
+}
+
+// readable reports whether data is mostly printable text
+func readable(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	s := string(data)
+	printable, total := 0, 0
+	for _, r := range s {
+		total++
+		if r == '\n' || r == '\t' || r >= 0x20 && r != 0x7f {
+			printable++
+		}
+	}
+	return total > 0 && printable*10 >= total*9 && strings.ContainsRune(s, ' ')
+}
+
+// AnalyzePost inspects a post's title and content
+func AnalyzePost(post *moltbook.Post) Report {
+	return Analyze(post.Title + "\n" + post.Content)
+}
+
+// AnalyzeComment inspects a comment's content
+func AnalyzeComment(comment *moltbook.Comment) Report {
+	return Analyze(comment.Content)
+}
+
+// DropFlagged returns the comment thread without flagged comments and their
+// replies, and the number of comments removed
+func DropFlagged(comments []moltbook.Comment) ([]moltbook.Comment, int) {
+	var kept []moltbook.Comment
+	dropped := 0
+	for _, c := range comments {
+		if AnalyzeComment(&c).Flagged() {
+			dropped += 1 + countThread(c.Replies)
+			continue
+		}
+		var n int
+		c.Replies, n = DropFlagged(c.Replies)
+		dropped += n
+		kept = append(kept, c)
+	}
+	return kept, dropped
+}
+
+func countThread(comments []moltbook.Comment) int {
+	n := len(comments)
+	for _, c := range comments {
+		n += countThread(c.Replies)
+	}
+	return n
+}
@@ -0,0 +1,29 @@
+// Package llm generates agent content with a language model.
+package llm
+
+import "context"
+
+// Message roles
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Message is a single chat message
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Request is a chat completion request
+type Request struct {
+	Messages    []Message
+	Temperature float64
+	MaxTokens   int
+}
+
+// Backend produces completions for chat requests
+type Backend interface {
+	Complete(ctx context.Context, req *Request) (string, error)
+}
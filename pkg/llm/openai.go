@@ -0,0 +1,101 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAI is a Backend for any server implementing the OpenAI chat
+// completions API, such as llama.cpp, Ollama or vLLM
+type OpenAI struct {
+	baseURL    string
+	apiKey     string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOpenAI creates a backend for the chat completions API at baseURL
+// (for example "http://localhost:11434/v1")
+func NewOpenAI(baseURL, apiKey, model string, timeout time.Duration) *OpenAI {
+	return &OpenAI{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		model:      model,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+type chatRequest struct {
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	Temperature float64   `json:"temperature,omitempty"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message Message `json:"message"`
+	} `json:"choices"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Complete sends the request and returns the first choice's content
+func (o *OpenAI) Complete(ctx context.Context, req *Request) (string, error) {
+	jsonData, err := json.Marshal(chatRequest{
+		Model:       o.model,
+		Messages:    req.Messages,
+		Temperature: req.Temperature,
+		MaxTokens:   req.MaxTokens,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", o.baseURL+"/chat/completions", bytes.NewReader(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if o.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+
+	resp, err := o.httpClient.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read response: %w", err)
+	}
+
+	var result chatResponse
+	if err := json.Unmarshal(body, &result); err != nil {
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return "", fmt.Errorf("LLM error (status %d): %s", resp.StatusCode, string(body))
+		}
+		return "", fmt.Errorf("failed to parse completion: %w", err)
+	}
+
+	if result.Error != nil {
+		return "", fmt.Errorf("LLM error (status %d): %s", resp.StatusCode, result.Error.Message)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("LLM error (status %d): %s", resp.StatusCode, string(body))
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("LLM returned no choices")
+	}
+
+	return strings.TrimSpace(result.Choices[0].Message.Content), nil
+}
@@ -0,0 +1,106 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+)
+
+// DefaultPersona is used when no persona is configured
+const DefaultPersona = "You are a thoughtful, curious AI agent participating in Moltbook, a social network for AI agents. You write concisely and add something new to each conversation."
+
+// maxContextChars caps how much of each post or comment is quoted back to
+// the model
+const maxContextChars = 1500
+
+const postFormat = `Reply with the post only, in exactly this format:
+
+---
+title: <a short, specific title>
+---
+<the post body in Markdown>`
+
+// PostMessages builds the prompt for drafting a new post in submolt. Recent
+// posts from the submolt are included so the model avoids repeating them.
+func PostMessages(persona, submolt, topic string, recent []moltbook.Post) []Message {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Write a new post for the /%s community on Moltbook.\n", submolt)
+	if topic != "" {
+		fmt.Fprintf(&b, "Topic: %s\n", topic)
+	}
+	if len(recent) > 0 {
+		b.WriteString("\nRecent posts in this community (do not repeat them):\n")
+		for _, p := range recent {
+			fmt.Fprintf(&b, "- %q by %s\n", p.Title, p.Author)
+		}
+	}
+	b.WriteString("\n" + postFormat)
+
+	return []Message{
+		{Role: RoleSystem, Content: personaOrDefault(persona)},
+		{Role: RoleUser, Content: b.String()},
+	}
+}
+
+// CommentMessages builds the prompt for replying to a post given its
+// existing comment thread
+func CommentMessages(persona string, post *moltbook.Post, comments []moltbook.Comment) []Message {
+	var b strings.Builder
+	b.WriteString("Write a comment replying to this Moltbook post.\n\n")
+	fmt.Fprintf(&b, "Post by %s in /%s\nTitle: %s\n", post.Author, post.Submolt, post.Title)
+	if post.URL != "" {
+		fmt.Fprintf(&b, "Link: %s\n", post.URL)
+	}
+	if post.Content != "" {
+		fmt.Fprintf(&b, "\n%s\n", clip(post.Content))
+	}
+
+	if len(comments) > 0 {
+		b.WriteString("\nExisting comments:\n")
+		writeThread(&b, comments, 0)
+	}
+
+	b.WriteString("\nReply with the comment text only, in Markdown, without a preamble.")
+
+	return []Message{
+		{Role: RoleSystem, Content: personaOrDefault(persona)},
+		{Role: RoleUser, Content: b.String()},
+	}
+}
+
+func writeThread(b *strings.Builder, comments []moltbook.Comment, depth int) {
+	for _, c := range comments {
+		fmt.Fprintf(b, "%s- %s: %s\n", strings.Repeat("  ", depth), c.Author, strings.Join(strings.Fields(clip(c.Content)), " "))
+		writeThread(b, c.Replies, depth+1)
+	}
+}
+
+func personaOrDefault(persona string) string {
+	if strings.TrimSpace(persona) == "" {
+		return DefaultPersona
+	}
+	return persona
+}
+
+func clip(s string) string {
+	r := []rune(s)
+	if len(r) <= maxContextChars {
+		return s
+	}
+	return string(r[:maxContextChars]) + "…"
+}
+
+// StripFences removes a Markdown code fence wrapped around the whole
+// completion, which some models add
+func StripFences(s string) string {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "```") || !strings.HasSuffix(s, "```") {
+		return s
+	}
+	s = strings.TrimSuffix(s, "```")
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		s = s[i+1:]
+	}
+	return strings.TrimSpace(s)
+}
@@ -0,0 +1,288 @@
+// Package metrics keeps counters and histograms and exposes them in the
+// Prometheus text format, either over HTTP or as a node-exporter textfile.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefBuckets are histogram buckets, in seconds, suited to API latencies
+var DefBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Registry holds metric families
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{families: make(map[string]*family)}
+}
+
+// Default is the registry moltgo's metrics are kept in
+var Default = NewRegistry()
+
+type kind string
+
+const (
+	counterKind   kind = "counter"
+	histogramKind kind = "histogram"
+)
+
+// family is a metric and all its labeled series
+type family struct {
+	name    string
+	help    string
+	kind    kind
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+// series is one combination of label values
+type series struct {
+	values []string
+
+	value float64 // counters
+
+	counts []uint64 // histograms, per bucket (not cumulative)
+	sum    float64
+	count  uint64
+}
+
+// family returns the named family, creating it on first use
+func (r *Registry) family(name, help string, k kind, buckets []float64, labels []string) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if f, ok := r.families[name]; ok {
+		if f.kind != k || len(f.labels) != len(labels) {
+			panic(fmt.Sprintf("metrics: %s registered twice with different types or labels", name))
+		}
+		return f
+	}
+	f := &family{
+		name:    name,
+		help:    help,
+		kind:    k,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	if len(labels) == 0 {
+		// Without labels there is one series, exposed from the start
+		f.get(nil)
+	}
+	r.families[name] = f
+	return f
+}
+
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{values: append([]string(nil), values...)}
+		if f.kind == histogramKind {
+			s.counts = make([]uint64, len(f.buckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Counter is a value that only goes up, per combination of label values
+type Counter struct {
+	f *family
+}
+
+// Counter returns the counter with the name, creating it on first use
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{r.family(name, help, counterKind, nil, labels)}
+}
+
+// Inc adds 1 to the series with the label values
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds v, which must not be negative, to the series with the label
+// values
+func (c *Counter) Add(v float64, values ...string) {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	c.f.get(values).value += v
+}
+
+// Value returns the current value of the series with the label values, or
+// 0 if nothing was counted for them. Reading does not create the series.
+func (c *Counter) Value(values ...string) float64 {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	if s, ok := c.f.series[strings.Join(values, "\xff")]; ok {
+		return s.value
+	}
+	return 0
+}
+
+// Histogram counts observations into buckets, per combination of label
+// values
+type Histogram struct {
+	f *family
+}
+
+// Histogram returns the histogram with the name, creating it on first use.
+// buckets are upper bounds in increasing order; +Inf is implied.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{r.family(name, help, histogramKind, buckets, labels)}
+}
+
+// Observe records v in the series with the label values
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	s := h.f.get(values)
+	for i, upper := range h.f.buckets {
+		if v <= upper {
+			s.counts[i]++
+			break
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+// WriteText writes every metric in the Prometheus text exposition format
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	families := make([]*family, len(names))
+	sort.Strings(names)
+	for i, name := range names {
+		families[i] = r.families[name]
+	}
+	r.mu.Unlock()
+
+	var b strings.Builder
+	for _, f := range families {
+		f.write(&b)
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (f *family) write(b *strings.Builder) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fmt.Fprintf(b, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(b, "# TYPE %s %s\n", f.name, f.kind)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if f.kind == counterKind {
+			fmt.Fprintf(b, "%s%s %s\n", f.name, labelText(f.labels, s.values, "", ""), formatValue(s.value))
+			continue
+		}
+
+		var cumulative uint64
+		for i, upper := range f.buckets {
+			cumulative += s.counts[i]
+			fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, labelText(f.labels, s.values, "le", formatValue(upper)), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", f.name, labelText(f.labels, s.values, "le", "+Inf"), s.count)
+		fmt.Fprintf(b, "%s_sum%s %s\n", f.name, labelText(f.labels, s.values, "", ""), formatValue(s.sum))
+		fmt.Fprintf(b, "%s_count%s %d\n", f.name, labelText(f.labels, s.values, "", ""), s.count)
+	}
+}
+
+// labelText formats label pairs, with an extra pair such as le when
+// extraName is set
+func labelText(names, values []string, extraName, extraValue string) string {
+	var pairs []string
+	for i, name := range names {
+		pairs = append(pairs, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	if extraName != "" {
+		pairs = append(pairs, extraName+`="`+extraValue+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+// Handler serves the registry's metrics over HTTP
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteText(w)
+	})
+}
+
+// WriteTextfile writes the metrics to path for node-exporter's textfile
+// collector. The file is replaced atomically so the collector never reads
+// a partial file; path should end in ".prom".
+func (r *Registry) WriteTextfile(path string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".moltgo-metrics-*")
+	if err != nil {
+		return fmt.Errorf("failed to create metrics file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := r.WriteText(tmp); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write metrics file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"flag"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// testRegistry holds a counter with and without labels and a histogram,
+// with label values and help text that need escaping
+func testRegistry() *Registry {
+	r := NewRegistry()
+	requests := r.Counter("test_requests_total", "Requests by method and path", "method", "path")
+	requests.Inc("GET", "/posts")
+	requests.Add(2, "GET", "/posts")
+	requests.Inc("POST", `/search?q="go"\n`)
+	requests.Inc("GET", "line\nbreak")
+
+	r.Counter("test_idle_total", "A counter\nwith a \\ in its help")
+
+	latency := r.Histogram("test_duration_seconds", "Request latency", []float64{0.1, 1, 10}, "method")
+	for _, v := range []float64{0.05, 0.1, 0.5, 3, 42} {
+		latency.Observe(v, "GET")
+	}
+	latency.Observe(0.25, "POST")
+	return r
+}
+
+func TestWriteText(t *testing.T) {
+	var b strings.Builder
+	if err := testRegistry().WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join("testdata", "exposition.golden")
+	if *update {
+		if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != string(want) {
+		t.Errorf("exposition differs from %s:\n%s", path, b.String())
+	}
+}
+
+func TestCounterValueDoesNotCreateSeries(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("test_total", "Test", "result")
+	if v := c.Value("ok"); v != 0 {
+		t.Errorf("Value of an unused series = %v, want 0", v)
+	}
+
+	var b strings.Builder
+	if err := r.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), `result="ok"`) {
+		t.Errorf("reading created a series:\n%s", b.String())
+	}
+
+	c.Add(2.5, "ok")
+	if v := c.Value("ok"); v != 2.5 {
+		t.Errorf("Value = %v, want 2.5", v)
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	r := NewRegistry()
+	a := r.Counter("test_total", "Test", "result")
+	a.Inc("ok")
+	if b := r.Counter("test_total", "Test", "result"); b.Value("ok") != 1 {
+		t.Errorf("registering again did not return the same counter")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("registering a counter as a histogram did not panic")
+		}
+	}()
+	r.Histogram("test_total", "Test", DefBuckets, "result")
+}
+
+func TestWrongLabelCount(t *testing.T) {
+	c := NewRegistry().Counter("test_total", "Test", "result")
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Inc with missing label values did not panic")
+		}
+	}()
+	c.Inc()
+}
+
+func TestHandlerAndTextfile(t *testing.T) {
+	r := testRegistry()
+	var want strings.Builder
+	if err := r.WriteText(&want); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if rec.Body.String() != want.String() {
+		t.Errorf("handler served:\n%s", rec.Body.String())
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "moltgo.prom")
+	if err := r.WriteTextfile(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want.String() {
+		t.Errorf("textfile holds:\n%s", data)
+	}
+	// The temporary file is renamed into place
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory holds %d files, want only the textfile", len(entries))
+	}
+}
@@ -0,0 +1,25 @@
+package moltbook
+
+import "context"
+
+// API is the set of Moltbook operations a Client performs. Code that talks to
+// Moltbook takes an API so it can be given a fake instead. Each request is
+// sent with the context it is given: it is cancelled with it, and traced as
+// a child of the span it carries.
+type API interface {
+	Register(ctx context.Context, name, description string) (*RegisterResponse, error)
+	GetProfile(ctx context.Context) (*Agent, error)
+	UpdateProfile(ctx context.Context, req *UpdateProfileRequest) (*Agent, error)
+
+	BrowsePosts(ctx context.Context, req *BrowsePostsRequest) ([]Post, error)
+	GetPost(ctx context.Context, postID string) (*Post, error)
+	GetComments(ctx context.Context, postID, sort string) ([]Comment, error)
+	Search(ctx context.Context, query string) ([]Post, error)
+
+	CreatePost(ctx context.Context, req *CreatePostRequest) (*Post, error)
+	CreateComment(ctx context.Context, postID string, content string) (*Comment, error)
+	CreateReply(ctx context.Context, postID, parentID, content string) (*Comment, error)
+	Vote(ctx context.Context, targetType, targetID, direction string) error
+}
+
+var _ API = (*Client)(nil)
@@ -0,0 +1,101 @@
+package moltbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/audit"
+	"github.com/moltgo/moltgo/pkg/timestamp"
+)
+
+// WithAudit records every request that changes something on Moltbook in
+// log, under the agent name profile. The trigger of each entry is taken
+// from the client's context; see audit.WithTrigger. Dry-run and held
+// requests are not sent, and not recorded. Failures to write the log are
+// reported on warn whatever the log level.
+func WithAudit(log *audit.Log, profile string, warn io.Writer) Option {
+	return func(c *Client) {
+		c.audit = log
+		c.profile = profile
+		c.auditWarn = warn
+	}
+}
+
+// auditRequest records a sent mutating request in the audit log. A failure
+// to record it is reported rather than returned: the request has been made,
+// and failing it would invite a retry that makes it again.
+func (c *Client) auditRequest(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, err error) {
+	if c.audit == nil || req.Method == "GET" {
+		return
+	}
+
+	endpoint := trimBase(req.URL.Path, c.baseURL)
+	e := audit.Entry{
+		Time:        timestamp.New(time.Now()),
+		Profile:     c.profile,
+		Action:      actionOf(req.Method, routeOf(endpoint, "")),
+		Endpoint:    endpoint,
+		PayloadHash: audit.HashPayload(reqBody),
+		Trigger:     audit.TriggerFrom(req.Context()),
+	}
+	if resp != nil {
+		e.Status = resp.StatusCode
+		e.ResponseID = responseID(respBody)
+		if err == nil {
+			err = checkEnvelope(resp.StatusCode, respBody)
+		}
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+
+	if _, err := c.audit.Append(e); err != nil {
+		c.logger.Error("failed to write audit log", "error", err)
+		if c.auditWarn != nil {
+			fmt.Fprintf(c.auditWarn, "Warning: %s %s was sent but not recorded in the audit log: %v\n", req.Method, endpoint, err)
+		}
+	}
+}
+
+// actionOf names the action a request takes
+func actionOf(method, route string) string {
+	switch {
+	case method == "DELETE":
+		return "delete"
+	case method == "POST" && route == "/agents/register":
+		return "register"
+	case method == "PATCH" && route == "/agents/me":
+		return "update_profile"
+	case method == "POST" && route == "/posts":
+		return "post"
+	case method == "POST" && route == "/posts/{id}/comments":
+		return "comment"
+	case method == "POST" && route == "/vote":
+		return "vote"
+	}
+	return method + " " + route
+}
+
+// responseID finds the ID of the object a response created or changed,
+// at the top level or under one of the envelope keys
+func responseID(body []byte) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return ""
+	}
+	var id string
+	if err := json.Unmarshal(fields["id"], &id); err == nil && id != "" {
+		return id
+	}
+	for _, key := range []string{"data", "post", "comment", "agent"} {
+		if raw, ok := fields[key]; ok {
+			if id := responseID(raw); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
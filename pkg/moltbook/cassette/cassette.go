@@ -0,0 +1,313 @@
+// Package cassette records Moltbook API interactions to files and replays
+// them, so client and command tests run deterministically without network
+// access.
+//
+// In a test:
+//
+//	rec := cassette.Start(t, "browse")
+//	client := moltbook.NewClient(os.Getenv("MOLTBOOK_API_KEY"), rec.Option())
+//
+// replays testdata/cassettes/browse.json. With MOLTGO_RECORD=1 the requests
+// go to the real API and the cassette is written afresh; diffing it shows
+// how the API's responses changed. Secrets are scrubbed before anything is
+// written.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+)
+
+// EnvRecord is the environment variable that switches to recording
+const EnvRecord = "MOLTGO_RECORD"
+
+// Mode says whether a Recorder records or replays
+type Mode int
+
+const (
+	// Replay answers requests from the cassette and never touches the network
+	Replay Mode = iota
+	// Record sends requests to the API and writes them to the cassette
+	Record
+)
+
+// ModeFromEnv returns Record when MOLTGO_RECORD=1 and Replay otherwise
+func ModeFromEnv() Mode {
+	if os.Getenv(EnvRecord) == "1" {
+		return Record
+	}
+	return Replay
+}
+
+// Headers that change on every request and would only add noise to diffs
+var volatileHeaders = []string{"Date", "Cf-Ray", "X-Request-Id"}
+
+// RecordedRequest is the request half of an interaction
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is the response half of an interaction
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// Interaction is one request and the response it got
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Cassette is the file format: interactions in the order they happened
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records or replays a cassette
+type Recorder struct {
+	path    string
+	mode    Mode
+	real    http.RoundTripper
+	secrets []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// Option configures a Recorder
+type Option func(*Recorder)
+
+// WithTransport sets the transport real requests are sent with when
+// recording
+func WithTransport(rt http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.real = rt
+	}
+}
+
+// WithSecrets adds strings to scrub from cassettes, beyond API keys and
+// authentication headers
+func WithSecrets(secrets ...string) Option {
+	return func(r *Recorder) {
+		r.secrets = append(r.secrets, secrets...)
+	}
+}
+
+// New creates a recorder for the cassette at path. Replaying requires the
+// cassette to exist; recording starts it afresh.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, real: http.DefaultTransport}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == Replay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cassette (record it with %s=1): %w", EnvRecord, err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+// Start opens testdata/cassettes/<name>.json in the mode MOLTGO_RECORD
+// selects, failing the test if it cannot
+func Start(t testing.TB, name string, opts ...Option) *Recorder {
+	t.Helper()
+	r, err := New(filepath.Join("testdata", "cassettes", name+".json"), ModeFromEnv(), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// Mode returns whether the recorder records or replays
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// HTTPClient returns an HTTP client using the recorder
+func (r *Recorder) HTTPClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Option returns a client option routing requests through the recorder
+func (r *Recorder) Option() moltbook.Option {
+	return moltbook.WithHTTPClient(r.HTTPClient())
+}
+
+// Unused returns the recorded interactions that were not replayed
+func (r *Recorder) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var unused []Interaction
+	for i, used := range r.used {
+		if !used {
+			unused = append(unused, r.cassette.Interactions[i])
+		}
+	}
+	return unused
+}
+
+// RoundTrip records or replays a request
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	recorded := RecordedRequest{
+		Method: req.Method,
+		URL:    req.URL.RequestURI(),
+		Header: r.scrubHeader(req.Header),
+		Body:   r.scrub(string(body)),
+	}
+
+	if r.mode == Replay {
+		return r.replay(req, &recorded)
+	}
+
+	out := req.Clone(req.Context())
+	out.Body = io.NopCloser(bytes.NewReader(body))
+	resp, err := r.real.RoundTrip(out)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	err = r.append(Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: r.scrubHeader(resp.Header),
+			Body:   r.scrub(string(respBody)),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// replay answers a request with the first unused interaction recorded for
+// the same method, URL and body
+func (r *Recorder) replay(req *http.Request, recorded *RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || in.Request.Method != recorded.Method || in.Request.URL != recorded.URL ||
+			!sameBody(in.Request.Body, recorded.Body) {
+			continue
+		}
+		r.used[i] = true
+
+		header := in.Response.Header.Clone()
+		if header == nil {
+			header = http.Header{}
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.Status, http.StatusText(in.Response.Status)),
+			StatusCode:    in.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewReader([]byte(in.Response.Body))),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("cassette %s has no recorded response for %s %s (re-record it with %s=1)",
+		r.path, recorded.Method, recorded.URL, EnvRecord)
+}
+
+// append adds an interaction and rewrites the cassette, so an interrupted
+// recording keeps what was recorded so far
+func (r *Recorder) append(in Interaction) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// scrub removes API keys, verification codes and configured secrets
+func (r *Recorder) scrub(s string) string {
+	s = moltbook.Redact(s)
+	for _, secret := range r.secrets {
+		if secret != "" {
+			s = strings.ReplaceAll(s, secret, moltbook.Redacted)
+		}
+	}
+	return s
+}
+
+func (r *Recorder) scrubHeader(h http.Header) http.Header {
+	out := moltbook.RedactHeader(h)
+	for _, name := range volatileHeaders {
+		out.Del(name)
+	}
+	for _, values := range out {
+		for i := range values {
+			values[i] = r.scrub(values[i])
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// sameBody compares request bodies, ignoring JSON formatting
+func sameBody(a, b string) bool {
+	if a == b {
+		return true
+	}
+	var va, vb interface{}
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return bytes.Equal(ja, jb)
+}
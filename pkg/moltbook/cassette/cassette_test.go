@@ -0,0 +1,271 @@
+package cassette_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/moltbook/cassette"
+	"github.com/moltgo/moltgo/pkg/moltbook/moltbooktest"
+)
+
+// startClient opens a cassette and returns a client for the real API
+// routed through it. With MOLTGO_RECORD=1 and MOLTBOOK_API_KEY set, the
+// cassette is recorded afresh from the API.
+func startClient(t *testing.T, name string) (*moltbook.Client, *cassette.Recorder) {
+	t.Helper()
+	rec := cassette.Start(t, name)
+	return moltbook.NewClient(os.Getenv("MOLTBOOK_API_KEY"), rec.Option()), rec
+}
+
+// assertAllUsed fails the test if the cassette has interactions the test
+// did not replay
+func assertAllUsed(t *testing.T, rec *cassette.Recorder) {
+	t.Helper()
+	if rec.Mode() != cassette.Replay {
+		return
+	}
+	for _, in := range rec.Unused() {
+		t.Errorf("unused interaction: %s %s", in.Request.Method, in.Request.URL)
+	}
+}
+
+func TestReplayBrowse(t *testing.T) {
+	client, rec := startClient(t, "browse")
+
+	posts, err := client.BrowsePosts(context.Background(), &moltbook.BrowsePostsRequest{Submolt: "golang", Sort: moltbook.SortNew, Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 || posts[0].Title != "Error wrapping" || posts[1].Title != "Generics in practice" {
+		t.Fatalf("posts = %+v", posts)
+	}
+
+	post, err := client.GetPost(context.Background(), posts[1].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.Content != "Where do type parameters pay off?" || post.NumComments != 2 {
+		t.Errorf("post = %+v", post)
+	}
+	if want := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC); !post.CreatedAt.Equal(want) {
+		t.Errorf("created at %s, want %s", post.CreatedAt, want)
+	}
+
+	comments, err := client.GetComments(context.Background(), post.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || len(comments[0].Replies) != 1 || comments[0].Replies[0].Author != "gopher" {
+		t.Errorf("comments = %+v, want one comment with one reply", comments)
+	}
+	assertAllUsed(t, rec)
+}
+
+func TestReplayPost(t *testing.T) {
+	client, rec := startClient(t, "post")
+
+	post, err := client.CreatePost(context.Background(), &moltbook.CreatePostRequest{Submolt: "golang", Title: "Hello from a cassette", Content: "Recorded once, replayed forever."})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.ID != "post_6" {
+		t.Errorf("post ID = %q", post.ID)
+	}
+	comment, err := client.CreateComment(context.Background(), post.ID, "First comment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.ID != "comment_7" || comment.PostID != post.ID {
+		t.Errorf("comment = %+v", comment)
+	}
+	if err := client.Vote(context.Background(), "post", "post_2", "up"); err != nil {
+		t.Fatal(err)
+	}
+	assertAllUsed(t, rec)
+}
+
+func TestReplayRegister(t *testing.T) {
+	rec := cassette.Start(t, "register")
+
+	res, err := moltbook.Register(context.Background(), "CassetteAgent", "Records cassettes", rec.Option())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.AgentID != "agent_8" {
+		t.Errorf("agent ID = %q", res.AgentID)
+	}
+	if rec.Mode() == cassette.Replay && (res.APIKey != moltbook.Redacted || res.VerificationCode != moltbook.Redacted) {
+		t.Errorf("replayed credentials were not scrubbed: %+v", res)
+	}
+
+	profile, err := moltbook.NewClient(res.APIKey, rec.Option()).GetProfile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Name != "CassetteAgent" {
+		t.Errorf("profile = %+v", profile)
+	}
+	assertAllUsed(t, rec)
+}
+
+func TestReplayErrors(t *testing.T) {
+	client, rec := startClient(t, "errors")
+
+	_, err := client.GetPost(context.Background(), "post_missing")
+	var apiErr *moltbook.APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound || apiErr.Message != "Post not found" {
+		t.Errorf("err = %v, want a 404", err)
+	}
+
+	_, err = client.CreatePost(context.Background(), &moltbook.CreatePostRequest{Submolt: "golang", Title: "Too soon", Content: "Slow down."})
+	var rateErr *moltbook.RateLimitError
+	if !errors.As(err, &rateErr) || rateErr.RetryAfter != 90*time.Second {
+		t.Errorf("err = %v, want a rate limit error with a 90s retry", err)
+	}
+	assertAllUsed(t, rec)
+}
+
+func TestReplayUnknownRequest(t *testing.T) {
+	client, _ := startClient(t, "browse")
+	_, err := client.Search(context.Background(), "anything")
+	if err == nil || !strings.Contains(err.Error(), "has no recorded response for GET /api/v1/search?q=anything") {
+		t.Errorf("err = %v, want a missing interaction error", err)
+	}
+}
+
+func TestReplayMissingCassette(t *testing.T) {
+	_, err := cassette.New(filepath.Join(t.TempDir(), "none.json"), cassette.Replay)
+	if err == nil || !strings.Contains(err.Error(), cassette.EnvRecord+"=1") {
+		t.Errorf("err = %v, want a hint to record", err)
+	}
+}
+
+// toServer sends requests for the real API to a moltbooktest server
+type toServer struct {
+	base *url.URL
+}
+
+func (s toServer) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	out.URL.Scheme, out.URL.Host = s.base.Scheme, s.base.Host
+	out.URL.Path = strings.TrimPrefix(out.URL.Path, "/api/v1")
+	out.Host = ""
+	return http.DefaultTransport.RoundTrip(out)
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	base, _ := url.Parse(srv.URL)
+	srv.AddPost(moltbook.Post{Submolt: "general", Title: "Recorded"})
+	path := filepath.Join(t.TempDir(), "cassettes", "roundtrip.json")
+
+	rec, err := cassette.New(path, cassette.Record, cassette.WithTransport(toServer{base}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := moltbook.NewClient(moltbooktest.DefaultAPIKey, rec.Option()).BrowsePosts(context.Background(), &moltbook.BrowsePostsRequest{Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err = cassette.New(path, cassette.Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := moltbook.NewClient("", rec.Option()).BrowsePosts(context.Background(), &moltbook.BrowsePostsRequest{Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replayed) != 1 || replayed[0] != recorded[0] {
+		t.Errorf("replayed %+v, recorded %+v", replayed, recorded)
+	}
+	srv.AssertRequestCount(t, "GET", "/posts", 1)
+}
+
+// secretPatterns match credentials that must never reach a cassette
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`moltbook_sk_`),
+	regexp.MustCompile(`Bearer`),
+	regexp.MustCompile(`\\?"api_key\\?"\s*:\s*\\?"[^\\"[]`),
+	regexp.MustCompile(`\\?"verification_code\\?"\s*:\s*\\?"[^\\"[]`),
+}
+
+// assertScrubbed fails the test if a cassette file holds a credential or an
+// Authorization header that is not redacted
+func assertScrubbed(t *testing.T, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pattern := range secretPatterns {
+		if loc := pattern.FindIndex(data); loc != nil {
+			t.Errorf("%s contains a secret matching %s: %q", path, pattern, data[loc[0]:min(loc[1]+20, len(data))])
+		}
+	}
+
+	rec, err := cassette.New(path, cassette.Replay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, in := range rec.Unused() {
+		for _, h := range []http.Header{in.Request.Header, in.Response.Header} {
+			if v := h.Get("Authorization"); v != "" && v != moltbook.Redacted {
+				t.Errorf("%s: %s %s has Authorization %q", path, in.Request.Method, in.Request.URL, v)
+			}
+		}
+	}
+}
+
+func TestCassettesAreScrubbed(t *testing.T) {
+	// Recording scrubs what the server sends back as well as what the
+	// client sends
+	srv := moltbooktest.NewServer(t)
+	base, _ := url.Parse(srv.URL)
+	path := filepath.Join(t.TempDir(), "secrets.json")
+	rec, err := cassette.New(path, cassette.Record, cassette.WithTransport(toServer{base}), cassette.WithSecrets("hunter2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := moltbook.Register(context.Background(), "Leaky", "password hunter2", rec.Option())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(res.APIKey, "moltbook_sk_") {
+		t.Fatalf("recording changed the live response: API key %q", res.APIKey)
+	}
+	if _, err := moltbook.NewClient(res.APIKey, rec.Option()).GetProfile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := filepath.Glob(filepath.Join("testdata", "cassettes", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no committed cassettes found")
+	}
+	for _, p := range append(paths, path) {
+		assertScrubbed(t, p)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "hunter2") {
+		t.Errorf("configured secret was recorded")
+	}
+	if !strings.Contains(string(data), `"Authorization": [`+"\n"+`            "[REDACTED]"`) {
+		t.Errorf("recorded request has no redacted Authorization header:\n%s", data)
+	}
+}
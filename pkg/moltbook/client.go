@@ -2,31 +2,155 @@ package moltbook
 
 import (
 	"bytes"
+	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/moltgo/moltgo/pkg/audit"
+	"github.com/moltgo/moltgo/pkg/timestamp"
 )
 
 const (
 	BaseURL = "https://www.moltbook.com/api/v1"
+	WebURL  = "https://www.moltbook.com"
 )
 
+// PostWebURL returns the link to a post on the Moltbook website
+func PostWebURL(postID string) string {
+	return WebURL + "/post/" + postID
+}
+
 // Client is the Moltbook API client
 type Client struct {
 	apiKey     string
+	baseURL    string
 	httpClient *http.Client
+
+	// dryRun receives a log of mutating requests instead of sending them
+	dryRun io.Writer
+
+	hold   Holder
+	filter ContentFilter
+
+	// strict receives warnings about unknown response fields
+	strict io.Writer
+
+	logger    *slog.Logger
+	debugHTTP bool
+	metrics   *clientMetrics
+
+	audit     *audit.Log
+	profile   string
+	auditWarn io.Writer
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithBaseURL points the client at a different API root, such as a local
+// test server
+func WithBaseURL(baseURL string) Option {
+	return func(c *Client) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient replaces the underlying HTTP client
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithDryRun makes the client log mutating requests (anything but GET) to w
+// instead of sending them, and answer them with synthetic responses. Read
+// requests are still sent.
+func WithDryRun(w io.Writer) Option {
+	return func(c *Client) {
+		c.dryRun = w
+	}
+}
+
+// ErrHeld is returned, wrapped, by requests a Holder kept back instead of
+// sending
+var ErrHeld = errors.New("request held")
+
+// Holder keeps outbound content back instead of sending it, for example to
+// queue it for human approval. Each method returns an error wrapping ErrHeld
+// once the request is held.
+type Holder interface {
+	HoldPost(req *CreatePostRequest) error
+	HoldComment(postID string, req *CreateCommentRequest) error
+	HoldVote(req *VoteRequest) error
+}
+
+// WithHolder passes posts, comments and votes to h instead of sending them
+func WithHolder(h Holder) Option {
+	return func(c *Client) {
+		c.hold = h
+	}
+}
+
+// ContentFilter checks outbound text before it is sent. It returns the text
+// to send, which may be redacted, or an error if the text must not be sent.
+type ContentFilter interface {
+	FilterContent(field, text string) (string, error)
 }
 
+// WithContentFilter passes the text of posts, comments and profile updates
+// through f before they are sent or held
+func WithContentFilter(f ContentFilter) Option {
+	return func(c *Client) {
+		c.filter = f
+	}
+}
+
+// filterFields runs the content filter over the named text fields,
+// replacing them with the filtered text
+func (c *Client) filterFields(fields map[string]*string) error {
+	if c.filter == nil {
+		return nil
+	}
+	for _, name := range []string{"title", "content", "url", "description"} {
+		text, ok := fields[name]
+		if !ok || *text == "" {
+			continue
+		}
+		filtered, err := c.filter.FilterContent(name, *text)
+		if err != nil {
+			return err
+		}
+		*text = filtered
+	}
+	return nil
+}
+
+// DryRunID is the ID given to objects in synthetic dry-run responses
+const DryRunID = "dry-run"
+
 // NewClient creates a new Moltbook API client
-func NewClient(apiKey string) *Client {
-	return &Client{
-		apiKey: apiKey,
+func NewClient(apiKey string, opts ...Option) *Client {
+	c := &Client{
+		apiKey:  apiKey,
+		baseURL: BaseURL,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		logger: discardLogger,
 	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
 }
 
 // RegisterRequest represents an agent registration request
@@ -37,12 +161,12 @@ type RegisterRequest struct {
 
 // RegisterResponse represents the response from registration
 type RegisterResponse struct {
-	Success          bool   `json:"success"`
-	Error            string `json:"error,omitempty"`
-	Hint             string `json:"hint,omitempty"`
-	Message          string `json:"message,omitempty"`
-	Agent            *AgentRegistration `json:"agent,omitempty"`
-	TweetTemplate    string `json:"tweet_template,omitempty"`
+	Success       bool               `json:"success"`
+	Error         string             `json:"error,omitempty"`
+	Hint          string             `json:"hint,omitempty"`
+	Message       string             `json:"message,omitempty"`
+	Agent         *AgentRegistration `json:"agent,omitempty"`
+	TweetTemplate string             `json:"tweet_template,omitempty"`
 	// Legacy flat fields (for backward compatibility)
 	APIKey           string `json:"api_key,omitempty"`
 	AgentID          string `json:"agent_id,omitempty"`
@@ -50,136 +174,131 @@ type RegisterResponse struct {
 	VerificationCode string `json:"verification_code,omitempty"`
 }
 
+// UnmarshalJSON reads both the response with the agent nested under
+// "agent" and the legacy one with only the flat fields, and fills in
+// whichever shape the response lacks, so callers can use either
+func (r *RegisterResponse) UnmarshalJSON(data []byte) error {
+	type plain RegisterResponse
+	if err := json.Unmarshal(data, (*plain)(r)); err != nil {
+		return err
+	}
+
+	if a := r.Agent; a != nil {
+		r.APIKey = cmp.Or(r.APIKey, a.APIKey)
+		r.AgentID = cmp.Or(r.AgentID, a.ID)
+		r.ClaimURL = cmp.Or(r.ClaimURL, a.ClaimURL)
+		r.VerificationCode = cmp.Or(r.VerificationCode, a.VerificationCode)
+	} else if r.AgentID != "" || r.APIKey != "" {
+		r.Agent = &AgentRegistration{
+			ID:               r.AgentID,
+			APIKey:           r.APIKey,
+			ClaimURL:         r.ClaimURL,
+			VerificationCode: r.VerificationCode,
+		}
+	}
+	return nil
+}
+
 // AgentRegistration represents the agent data in registration response
 type AgentRegistration struct {
-	ID               string `json:"id"`
-	Name             string `json:"name"`
-	APIKey           string `json:"api_key"`
-	ClaimURL         string `json:"claim_url"`
-	VerificationCode string `json:"verification_code"`
-	ProfileURL       string `json:"profile_url,omitempty"`
-	CreatedAt        string `json:"created_at,omitempty"`
+	ID               string         `json:"id"`
+	Name             string         `json:"name"`
+	APIKey           string         `json:"api_key"`
+	ClaimURL         string         `json:"claim_url"`
+	VerificationCode string         `json:"verification_code"`
+	ProfileURL       string         `json:"profile_url,omitempty"`
+	CreatedAt        timestamp.Time `json:"created_at,omitempty"`
 }
 
 // Post represents a Moltbook post
 type Post struct {
-	ID          string `json:"id"`
-	Submolt     string `json:"submolt"`
-	Title       string `json:"title"`
-	Content     string `json:"content,omitempty"`
-	URL         string `json:"url,omitempty"`
-	Author      string `json:"author"`
-	Score       int    `json:"score"`
-	NumComments int    `json:"num_comments"`
-	CreatedAt   string `json:"created_at"`
+	ID          string         `json:"id"`
+	Submolt     string         `json:"submolt"`
+	Title       string         `json:"title"`
+	Content     string         `json:"content,omitempty"`
+	URL         string         `json:"url,omitempty"`
+	Author      string         `json:"author"`
+	Score       int            `json:"score"`
+	NumComments int            `json:"num_comments"`
+	CreatedAt   timestamp.Time `json:"created_at"`
 }
 
 // Comment represents a comment on a post
 type Comment struct {
-	ID        string `json:"id"`
-	PostID    string `json:"post_id"`
-	Content   string `json:"content"`
-	Author    string `json:"author"`
-	Score     int    `json:"score"`
-	CreatedAt string `json:"created_at"`
+	ID        string         `json:"id"`
+	PostID    string         `json:"post_id"`
+	ParentID  string         `json:"parent_id,omitempty"`
+	Content   string         `json:"content"`
+	Author    string         `json:"author"`
+	Score     int            `json:"score"`
+	CreatedAt timestamp.Time `json:"created_at"`
+	Replies   []Comment      `json:"replies,omitempty"`
 }
 
 // Agent represents an agent profile
 type Agent struct {
-	ID          string `json:"id"`
-	Name        string `json:"name"`
-	Description string `json:"description"`
-	AvatarURL   string `json:"avatar_url,omitempty"`
-	CreatedAt   string `json:"created_at"`
+	ID          string         `json:"id"`
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	AvatarURL   string         `json:"avatar_url,omitempty"`
+	CreatedAt   timestamp.Time `json:"created_at"`
 }
 
-// Register registers a new agent with Moltbook
-func Register(name, description string) (*RegisterResponse, error) {
-	client := &http.Client{Timeout: 30 * time.Second}
+// Register registers a new agent with Moltbook. Options configure the
+// unauthenticated client used for the request.
+func Register(ctx context.Context, name, description string, opts ...Option) (*RegisterResponse, error) {
+	return NewClient("", opts...).Register(ctx, name, description)
+}
 
+// Register registers a new agent with Moltbook; it needs no API key
+func (c *Client) Register(ctx context.Context, name, description string) (*RegisterResponse, error) {
 	reqData := RegisterRequest{
 		Name:        name,
 		Description: description,
 	}
 
-	jsonData, err := json.Marshal(reqData)
+	data, err := c.doRequest(ctx, "POST", "/agents/register", reqData)
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", BaseURL+"/agents/register", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+	if c.dryRun != nil {
+		return &RegisterResponse{Success: true, AgentID: DryRunID, APIKey: "moltbook_sk_" + DryRunID}, nil
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	result, err := decode[RegisterResponse](c, "POST /agents/register", data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response: %w", err)
-	}
-
-	var result RegisterResponse
-	if err := json.Unmarshal(body, &result); err != nil {
-		// If we can't parse the response, return the raw body
-		if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-			return nil, fmt.Errorf("registration failed (status %d): %s", resp.StatusCode, string(body))
-		}
 		return nil, fmt.Errorf("failed to parse response: %w", err)
 	}
-
-	// Check for error in response body
-	if !result.Success && result.Error != "" {
-		errMsg := result.Error
-		if result.Hint != "" {
-			errMsg += " - " + result.Hint
-		}
-		return nil, fmt.Errorf("registration failed: %s", errMsg)
-	}
-
-	// Check status code
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("registration failed (status %d): %s", resp.StatusCode, string(body))
-	}
-
-	// Normalize response - if agent field exists, copy values to top level for easier access
-	if result.Agent != nil {
-		if result.APIKey == "" {
-			result.APIKey = result.Agent.APIKey
-		}
-		if result.AgentID == "" {
-			result.AgentID = result.Agent.ID
-		}
-		if result.ClaimURL == "" {
-			result.ClaimURL = result.Agent.ClaimURL
-		}
-		if result.VerificationCode == "" {
-			result.VerificationCode = result.Agent.VerificationCode
-		}
-	}
-
-	return &result, nil
+	return result, nil
 }
 
 // doRequest performs an authenticated API request
-func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, error) {
+func (c *Client) doRequest(ctx context.Context, method, endpoint string, body interface{}) (data []byte, err error) {
+	ctx, span := c.startRequestSpan(ctx, method, endpoint)
+	defer func() {
+		span.Fail(err)
+		span.End()
+	}()
+
+	var jsonData []byte
 	var reqBody io.Reader
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		var err error
+		jsonData, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request: %w", err)
 		}
 		reqBody = bytes.NewBuffer(jsonData)
 	}
 
-	url := BaseURL + endpoint
-	req, err := http.NewRequest(method, url, reqBody)
+	// Requests a dry run does not send only go to the dry-run log. The
+	// request log, audit log and metrics record requests that were made,
+	// so reads during a dry run are recorded as usual.
+	if c.dryRun != nil && method != "GET" {
+		return c.dryRunResponse(method, endpoint, jsonData)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -189,52 +308,83 @@ func (c *Client) doRequest(method, endpoint string, body interface{}) ([]byte, e
 		req.Header.Set("Authorization", "Bearer "+c.apiKey)
 	}
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		c.observeRequest(req, jsonData, nil, nil, time.Since(start), err)
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	respBody, err := io.ReadAll(resp.Body)
+	c.observeRequest(req, jsonData, resp, respBody, time.Since(start), err)
+	span.SetAttributes(slog.Int("http.response.status_code", resp.StatusCode))
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	if resp.StatusCode == http.StatusTooManyRequests {
-		retryAfter := resp.Header.Get("Retry-After")
-		return nil, fmt.Errorf("rate limited (retry after %s seconds)", retryAfter)
+		return nil, newRateLimitError(resp.Header.Get("Retry-After"))
 	}
 
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(respBody))
+	if err := checkEnvelope(resp.StatusCode, respBody); err != nil {
+		return nil, err
 	}
 
 	return respBody, nil
 }
 
-// GetProfileResponse represents the response from getting agent profile
-type GetProfileResponse struct {
-	Success bool  `json:"success"`
-	Agent   Agent `json:"agent"`
+// dryRunResponse logs a request that is not sent and builds a synthetic
+// response for it: the request body echoed back with a dry-run ID, which
+// decodes into the object the request would have created or updated
+func (c *Client) dryRunResponse(method, endpoint string, body []byte) ([]byte, error) {
+	fmt.Fprintf(c.dryRun, "[dry-run] %s %s\n", method, c.baseURL+endpoint)
+
+	fields := map[string]interface{}{}
+	if len(body) > 0 {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, body, "          ", "  "); err == nil {
+			fmt.Fprintf(c.dryRun, "          %s\n", pretty.String())
+		}
+		// Bodies that are not objects are logged but not echoed
+		_ = json.Unmarshal(body, &fields)
+	}
+
+	fields["id"] = DryRunID
+	fields["success"] = true
+	fields["created_at"] = time.Now().UTC().Format(time.RFC3339)
+	return json.Marshal(fields)
+}
+
+// RateLimitError is returned when the API rejects a request for exceeding
+// its rate limit
+type RateLimitError struct {
+	// RetryAfter is how long the API asked us to wait, or 0 if it did not say
+	RetryAfter time.Duration
+}
+
+func newRateLimitError(retryAfter string) *RateLimitError {
+	seconds, _ := strconv.Atoi(strings.TrimSpace(retryAfter))
+	return &RateLimitError{RetryAfter: time.Duration(seconds) * time.Second}
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited (retry after %d seconds)", int(e.RetryAfter.Seconds()))
 }
 
 // GetProfile gets the authenticated agent's profile
-func (c *Client) GetProfile() (*Agent, error) {
-	data, err := c.doRequest("GET", "/agents/me", nil)
+func (c *Client) GetProfile(ctx context.Context) (*Agent, error) {
+	data, err := c.doRequest(ctx, "GET", "/agents/me", nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var response GetProfileResponse
-	if err := json.Unmarshal(data, &response); err != nil {
+	agent, err := decode[Agent](c, "GET /agents/me", data, "agent")
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse profile: %w", err)
 	}
 
-	if !response.Success {
-		return nil, fmt.Errorf("API returned success=false")
-	}
-
-	return &response.Agent, nil
+	return agent, nil
 }
 
 // UpdateProfileRequest represents a request to update agent profile
@@ -243,49 +393,144 @@ type UpdateProfileRequest struct {
 }
 
 // UpdateProfile updates the authenticated agent's profile
-func (c *Client) UpdateProfile(req *UpdateProfileRequest) (*Agent, error) {
-	data, err := c.doRequest("PATCH", "/agents/me", req)
+func (c *Client) UpdateProfile(ctx context.Context, req *UpdateProfileRequest) (*Agent, error) {
+	filtered := *req
+	if err := c.filterFields(map[string]*string{"description": &filtered.Description}); err != nil {
+		return nil, err
+	}
+	req = &filtered
+
+	data, err := c.doRequest(ctx, "PATCH", "/agents/me", req)
 	if err != nil {
 		return nil, err
 	}
 
-	var agent Agent
-	if err := json.Unmarshal(data, &agent); err != nil {
+	agent, err := decode[Agent](c, "PATCH /agents/me", data, "agent")
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse profile: %w", err)
 	}
 
-	return &agent, nil
+	return agent, nil
 }
 
+// Sort orders accepted by the posts and comments endpoints
+const (
+	SortHot    = "hot"
+	SortNew    = "new"
+	SortTop    = "top"
+	SortRising = "rising"
+)
+
 // BrowsePostsRequest contains parameters for browsing posts
 type BrowsePostsRequest struct {
 	Submolt string
+	Sort    string
 	Limit   int
-}
-
-// BrowsePostsResponse represents the response from browsing posts
-type BrowsePostsResponse struct {
-	Posts []Post `json:"posts"`
+	Offset  int
 }
 
 // BrowsePosts retrieves recent posts
-func (c *Client) BrowsePosts(req *BrowsePostsRequest) ([]Post, error) {
-	endpoint := fmt.Sprintf("/posts?limit=%d", req.Limit)
+func (c *Client) BrowsePosts(ctx context.Context, req *BrowsePostsRequest) ([]Post, error) {
+	query := url.Values{}
+	query.Set("limit", fmt.Sprintf("%d", req.Limit))
 	if req.Submolt != "" {
-		endpoint += "&submolt=" + req.Submolt
+		query.Set("submolt", req.Submolt)
+	}
+	if req.Sort != "" {
+		query.Set("sort", req.Sort)
+	}
+	if req.Offset > 0 {
+		query.Set("offset", strconv.Itoa(req.Offset))
 	}
 
-	data, err := c.doRequest("GET", endpoint, nil)
+	data, err := c.doRequest(ctx, "GET", "/posts?"+query.Encode(), nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var response BrowsePostsResponse
-	if err := json.Unmarshal(data, &response); err != nil {
+	posts, err := decode[[]Post](c, "GET /posts", data, "posts")
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse posts: %w", err)
 	}
 
-	return response.Posts, nil
+	return *posts, nil
+}
+
+// GetPost retrieves a single post by ID
+func (c *Client) GetPost(ctx context.Context, postID string) (*Post, error) {
+	data, err := c.doRequest(ctx, "GET", "/posts/"+url.PathEscape(postID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := decode[Post](c, "GET /posts/{id}", data, "post")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse post: %w", err)
+	}
+
+	return post, nil
+}
+
+// GetComments retrieves the comment thread for a post. Replies are nested
+// under their parent comment.
+func (c *Client) GetComments(ctx context.Context, postID, sort string) ([]Comment, error) {
+	endpoint := "/posts/" + url.PathEscape(postID) + "/comments"
+	if sort != "" {
+		endpoint += "?sort=" + url.QueryEscape(sort)
+	}
+
+	data, err := c.doRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := decode[[]Comment](c, "GET /posts/{id}/comments", data, "comments")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse comments: %w", err)
+	}
+
+	return NestComments(*comments), nil
+}
+
+// NestComments arranges a flat comment list into a thread using ParentID.
+// Comments that already carry nested replies are returned unchanged.
+func NestComments(comments []Comment) []Comment {
+	flat := true
+	for _, c := range comments {
+		if len(c.Replies) > 0 {
+			flat = false
+			break
+		}
+	}
+	if !flat {
+		return comments
+	}
+
+	children := make(map[string][]Comment)
+	known := make(map[string]bool, len(comments))
+	for _, c := range comments {
+		known[c.ID] = true
+	}
+	var roots []Comment
+	for _, c := range comments {
+		if c.ParentID != "" && known[c.ParentID] {
+			children[c.ParentID] = append(children[c.ParentID], c)
+		} else {
+			roots = append(roots, c)
+		}
+	}
+
+	var attach func(c Comment) Comment
+	attach = func(c Comment) Comment {
+		for _, child := range children[c.ID] {
+			c.Replies = append(c.Replies, attach(child))
+		}
+		return c
+	}
+	for i := range roots {
+		roots[i] = attach(roots[i])
+	}
+	return roots
 }
 
 // CreatePostRequest represents a request to create a post
@@ -297,41 +542,73 @@ type CreatePostRequest struct {
 }
 
 // CreatePost creates a new post
-func (c *Client) CreatePost(req *CreatePostRequest) (*Post, error) {
-	data, err := c.doRequest("POST", "/posts", req)
+func (c *Client) CreatePost(ctx context.Context, req *CreatePostRequest) (*Post, error) {
+	filtered := *req
+	if err := c.filterFields(map[string]*string{
+		"title":   &filtered.Title,
+		"content": &filtered.Content,
+		"url":     &filtered.URL,
+	}); err != nil {
+		return nil, err
+	}
+	req = &filtered
+
+	if c.hold != nil {
+		return nil, c.hold.HoldPost(req)
+	}
+
+	data, err := c.doRequest(ctx, "POST", "/posts", req)
 	if err != nil {
 		return nil, err
 	}
+	c.countCreated(func(m *clientMetrics) { m.posts.Inc() })
 
-	var post Post
-	if err := json.Unmarshal(data, &post); err != nil {
+	post, err := decode[Post](c, "POST /posts", data, "post")
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse post: %w", err)
 	}
 
-	return &post, nil
+	return post, nil
 }
 
 // CreateCommentRequest represents a request to create a comment
 type CreateCommentRequest struct {
-	Content string `json:"content"`
+	Content  string `json:"content"`
+	ParentID string `json:"parent_id,omitempty"`
 }
 
 // CreateComment creates a comment on a post
-func (c *Client) CreateComment(postID string, content string) (*Comment, error) {
-	req := CreateCommentRequest{Content: content}
-	endpoint := fmt.Sprintf("/posts/%s/comments", postID)
+func (c *Client) CreateComment(ctx context.Context, postID string, content string) (*Comment, error) {
+	return c.CreateReply(ctx, postID, "", content)
+}
+
+// CreateReply creates a comment on a post in reply to another comment. An
+// empty parentID creates a top-level comment.
+func (c *Client) CreateReply(ctx context.Context, postID, parentID, content string) (*Comment, error) {
+	req := CreateCommentRequest{Content: content, ParentID: parentID}
+	if err := c.filterFields(map[string]*string{"content": &req.Content}); err != nil {
+		return nil, err
+	}
+	if c.hold != nil {
+		return nil, c.hold.HoldComment(postID, &req)
+	}
+	endpoint := fmt.Sprintf("/posts/%s/comments", url.PathEscape(postID))
 
-	data, err := c.doRequest("POST", endpoint, req)
+	data, err := c.doRequest(ctx, "POST", endpoint, req)
 	if err != nil {
 		return nil, err
 	}
+	c.countCreated(func(m *clientMetrics) { m.comments.Inc() })
 
-	var comment Comment
-	if err := json.Unmarshal(data, &comment); err != nil {
+	comment, err := decode[Comment](c, "POST /posts/{id}/comments", data, "comment")
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse comment: %w", err)
 	}
+	if comment.PostID == "" {
+		comment.PostID = postID
+	}
 
-	return &comment, nil
+	return comment, nil
 }
 
 // VoteRequest represents a vote request
@@ -342,35 +619,36 @@ type VoteRequest struct {
 }
 
 // Vote votes on a post or comment
-func (c *Client) Vote(targetType, targetID, direction string) error {
+func (c *Client) Vote(ctx context.Context, targetType, targetID, direction string) error {
 	req := VoteRequest{
 		TargetType: targetType,
 		TargetID:   targetID,
 		Direction:  direction,
 	}
+	if c.hold != nil {
+		return c.hold.HoldVote(&req)
+	}
 
-	_, err := c.doRequest("POST", "/vote", req)
-	return err
-}
-
-// SearchResponse represents search results
-type SearchResponse struct {
-	Results []Post `json:"results"`
+	if _, err := c.doRequest(ctx, "POST", "/vote", req); err != nil {
+		return err
+	}
+	c.countCreated(func(m *clientMetrics) { m.votes.Inc(direction) })
+	return nil
 }
 
 // Search performs semantic search for posts
-func (c *Client) Search(query string) ([]Post, error) {
-	endpoint := fmt.Sprintf("/search?q=%s", query)
+func (c *Client) Search(ctx context.Context, query string) ([]Post, error) {
+	endpoint := "/search?q=" + url.QueryEscape(query)
 
-	data, err := c.doRequest("GET", endpoint, nil)
+	data, err := c.doRequest(ctx, "GET", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	var response SearchResponse
-	if err := json.Unmarshal(data, &response); err != nil {
+	results, err := decode[[]Post](c, "GET /search", data, "results", "posts")
+	if err != nil {
 		return nil, fmt.Errorf("failed to parse search results: %w", err)
 	}
 
-	return response.Results, nil
+	return *results, nil
 }
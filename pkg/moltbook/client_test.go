@@ -0,0 +1,309 @@
+package moltbook_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/audit"
+	"github.com/moltgo/moltgo/pkg/metrics"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/moltbook/moltbooktest"
+)
+
+// noLimits turns off the server's content rate limits so a test can post
+// and comment freely
+var noLimits = moltbooktest.WithLimits(moltbooktest.Limits{})
+
+func TestBrowsePostsQuery(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	_, err := srv.Client().BrowsePosts(context.Background(), &moltbook.BrowsePostsRequest{Submolt: "general", Sort: moltbook.SortNew, Limit: 10, Offset: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := srv.Requests()[0].Query
+	for key, want := range map[string]string{"submolt": "general", "sort": "new", "limit": "10", "offset": "20"} {
+		if got := q.Get(key); got != want {
+			t.Errorf("%s = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestGetCommentsNestsReplies(t *testing.T) {
+	srv := moltbooktest.NewServer(t, noLimits)
+	client := srv.Client()
+	post, err := client.CreatePost(context.Background(), &moltbook.CreatePostRequest{Submolt: "general", Title: "Thread", Content: "Body"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	top, err := client.CreateComment(context.Background(), post.ID, "top")
+	if err != nil {
+		t.Fatal(err)
+	}
+	reply, err := client.CreateReply(context.Background(), post.ID, top.ID, "reply")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateReply(context.Background(), post.ID, reply.ID, "deeper"); err != nil {
+		t.Fatal(err)
+	}
+
+	comments, err := client.GetComments(context.Background(), post.ID, moltbook.SortNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 1 || len(comments[0].Replies) != 1 || len(comments[0].Replies[0].Replies) != 1 {
+		t.Fatalf("comments = %+v, want a thread three deep", comments)
+	}
+	if got := comments[0].Replies[0].Replies[0].Content; got != "deeper" {
+		t.Errorf("deepest reply = %q", got)
+	}
+	if sort := srv.Requests()[len(srv.Requests())-1].Query.Get("sort"); sort != "new" {
+		t.Errorf("sort = %q, want new", sort)
+	}
+}
+
+func TestCreateReplyFillsPostID(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	post := srv.AddPost(moltbook.Post{Submolt: "general", Title: "Seeded"})
+
+	comment, err := srv.Client().CreateComment(context.Background(), post.ID, "Nice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if comment.PostID != post.ID {
+		t.Errorf("post ID = %q, want %q", comment.PostID, post.ID)
+	}
+}
+
+func TestUpdateProfile(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	client := srv.Client()
+
+	agent, err := client.UpdateProfile(context.Background(), &moltbook.UpdateProfileRequest{Description: "Updated"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if agent.Description != "Updated" {
+		t.Errorf("description = %q", agent.Description)
+	}
+	srv.AssertRequested(t, "PATCH", "/agents/me")
+}
+
+func TestAPIErrors(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	client := srv.Client()
+
+	_, err := client.CreatePost(context.Background(), &moltbook.CreatePostRequest{Submolt: "general"})
+	var apiErr *moltbook.APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusBadRequest || apiErr.Message != "Title is required" {
+		t.Errorf("err = %v, want a 400 about the title", err)
+	}
+
+	_, err = client.GetPost(context.Background(), "missing")
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusNotFound {
+		t.Errorf("err = %v, want a 404", err)
+	}
+}
+
+func TestRateLimitError(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	client := srv.Client()
+	if _, err := client.CreatePost(context.Background(), &moltbook.CreatePostRequest{Submolt: "general", Title: "One", Content: "x"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := client.CreatePost(context.Background(), &moltbook.CreatePostRequest{Submolt: "general", Title: "Two", Content: "x"})
+	var rateErr *moltbook.RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("err = %v, want a rate limit error", err)
+	}
+	if rateErr.RetryAfter <= 29*time.Minute || rateErr.RetryAfter > 30*time.Minute {
+		t.Errorf("retry after %s, want about 30m", rateErr.RetryAfter)
+	}
+}
+
+func TestDryRunSendsOnlyReads(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	var log bytes.Buffer
+	client := srv.Client(moltbook.WithDryRun(&log))
+
+	post, err := client.CreatePost(context.Background(), &moltbook.CreatePostRequest{Submolt: "general", Title: "Draft", Content: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.ID != moltbook.DryRunID || post.Title != "Draft" {
+		t.Errorf("post = %+v, want the request echoed with the dry-run ID", post)
+	}
+	if err := client.Vote(context.Background(), "post", "post_1", "up"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetProfile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	srv.AssertNotRequested(t, "POST", "/posts")
+	srv.AssertNotRequested(t, "POST", "/vote")
+	srv.AssertRequested(t, "GET", "/agents/me")
+	if !strings.Contains(log.String(), "[dry-run] POST "+srv.URL+"/posts") {
+		t.Errorf("dry-run log = %q", log.String())
+	}
+}
+
+func TestDryRunMetricsCountOnlySentRequests(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	reg := metrics.NewRegistry()
+	client := srv.Client(moltbook.WithDryRun(io.Discard), moltbook.WithMetrics(reg))
+
+	if _, err := client.CreatePost(context.Background(), &moltbook.CreatePostRequest{Submolt: "general", Title: "Draft", Content: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Vote(context.Background(), "post", "post_1", "up"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetProfile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	if err := reg.WriteText(&b); err != nil {
+		t.Fatal(err)
+	}
+	text := b.String()
+	if !strings.Contains(text, `moltgo_api_requests_total{method="GET",endpoint="/agents/me",status="200"} 1`) {
+		t.Errorf("the read is not counted:\n%s", text)
+	}
+	for _, unwanted := range []string{`method="POST"`, "moltgo_posts_created_total 1", `direction="up"`} {
+		if strings.Contains(text, unwanted) {
+			t.Errorf("metrics count a request that was not sent (%s):\n%s", unwanted, text)
+		}
+	}
+}
+
+// holder records held requests
+type holder struct {
+	posts, comments, votes int
+}
+
+func (h *holder) HoldPost(*moltbook.CreatePostRequest) error { h.posts++; return moltbook.ErrHeld }
+
+func (h *holder) HoldComment(string, *moltbook.CreateCommentRequest) error {
+	h.comments++
+	return moltbook.ErrHeld
+}
+
+func (h *holder) HoldVote(*moltbook.VoteRequest) error { h.votes++; return moltbook.ErrHeld }
+
+func TestHolderKeepsRequestsBack(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	h := &holder{}
+	client := srv.Client(moltbook.WithHolder(h))
+
+	if _, err := client.CreatePost(context.Background(), &moltbook.CreatePostRequest{Submolt: "general", Title: "Held", Content: "x"}); !errors.Is(err, moltbook.ErrHeld) {
+		t.Errorf("post: err = %v, want ErrHeld", err)
+	}
+	if _, err := client.CreateComment(context.Background(), "post_1", "Held"); !errors.Is(err, moltbook.ErrHeld) {
+		t.Errorf("comment: err = %v, want ErrHeld", err)
+	}
+	if err := client.Vote(context.Background(), "post", "post_1", "up"); !errors.Is(err, moltbook.ErrHeld) {
+		t.Errorf("vote: err = %v, want ErrHeld", err)
+	}
+	if h.posts != 1 || h.comments != 1 || h.votes != 1 {
+		t.Errorf("held %+v, want one of each", *h)
+	}
+	if n := len(srv.Requests()); n != 0 {
+		t.Errorf("%d requests sent, want none", n)
+	}
+}
+
+// upperFilter uppercases text and rejects anything containing "secret"
+type upperFilter struct{}
+
+func (upperFilter) FilterContent(field, text string) (string, error) {
+	if strings.Contains(text, "secret") {
+		return "", errors.New("blocked " + field)
+	}
+	return strings.ToUpper(text), nil
+}
+
+func TestContentFilter(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	client := srv.Client(moltbook.WithContentFilter(upperFilter{}))
+
+	req := &moltbook.CreatePostRequest{Submolt: "general", Title: "quiet", Content: "hello"}
+	if _, err := client.CreatePost(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	srv.AssertPosted(t, "QUIET")
+	if req.Title != "quiet" {
+		t.Errorf("filter changed the caller's request")
+	}
+
+	if _, err := client.CreateComment(context.Background(), "post_2", "a secret"); err == nil || err.Error() != "blocked content" {
+		t.Errorf("err = %v, want the filter's error", err)
+	}
+	srv.AssertNotRequested(t, "POST", "/posts/")
+}
+
+func TestAuditRecordsSentRequests(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	log, err := audit.Open(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var warnings bytes.Buffer
+	client := srv.Client(moltbook.WithAudit(log, "TestAgent", &warnings))
+
+	post, err := client.CreatePost(context.Background(), &moltbook.CreatePostRequest{Submolt: "general", Title: "Audited", Content: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetPost(context.Background(), post.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := audit.Read(log.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Action != "post" || entries[0].ResponseID != post.ID || entries[0].Profile != "TestAgent" {
+		t.Errorf("entries = %+v, want the post alone", entries)
+	}
+	if warnings.Len() != 0 {
+		t.Errorf("warnings = %q", warnings.String())
+	}
+}
+
+func TestAuditFailureIsReported(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	log, err := audit.Open(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The log becomes unwritable after it was opened
+	if err := os.Remove(log.Path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(log.Path, 0700); err != nil {
+		t.Fatal(err)
+	}
+	post := srv.AddPost(moltbook.Post{Submolt: "general", Title: "Voted on"})
+	var warnings bytes.Buffer
+	client := srv.Client(moltbook.WithAudit(log, "TestAgent", &warnings))
+
+	// The request was made, so it succeeds, but the failure is not silent
+	if err := client.Vote(context.Background(), "post", post.ID, "up"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(warnings.String(), "Warning: POST /vote was sent but not recorded in the audit log: ") {
+		t.Errorf("warnings = %q", warnings.String())
+	}
+}
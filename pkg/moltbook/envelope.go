@@ -0,0 +1,222 @@
+package moltbook
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// APIError is an error the API reported, either with an error status or
+// with "success": false in the response body
+type APIError struct {
+	Status  int
+	Message string
+	Hint    string
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if e.Hint != "" {
+		msg += " - " + e.Hint
+	}
+	return fmt.Sprintf("API error (status %d): %s", e.Status, msg)
+}
+
+// WithStrictDecoding makes the client write a warning to w whenever a
+// response has fields the client does not know, to catch API changes early
+func WithStrictDecoding(w io.Writer) Option {
+	return func(c *Client) {
+		c.strict = w
+	}
+}
+
+// envelopeFields are the fields of a response envelope that are never part
+// of the payload
+var envelopeFields = map[string]bool{"success": true, "error": true, "hint": true, "message": true}
+
+// errorEnvelope is the shape of an API error
+type errorEnvelope struct {
+	Success *bool  `json:"success"`
+	Error   string `json:"error"`
+	Hint    string `json:"hint"`
+	Message string `json:"message"`
+}
+
+// checkEnvelope returns an *APIError for an error status or a body with
+// "success": false, and nil otherwise
+func checkEnvelope(status int, body []byte) error {
+	ok := status >= 200 && status < 300
+
+	var env errorEnvelope
+	if err := json.Unmarshal(body, &env); err != nil {
+		if ok {
+			return nil
+		}
+		return &APIError{Status: status, Message: strings.TrimSpace(string(body))}
+	}
+	if ok && (env.Success == nil || *env.Success) {
+		return nil
+	}
+
+	msg := env.Error
+	if msg == "" {
+		msg = env.Message
+	}
+	if msg == "" && ok {
+		msg = "request was not successful"
+	}
+	if msg == "" {
+		msg = strings.TrimSpace(string(body))
+	}
+	return &APIError{Status: status, Message: msg, Hint: env.Hint}
+}
+
+// decode parses a response payload into a T. Responses come either wrapped
+// in an envelope, with the payload under one of keys or under "data", or as
+// the bare payload. what names the endpoint in strict-mode warnings.
+func decode[T any](c *Client, what string, body []byte, keys ...string) (*T, error) {
+	payload, extra := unwrap(body, keys)
+
+	var v T
+	if err := json.Unmarshal(payload, &v); err != nil {
+		return nil, err
+	}
+
+	if c.strict != nil {
+		unknown := append(extra, unknownFields(payload, reflect.TypeOf(v), "", len(extra) == 0 && !wrapped(body, keys))...)
+		if len(unknown) > 0 {
+			fmt.Fprintf(c.strict, "[strict] %s: unknown fields: %s\n", what, strings.Join(unknown, ", "))
+		}
+	}
+	return &v, nil
+}
+
+// unwrap returns the payload of a response and the envelope fields that
+// are neither payload nor envelopeFields
+func unwrap(body []byte, keys []string) (json.RawMessage, []string) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return body, nil
+	}
+
+	for _, key := range append(keys, "data") {
+		raw, ok := fields[key]
+		if !ok || string(raw) == "null" {
+			continue
+		}
+
+		var extra []string
+		for name := range fields {
+			if name != key && !envelopeFields[name] {
+				extra = append(extra, name)
+			}
+		}
+		sort.Strings(extra)
+
+		if key == "data" {
+			// data may itself hold the keyed payload
+			inner, innerExtra := unwrap(raw, keys)
+			return inner, append(extra, prefixed("data.", innerExtra)...)
+		}
+		return raw, extra
+	}
+	return body, nil
+}
+
+// wrapped reports whether the body is an envelope rather than a bare payload
+func wrapped(body []byte, keys []string) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return false
+	}
+	for _, key := range append(keys, "data") {
+		if raw, ok := fields[key]; ok && string(raw) != "null" {
+			return true
+		}
+	}
+	return false
+}
+
+// unknownFields lists the object fields in raw that t has no JSON field
+// for, as dotted paths. At the root of a bare payload the envelope fields
+// are expected and not reported.
+func unknownFields(raw json.RawMessage, t reflect.Type, path string, bareRoot bool) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		var items []json.RawMessage
+		if err := json.Unmarshal(raw, &items); err != nil {
+			return nil
+		}
+		seen := map[string]bool{}
+		var unknown []string
+		for _, item := range items {
+			for _, name := range unknownFields(item, t.Elem(), path+"[].", false) {
+				if !seen[name] {
+					seen[name] = true
+					unknown = append(unknown, name)
+				}
+			}
+		}
+		return unknown
+
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			return nil
+		}
+		known := jsonFields(t)
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var unknown []string
+		for _, name := range names {
+			field, ok := known[name]
+			switch {
+			case ok:
+				unknown = append(unknown, unknownFields(fields[name], field, path+name+".", false)...)
+			case !(bareRoot && envelopeFields[name]):
+				unknown = append(unknown, path+name)
+			}
+		}
+		return unknown
+	}
+	return nil
+}
+
+// jsonFields maps the JSON names of a struct's fields to their types
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func prefixed(prefix string, names []string) []string {
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = prefix + name
+	}
+	return out
+}
@@ -0,0 +1,304 @@
+package moltbook_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/moltbook/moltbooktest"
+)
+
+// endpoint describes how one client method reads its response
+type endpoint struct {
+	name   string
+	method string
+	path   string
+
+	// key is the envelope key the payload comes under, besides "data"
+	key     string
+	payload string
+
+	// id is the ID the payload decodes to
+	id string
+
+	// call makes the request and returns the ID of what it decoded
+	call func(c *moltbook.Client) (string, error)
+}
+
+var endpoints = []endpoint{
+	{
+		name: "GetProfile", method: "GET", path: "/agents/me", key: "agent",
+		payload: `{"id": "agent_1", "name": "Contract"}`,
+		id:      "agent_1",
+		call: func(c *moltbook.Client) (string, error) {
+			a, err := c.GetProfile(context.Background())
+			if err != nil {
+				return "", err
+			}
+			return a.ID, nil
+		},
+	},
+	{
+		name: "UpdateProfile", method: "PATCH", path: "/agents/me", key: "agent",
+		payload: `{"id": "agent_1", "name": "Contract", "description": "New"}`,
+		id:      "agent_1",
+		call: func(c *moltbook.Client) (string, error) {
+			a, err := c.UpdateProfile(context.Background(), &moltbook.UpdateProfileRequest{Description: "New"})
+			if err != nil {
+				return "", err
+			}
+			return a.ID, nil
+		},
+	},
+	{
+		name: "BrowsePosts", method: "GET", path: "/posts", key: "posts",
+		payload: `[{"id": "post_1", "title": "Listed"}]`,
+		id:      "post_1",
+		call: func(c *moltbook.Client) (string, error) {
+			posts, err := c.BrowsePosts(context.Background(), &moltbook.BrowsePostsRequest{Limit: 1})
+			if err != nil || len(posts) == 0 {
+				return "", err
+			}
+			return posts[0].ID, nil
+		},
+	},
+	{
+		name: "GetPost", method: "GET", path: "/posts/post_1", key: "post",
+		payload: `{"id": "post_1", "title": "Single"}`,
+		id:      "post_1",
+		call: func(c *moltbook.Client) (string, error) {
+			p, err := c.GetPost(context.Background(), "post_1")
+			if err != nil {
+				return "", err
+			}
+			return p.ID, nil
+		},
+	},
+	{
+		name: "GetComments", method: "GET", path: "/posts/post_1/comments", key: "comments",
+		payload: `[{"id": "comment_1", "post_id": "post_1", "content": "Hi"}]`,
+		id:      "comment_1",
+		call: func(c *moltbook.Client) (string, error) {
+			comments, err := c.GetComments(context.Background(), "post_1", "")
+			if err != nil || len(comments) == 0 {
+				return "", err
+			}
+			return comments[0].ID, nil
+		},
+	},
+	{
+		name: "CreatePost", method: "POST", path: "/posts", key: "post",
+		payload: `{"id": "post_2", "title": "Created"}`,
+		id:      "post_2",
+		call: func(c *moltbook.Client) (string, error) {
+			p, err := c.CreatePost(context.Background(), &moltbook.CreatePostRequest{Submolt: "general", Title: "Created", Content: "x"})
+			if err != nil {
+				return "", err
+			}
+			return p.ID, nil
+		},
+	},
+	{
+		name: "CreateReply", method: "POST", path: "/posts/post_1/comments", key: "comment",
+		payload: `{"id": "comment_2", "content": "Reply"}`,
+		id:      "comment_2",
+		call: func(c *moltbook.Client) (string, error) {
+			comment, err := c.CreateReply(context.Background(), "post_1", "comment_1", "Reply")
+			if err != nil {
+				return "", err
+			}
+			return comment.ID, nil
+		},
+	},
+	{
+		name: "Search", method: "GET", path: "/search", key: "results",
+		payload: `[{"id": "post_3", "title": "Found"}]`,
+		id:      "post_3",
+		call: func(c *moltbook.Client) (string, error) {
+			posts, err := c.Search(context.Background(), "found")
+			if err != nil || len(posts) == 0 {
+				return "", err
+			}
+			return posts[0].ID, nil
+		},
+	},
+}
+
+func TestResponseContracts(t *testing.T) {
+	for _, ep := range endpoints {
+		bodies := map[string]string{
+			"wrapped":           fmt.Sprintf(`{"success": true, %q: %s}`, ep.key, ep.payload),
+			"data":              fmt.Sprintf(`{"success": true, "data": %s}`, ep.payload),
+			"data wrapping key": fmt.Sprintf(`{"success": true, "data": {%q: %s}}`, ep.key, ep.payload),
+			"bare":              ep.payload,
+		}
+		for shape, body := range bodies {
+			t.Run(ep.name+"/"+shape, func(t *testing.T) {
+				srv := moltbooktest.NewServer(t)
+				srv.Fail(moltbooktest.Failure{Method: ep.method, Path: ep.path, Status: http.StatusOK, Body: body})
+
+				id, err := ep.call(srv.Client())
+				if err != nil {
+					t.Fatal(err)
+				}
+				if id != ep.id {
+					t.Errorf("decoded ID %q, want %q", id, ep.id)
+				}
+				srv.AssertRequestCount(t, ep.method, ep.path, 1)
+			})
+		}
+
+		t.Run(ep.name+"/unsuccessful", func(t *testing.T) {
+			srv := moltbooktest.NewServer(t)
+			srv.Fail(moltbooktest.Failure{Method: ep.method, Path: ep.path, Status: http.StatusOK,
+				Body: `{"success": false, "error": "Not allowed", "hint": "Ask nicely"}`})
+
+			_, err := ep.call(srv.Client())
+			var apiErr *moltbook.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("err = %v, want an API error", err)
+			}
+			if apiErr.Status != http.StatusOK || apiErr.Message != "Not allowed" || apiErr.Hint != "Ask nicely" {
+				t.Errorf("API error = %+v", apiErr)
+			}
+		})
+	}
+}
+
+func TestVoteContract(t *testing.T) {
+	tests := []struct {
+		body    string
+		wantErr string
+	}{
+		{`{"success": true, "message": "Upvoted"}`, ""},
+		{`{}`, ""},
+		{`{"success": false, "error": "Already voted"}`, "API error (status 200): Already voted"},
+		{`{"success": false}`, "API error (status 200): request was not successful"},
+	}
+	for _, tt := range tests {
+		srv := moltbooktest.NewServer(t)
+		srv.Fail(moltbooktest.Failure{Method: "POST", Path: "/vote", Status: http.StatusOK, Body: tt.body})
+
+		err := srv.Client().Vote(context.Background(), "post", "post_1", "up")
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != tt.wantErr {
+			t.Errorf("Vote with %s: err = %q, want %q", tt.body, got, tt.wantErr)
+		}
+	}
+}
+
+func TestRegisterContract(t *testing.T) {
+	tests := []struct {
+		shape string
+		body  string
+	}{
+		{"nested", `{"success": true, "agent": {"id": "agent_9", "api_key": "moltbook_sk_nested", "claim_url": "https://claim"}}`},
+		{"legacy flat", `{"success": true, "agent_id": "agent_9", "api_key": "moltbook_sk_nested", "claim_url": "https://claim"}`},
+		{"data", `{"success": true, "data": {"agent": {"id": "agent_9", "api_key": "moltbook_sk_nested", "claim_url": "https://claim"}}}`},
+		{"data legacy flat", `{"success": true, "data": {"agent_id": "agent_9", "api_key": "moltbook_sk_nested", "claim_url": "https://claim"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.shape, func(t *testing.T) {
+			srv := moltbooktest.NewServer(t)
+			srv.Fail(moltbooktest.Failure{Method: "POST", Path: "/agents/register", Status: http.StatusCreated, Body: tt.body})
+
+			res, err := moltbook.Register(context.Background(), "Contract", "", moltbook.WithBaseURL(srv.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Both shapes are filled in whichever the response used
+			if res.AgentID != "agent_9" || res.APIKey != "moltbook_sk_nested" || res.ClaimURL != "https://claim" {
+				t.Errorf("flat fields = %+v", res)
+			}
+			if a := res.Agent; a == nil || a.ID != "agent_9" || a.APIKey != "moltbook_sk_nested" || a.ClaimURL != "https://claim" {
+				t.Errorf("agent = %+v", a)
+			}
+		})
+	}
+
+	srv := moltbooktest.NewServer(t)
+	srv.Fail(moltbooktest.Failure{Method: "POST", Path: "/agents/register", Status: http.StatusOK,
+		Body: `{"success": false, "error": "Name taken"}`})
+	if _, err := moltbook.Register(context.Background(), "Contract", "", moltbook.WithBaseURL(srv.URL)); err == nil || err.Error() != "API error (status 200): Name taken" {
+		t.Errorf("unsuccessful registration: err = %v", err)
+	}
+}
+
+func TestStrictDecodingWarnsAboutUnknownFields(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		body string
+		call func(c *moltbook.Client) error
+		want string
+	}{
+		{
+			name: "unknown payload and envelope fields",
+			path: "/posts",
+			body: `{"success": true, "posts": [{"id": "post_1", "author_karma": 5}], "has_more": true}`,
+			call: func(c *moltbook.Client) error {
+				_, err := c.BrowsePosts(context.Background(), &moltbook.BrowsePostsRequest{})
+				return err
+			},
+			want: "[strict] GET /posts: unknown fields: has_more, [].author_karma\n",
+		},
+		{
+			name: "bare payload with envelope fields",
+			path: "/posts/post_1",
+			body: `{"success": true, "id": "post_1", "title": "Known", "flair": "new"}`,
+			call: func(c *moltbook.Client) error {
+				_, err := c.GetPost(context.Background(), "post_1")
+				return err
+			},
+			want: "[strict] GET /posts/{id}: unknown fields: flair\n",
+		},
+		{
+			name: "inside data",
+			path: "/agents/me",
+			body: `{"success": true, "data": {"agent": {"id": "agent_1", "karma": 3}, "stats": {}}}`,
+			call: func(c *moltbook.Client) error {
+				_, err := c.GetProfile(context.Background())
+				return err
+			},
+			want: "[strict] GET /agents/me: unknown fields: data.stats, karma\n",
+		},
+		{
+			name: "known fields only",
+			path: "/posts/post_1",
+			body: `{"success": true, "post": {"id": "post_1", "title": "Known", "score": 1}}`,
+			call: func(c *moltbook.Client) error {
+				_, err := c.GetPost(context.Background(), "post_1")
+				return err
+			},
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := moltbooktest.NewServer(t)
+			srv.Fail(moltbooktest.Failure{Method: "GET", Path: tt.path, Status: http.StatusOK, Body: tt.body})
+
+			var warnings bytes.Buffer
+			if err := tt.call(srv.Client(moltbook.WithStrictDecoding(&warnings))); err != nil {
+				t.Fatal(err)
+			}
+			if warnings.String() != tt.want {
+				t.Errorf("warnings = %q, want %q", warnings.String(), tt.want)
+			}
+		})
+	}
+
+	// Without strict decoding unknown fields are ignored silently
+	srv := moltbooktest.NewServer(t)
+	srv.Fail(moltbooktest.Failure{Method: "GET", Path: "/posts", Status: http.StatusOK, Body: tests[0].body})
+	if _, err := srv.Client().BrowsePosts(context.Background(), &moltbook.BrowsePostsRequest{}); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,74 @@
+package moltbook
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// maxLoggedBody caps how much of a body --debug-http style logging records
+const maxLoggedBody = 16 << 10
+
+// discardLogger is the logger of clients without one
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+// WithLogger sends the client's logs to l. Successful requests are logged
+// at debug level, requests the API rejected at info, and requests that could
+// not be made at warn.
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// WithHTTPDebug adds the request and response headers and bodies, with
+// credentials redacted, to each request's log record
+func WithHTTPDebug() Option {
+	return func(c *Client) {
+		c.debugHTTP = true
+	}
+}
+
+// logRequest logs one API request and its outcome
+func (c *Client) logRequest(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, latency time.Duration, err error) {
+	level := slog.LevelDebug
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+	}
+	if resp != nil {
+		attrs = append(attrs, slog.Int("status", resp.StatusCode))
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			level = slog.LevelInfo
+		}
+	}
+	attrs = append(attrs, slog.Duration("latency", latency))
+	if err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	if c.debugHTTP {
+		attrs = append(attrs,
+			slog.Any("request_headers", RedactHeader(req.Header)),
+			slog.String("request_body", loggedBody(reqBody)))
+		if resp != nil {
+			attrs = append(attrs,
+				slog.Any("response_headers", RedactHeader(resp.Header)),
+				slog.String("response_body", loggedBody(respBody)))
+		}
+	}
+
+	c.logger.LogAttrs(context.Background(), level, "api request", attrs...)
+}
+
+// loggedBody redacts a body and truncates it to maxLoggedBody
+func loggedBody(body []byte) string {
+	s := Redact(string(body))
+	if len(s) > maxLoggedBody {
+		s = s[:maxLoggedBody] + "...(truncated)"
+	}
+	return s
+}
@@ -0,0 +1,91 @@
+package moltbook
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/metrics"
+)
+
+// clientMetrics are the metrics a client records
+type clientMetrics struct {
+	requests    *metrics.Counter
+	latency     *metrics.Histogram
+	rateLimited *metrics.Counter
+	posts       *metrics.Counter
+	comments    *metrics.Counter
+	votes       *metrics.Counter
+}
+
+// WithMetrics records request counts and latencies, rate limiting, and the
+// posts, comments and votes the client creates in r
+func WithMetrics(r *metrics.Registry) Option {
+	return func(c *Client) {
+		c.metrics = &clientMetrics{
+			requests: r.Counter("moltgo_api_requests_total",
+				"API requests by method, endpoint and status code; status is \"error\" when no response was received",
+				"method", "endpoint", "status"),
+			latency: r.Histogram("moltgo_api_request_duration_seconds",
+				"API request latency", metrics.DefBuckets, "method", "endpoint"),
+			rateLimited: r.Counter("moltgo_api_rate_limited_total",
+				"API requests rejected with 429 Too Many Requests", "endpoint"),
+			posts:    r.Counter("moltgo_posts_created_total", "Posts created"),
+			comments: r.Counter("moltgo_comments_created_total", "Comments and replies created"),
+			votes:    r.Counter("moltgo_votes_cast_total", "Votes cast, by direction", "direction"),
+		}
+	}
+}
+
+// observeRequest logs an API request and records it in the metrics and the
+// audit log
+func (c *Client) observeRequest(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte, latency time.Duration, err error) {
+	c.logRequest(req, reqBody, resp, respBody, latency, err)
+	c.auditRequest(req, reqBody, resp, respBody, err)
+	if c.metrics == nil {
+		return
+	}
+
+	route := routeOf(req.URL.Path, c.baseURL)
+	status := "error"
+	if resp != nil {
+		status = strconv.Itoa(resp.StatusCode)
+		if resp.StatusCode == http.StatusTooManyRequests {
+			c.metrics.rateLimited.Inc(route)
+		}
+	}
+	c.metrics.requests.Inc(req.Method, route, status)
+	c.metrics.latency.Observe(latency.Seconds(), req.Method, route)
+}
+
+// routeOf returns the endpoint of a request path with IDs replaced by
+// "{id}", so metrics have one series per endpoint rather than per post
+func routeOf(path, baseURL string) string {
+	segments := strings.Split(trimBase(path, baseURL), "/")
+	for i := 1; i < len(segments); i++ {
+		if segments[i-1] == "posts" && segments[i] != "" {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// trimBase removes the path of baseURL, such as "/api/v1", from the front
+// of a request path
+func trimBase(path, baseURL string) string {
+	if i := strings.Index(baseURL, "://"); i >= 0 {
+		baseURL = baseURL[i+3:]
+		if j := strings.Index(baseURL, "/"); j >= 0 {
+			path = strings.TrimPrefix(path, baseURL[j:])
+		}
+	}
+	return path
+}
+
+// countCreated records a post, comment or vote the API accepted
+func (c *Client) countCreated(record func(m *clientMetrics)) {
+	if c.metrics != nil && c.dryRun == nil {
+		record(c.metrics)
+	}
+}
@@ -0,0 +1,112 @@
+package moltbooktest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+)
+
+// requestMatches reports whether a request has the method and path. Paths
+// ending in "/" match every path below them.
+func requestMatches(req *Request, method, path string) bool {
+	if !strings.EqualFold(req.Method, method) {
+		return false
+	}
+	if strings.HasSuffix(path, "/") {
+		return strings.HasPrefix(req.Path, path)
+	}
+	return req.Path == path
+}
+
+// RequestCount returns how many requests with the method and path were
+// received
+func (s *Server) RequestCount(method, path string) int {
+	n := 0
+	for _, req := range s.Requests() {
+		if requestMatches(&req, method, path) {
+			n++
+		}
+	}
+	return n
+}
+
+// AssertRequested fails the test unless a request with the method and path
+// was received
+func (s *Server) AssertRequested(t testing.TB, method, path string) {
+	t.Helper()
+	if s.RequestCount(method, path) == 0 {
+		t.Errorf("expected a %s %s request; got %s", method, path, s.describeRequests())
+	}
+}
+
+// AssertNotRequested fails the test if a request with the method and path
+// was received
+func (s *Server) AssertNotRequested(t testing.TB, method, path string) {
+	t.Helper()
+	if n := s.RequestCount(method, path); n > 0 {
+		t.Errorf("expected no %s %s request; got %d", method, path, n)
+	}
+}
+
+// AssertRequestCount fails the test unless exactly n requests with the
+// method and path were received
+func (s *Server) AssertRequestCount(t testing.TB, method, path string, n int) {
+	t.Helper()
+	if got := s.RequestCount(method, path); got != n {
+		t.Errorf("expected %d %s %s requests; got %d", n, method, path, got)
+	}
+}
+
+// AssertPosted fails the test unless a post with the title exists, and
+// returns it
+func (s *Server) AssertPosted(t testing.TB, title string) moltbook.Post {
+	t.Helper()
+	var titles []string
+	for _, p := range s.Posts() {
+		if p.Title == title {
+			return p
+		}
+		titles = append(titles, p.Title)
+	}
+	t.Errorf("expected a post titled %q; posts are %q", title, titles)
+	return moltbook.Post{}
+}
+
+// AssertCommented fails the test unless the post has a comment with the
+// content, and returns it
+func (s *Server) AssertCommented(t testing.TB, postID, content string) moltbook.Comment {
+	t.Helper()
+	var contents []string
+	for _, c := range s.Comments(postID) {
+		if c.Content == content {
+			return c
+		}
+		contents = append(contents, c.Content)
+	}
+	t.Errorf("expected a comment %q on post %s; comments are %q", content, postID, contents)
+	return moltbook.Comment{}
+}
+
+// AssertVoted fails the test unless the target has a vote in the direction
+func (s *Server) AssertVoted(t testing.TB, targetID, direction string) {
+	t.Helper()
+	for _, v := range s.Votes() {
+		if v.TargetID == targetID && v.Direction == direction {
+			return
+		}
+	}
+	t.Errorf("expected a %svote on %s", direction, targetID)
+}
+
+func (s *Server) describeRequests() string {
+	reqs := s.Requests()
+	if len(reqs) == 0 {
+		return "no requests"
+	}
+	lines := make([]string, len(reqs))
+	for i, req := range reqs {
+		lines[i] = req.Method + " " + req.Path
+	}
+	return strings.Join(lines, ", ")
+}
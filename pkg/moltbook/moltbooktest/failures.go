@@ -0,0 +1,199 @@
+package moltbooktest
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Limits are the rate limits the server enforces per agent
+type Limits struct {
+	// Requests allowed per Window
+	Requests int
+	Window   time.Duration
+
+	PostInterval    time.Duration
+	CommentInterval time.Duration
+	CommentsPerDay  int
+}
+
+// DefaultLimits are Moltbook's published limits
+var DefaultLimits = Limits{
+	Requests:        100,
+	Window:          time.Minute,
+	PostInterval:    30 * time.Minute,
+	CommentInterval: 20 * time.Second,
+	CommentsPerDay:  50,
+}
+
+// limitRequests rejects a request over the per-window limit
+func (s *Server) limitRequests(w http.ResponseWriter, a *agent) bool {
+	if s.limits.Requests <= 0 || s.limits.Window <= 0 {
+		return false
+	}
+	now := s.now()
+	recent := a.requests[:0]
+	for _, t := range a.requests {
+		if now.Sub(t) < s.limits.Window {
+			recent = append(recent, t)
+		}
+	}
+	a.requests = recent
+	if len(recent) >= s.limits.Requests {
+		writeRateLimited(w, s.limits.Window-now.Sub(recent[0]), "Too many requests")
+		return true
+	}
+	a.requests = append(a.requests, now)
+	return false
+}
+
+// limitPost rejects a post made too soon after the previous one
+func (s *Server) limitPost(w http.ResponseWriter, a *agent) bool {
+	now := s.now()
+	if s.limits.PostInterval > 0 && !a.lastPost.IsZero() {
+		if wait := s.limits.PostInterval - now.Sub(a.lastPost); wait > 0 {
+			writeRateLimited(w, wait, "You can only post once every "+s.limits.PostInterval.String())
+			return true
+		}
+	}
+	a.lastPost = now
+	return false
+}
+
+// limitComment rejects a comment made too soon after the previous one or
+// over the daily cap
+func (s *Server) limitComment(w http.ResponseWriter, a *agent) bool {
+	now := s.now()
+	day := now.UTC().Format("2006-01-02")
+	if a.commentDay != day {
+		a.commentDay = day
+		a.commentsDay = 0
+	}
+	if s.limits.CommentsPerDay > 0 && a.commentsDay >= s.limits.CommentsPerDay {
+		tomorrow := time.Date(now.UTC().Year(), now.UTC().Month(), now.UTC().Day()+1, 0, 0, 0, 0, time.UTC)
+		writeRateLimited(w, tomorrow.Sub(now), fmt.Sprintf("Daily limit of %d comments reached", s.limits.CommentsPerDay))
+		return true
+	}
+	if s.limits.CommentInterval > 0 && !a.lastComment.IsZero() {
+		if wait := s.limits.CommentInterval - now.Sub(a.lastComment); wait > 0 {
+			writeRateLimited(w, wait, "You can only comment once every "+s.limits.CommentInterval.String())
+			return true
+		}
+	}
+	a.lastComment = now
+	a.commentsDay++
+	return false
+}
+
+func writeRateLimited(w http.ResponseWriter, wait time.Duration, message string) {
+	seconds := int((wait + time.Second - 1) / time.Second)
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	writeJSON(w, http.StatusTooManyRequests, map[string]interface{}{
+		"success":             false,
+		"error":               message,
+		"retry_after_seconds": seconds,
+	})
+}
+
+// Failure scripts how the server answers matching requests instead of
+// handling them normally
+type Failure struct {
+	// Method and Path select requests; empty matches any. A Path ending in
+	// "/" matches every path below it.
+	Method string
+	Path   string
+
+	// Status and Body replace the response. Without either, the request is
+	// handled normally after Delay.
+	Status int
+	Body   string
+	// RetryAfter sets the Retry-After header, in seconds
+	RetryAfter int
+
+	// Delay holds the response back, for exercising client timeouts
+	Delay time.Duration
+
+	// Times is how many requests the failure applies to; 0 means once and a
+	// negative value means every matching request
+	Times int
+}
+
+func (f *Failure) matches(req *Request) bool {
+	if f.Method != "" && !strings.EqualFold(f.Method, req.Method) {
+		return false
+	}
+	if f.Path == "" {
+		return true
+	}
+	if strings.HasSuffix(f.Path, "/") {
+		return strings.HasPrefix(req.Path, f.Path)
+	}
+	return req.Path == f.Path
+}
+
+func (f *Failure) write(w http.ResponseWriter) {
+	if f.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(f.RetryAfter))
+	}
+	status := f.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if f.Body == "" {
+		writeError(w, status, http.StatusText(status), "")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write([]byte(f.Body))
+}
+
+// Fail scripts a failure. Failures are used in the order they were added.
+func (s *Server) Fail(f Failure) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if f.Times == 0 {
+		f.Times = 1
+	}
+	s.failures = append(s.failures, &f)
+}
+
+// FailWith answers the next matching request with an error status
+func (s *Server) FailWith(method, path string, status int) {
+	s.Fail(Failure{Method: method, Path: path, Status: status})
+}
+
+// RateLimit answers the next matching request with 429 Too Many Requests
+func (s *Server) RateLimit(method, path string, retryAfter time.Duration) {
+	s.Fail(Failure{Method: method, Path: path, Status: http.StatusTooManyRequests, RetryAfter: int(retryAfter.Seconds())})
+}
+
+// Malformed answers the next matching request with a body that is not
+// valid JSON
+func (s *Server) Malformed(method, path string) {
+	s.Fail(Failure{Method: method, Path: path, Status: http.StatusOK, Body: `{"success": true, "posts": [`})
+}
+
+// Delay holds back the responses to matching requests by d
+func (s *Server) Delay(method, path string, d time.Duration) {
+	s.Fail(Failure{Method: method, Path: path, Delay: d, Times: -1})
+}
+
+// takeFailure returns the first scripted failure matching req, using it up
+func (s *Server) takeFailure(req *Request) *Failure {
+	for i, f := range s.failures {
+		if !f.matches(req) {
+			continue
+		}
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
@@ -0,0 +1,616 @@
+// Package moltbooktest provides an in-memory Moltbook API server for tests.
+//
+// A Server implements the endpoints moltbook.Client uses: registration, the
+// agent profile, posts, comments, votes and search. It enforces Moltbook's
+// rate limits, can be scripted to fail, and records every request so tests
+// can assert on what was sent:
+//
+//	srv := moltbooktest.NewServer(t)
+//	client := srv.Client()
+//	post, err := client.CreatePost(&moltbook.CreatePostRequest{Submolt: "general", Title: "Hi", Content: "Hello"})
+//	...
+//	srv.AssertPosted(t, "Hi")
+package moltbooktest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/timestamp"
+)
+
+// Credentials of the agent every server starts with
+const (
+	DefaultAgentName = "TestAgent"
+	DefaultAPIKey    = "moltbook_sk_test"
+)
+
+// Request is a request the server received
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	APIKey string
+	Body   []byte
+}
+
+// Vote is a vote the server recorded
+type Vote struct {
+	Agent      string
+	TargetType string
+	TargetID   string
+	Direction  string
+}
+
+type agent struct {
+	moltbook.Agent
+	apiKey string
+
+	// Request times in the current window, and content rate limit state
+	requests    []time.Time
+	lastPost    time.Time
+	lastComment time.Time
+	commentDay  string
+	commentsDay int
+}
+
+// Server is a fake Moltbook API. Its URL is the API root to give
+// moltbook.WithBaseURL.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	now      func() time.Time
+	limits   Limits
+	agents   map[string]*agent // by API key
+	posts    []*moltbook.Post
+	comments map[string][]*moltbook.Comment // by post ID
+	votes    map[string]*Vote               // by agent and target
+	requests []Request
+	failures []*Failure
+	nextID   int
+}
+
+// Option configures a Server
+type Option func(*Server)
+
+// WithLimits replaces the default rate limits. Zero fields are not enforced,
+// so WithLimits(Limits{}) turns rate limiting off.
+func WithLimits(l Limits) Option {
+	return func(s *Server) {
+		s.limits = l
+	}
+}
+
+// WithClock sets the time source used for timestamps and rate limits
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// NewServer starts a server with one registered agent (DefaultAgentName,
+// authenticated by DefaultAPIKey). It is closed when the test ends.
+func NewServer(t testing.TB, opts ...Option) *Server {
+	s := &Server{
+		now:      time.Now,
+		limits:   DefaultLimits,
+		agents:   make(map[string]*agent),
+		comments: make(map[string][]*moltbook.Comment),
+		votes:    make(map[string]*Vote),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.addAgent(DefaultAgentName, "", DefaultAPIKey)
+
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// Client returns a client for the default agent talking to the server
+func (s *Server) Client(opts ...moltbook.Option) *moltbook.Client {
+	return s.ClientFor(DefaultAPIKey, opts...)
+}
+
+// ClientFor returns a client authenticated with apiKey
+func (s *Server) ClientFor(apiKey string, opts ...moltbook.Option) *moltbook.Client {
+	return moltbook.NewClient(apiKey, append([]moltbook.Option{moltbook.WithBaseURL(s.URL)}, opts...)...)
+}
+
+// AddAgent registers another agent and returns its API key
+func (s *Server) AddAgent(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := fmt.Sprintf("moltbook_sk_%s_%d", strings.ToLower(name), s.nextID+1)
+	s.addAgent(name, "", key)
+	return key
+}
+
+func (s *Server) addAgent(name, description, apiKey string) *agent {
+	a := &agent{
+		Agent: moltbook.Agent{
+			ID:          s.newID("agent"),
+			Name:        name,
+			Description: description,
+			CreatedAt:   s.timestamp(),
+		},
+		apiKey: apiKey,
+	}
+	s.agents[apiKey] = a
+	return a
+}
+
+// AddPost seeds a post, filling in a missing ID, author and creation time,
+// and returns it
+func (s *Server) AddPost(p moltbook.Post) moltbook.Post {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p.ID == "" {
+		p.ID = s.newID("post")
+	}
+	if p.Author == "" {
+		p.Author = DefaultAgentName
+	}
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = s.timestamp()
+	}
+	s.posts = append(s.posts, &p)
+	return p
+}
+
+// AddComment seeds a comment on an existing post, filling in a missing ID,
+// author and creation time, and returns it
+func (s *Server) AddComment(c moltbook.Comment) moltbook.Comment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c.ID == "" {
+		c.ID = s.newID("comment")
+	}
+	if c.Author == "" {
+		c.Author = DefaultAgentName
+	}
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = s.timestamp()
+	}
+	c.Replies = nil
+	s.comments[c.PostID] = append(s.comments[c.PostID], &c)
+	if p := s.post(c.PostID); p != nil {
+		p.NumComments++
+	}
+	return c
+}
+
+// Posts returns the posts on the server, oldest first
+func (s *Server) Posts() []moltbook.Post {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	posts := make([]moltbook.Post, len(s.posts))
+	for i, p := range s.posts {
+		posts[i] = *p
+	}
+	return posts
+}
+
+// Comments returns the comments on a post as a flat list, oldest first
+func (s *Server) Comments(postID string) []moltbook.Comment {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	comments := make([]moltbook.Comment, len(s.comments[postID]))
+	for i, c := range s.comments[postID] {
+		comments[i] = *c
+	}
+	return comments
+}
+
+// Votes returns the votes cast on the server
+func (s *Server) Votes() []Vote {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var votes []Vote
+	for _, v := range s.votes {
+		votes = append(votes, *v)
+	}
+	sort.Slice(votes, func(i, j int) bool { return votes[i].TargetID < votes[j].TargetID })
+	return votes
+}
+
+// Requests returns the requests received so far, in order
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+func (s *Server) newID(kind string) string {
+	s.nextID++
+	return fmt.Sprintf("%s_%d", kind, s.nextID)
+}
+
+func (s *Server) timestamp() timestamp.Time {
+	return timestamp.New(s.now().UTC().Truncate(time.Second))
+}
+
+func (s *Server) post(id string) *moltbook.Post {
+	for _, p := range s.posts {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+func (s *Server) comment(id string) *moltbook.Comment {
+	for _, comments := range s.comments {
+		for _, c := range comments {
+			if c.ID == id {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	req := Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.Query(),
+		APIKey: strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "),
+		Body:   body,
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	failure := s.takeFailure(&req)
+	s.mu.Unlock()
+
+	if failure != nil {
+		if failure.Delay > 0 {
+			select {
+			case <-time.After(failure.Delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		if failure.Status != 0 || failure.Body != "" {
+			failure.write(w)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.route(w, &req)
+}
+
+func (s *Server) route(w http.ResponseWriter, req *Request) {
+	if req.Method == "POST" && req.Path == "/agents/register" {
+		s.register(w, req)
+		return
+	}
+
+	a := s.agents[req.APIKey]
+	if a == nil {
+		writeError(w, http.StatusUnauthorized, "Invalid or missing API key", "")
+		return
+	}
+	if s.limitRequests(w, a) {
+		return
+	}
+
+	parts := strings.Split(strings.Trim(req.Path, "/"), "/")
+	switch {
+	case req.Path == "/agents/me" && req.Method == "GET":
+		writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "agent": a.Agent})
+	case req.Path == "/agents/me" && req.Method == "PATCH":
+		s.updateProfile(w, req, a)
+	case req.Path == "/posts" && req.Method == "GET":
+		s.listPosts(w, req)
+	case req.Path == "/posts" && req.Method == "POST":
+		s.createPost(w, req, a)
+	case len(parts) == 2 && parts[0] == "posts" && req.Method == "GET":
+		s.getPost(w, parts[1])
+	case len(parts) == 3 && parts[0] == "posts" && parts[2] == "comments" && req.Method == "GET":
+		s.listComments(w, req, parts[1])
+	case len(parts) == 3 && parts[0] == "posts" && parts[2] == "comments" && req.Method == "POST":
+		s.createComment(w, req, a, parts[1])
+	case req.Path == "/vote" && req.Method == "POST":
+		s.vote(w, req, a)
+	case req.Path == "/search" && req.Method == "GET":
+		s.search(w, req)
+	default:
+		writeError(w, http.StatusNotFound, "Not found", "")
+	}
+}
+
+func (s *Server) register(w http.ResponseWriter, req *Request) {
+	var body moltbook.RegisterRequest
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body", "")
+		return
+	}
+	if strings.TrimSpace(body.Name) == "" {
+		writeError(w, http.StatusBadRequest, "Name is required", "")
+		return
+	}
+	for _, a := range s.agents {
+		if strings.EqualFold(a.Name, body.Name) {
+			writeError(w, http.StatusConflict, "Agent name already taken", "Try a different name")
+			return
+		}
+	}
+
+	key := fmt.Sprintf("moltbook_sk_%s_%d", strings.ToLower(body.Name), s.nextID+1)
+	a := s.addAgent(body.Name, body.Description, key)
+	writeJSON(w, http.StatusCreated, moltbook.RegisterResponse{
+		Success: true,
+		Message: "Agent registered",
+		Agent: &moltbook.AgentRegistration{
+			ID:               a.ID,
+			Name:             a.Name,
+			APIKey:           key,
+			ClaimURL:         moltbook.WebURL + "/claim/" + a.ID,
+			VerificationCode: "test-" + a.ID,
+			CreatedAt:        a.CreatedAt,
+		},
+	})
+}
+
+func (s *Server) updateProfile(w http.ResponseWriter, req *Request, a *agent) {
+	var body moltbook.UpdateProfileRequest
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body", "")
+		return
+	}
+	if body.Description != "" {
+		a.Description = body.Description
+	}
+	writeJSON(w, http.StatusOK, a.Agent)
+}
+
+func (s *Server) listPosts(w http.ResponseWriter, req *Request) {
+	submolt := req.Query.Get("submolt")
+	var posts []moltbook.Post
+	for _, p := range s.posts {
+		if submolt == "" || strings.EqualFold(p.Submolt, submolt) {
+			posts = append(posts, *p)
+		}
+	}
+	sortPosts(posts, req.Query.Get("sort"))
+
+	offset := atoi(req.Query.Get("offset"), 0)
+	limit := atoi(req.Query.Get("limit"), 25)
+	posts = page(posts, offset, limit)
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "posts": posts})
+}
+
+// sortPosts orders posts the way the feed does. Hot and rising are
+// approximated by score, with newer posts first among equals.
+func sortPosts(posts []moltbook.Post, order string) {
+	sort.SliceStable(posts, func(i, j int) bool {
+		if order != moltbook.SortNew && posts[i].Score != posts[j].Score {
+			return posts[i].Score > posts[j].Score
+		}
+		return posts[i].CreatedAt.After(posts[j].CreatedAt.Time)
+	})
+}
+
+func page(posts []moltbook.Post, offset, limit int) []moltbook.Post {
+	if offset >= len(posts) {
+		return []moltbook.Post{}
+	}
+	posts = posts[offset:]
+	if limit > 0 && len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts
+}
+
+func (s *Server) createPost(w http.ResponseWriter, req *Request, a *agent) {
+	var body moltbook.CreatePostRequest
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body", "")
+		return
+	}
+	switch {
+	case body.Submolt == "":
+		writeError(w, http.StatusBadRequest, "Submolt is required", "")
+		return
+	case body.Title == "":
+		writeError(w, http.StatusBadRequest, "Title is required", "")
+		return
+	case body.Content == "" && body.URL == "":
+		writeError(w, http.StatusBadRequest, "Content or URL is required", "")
+		return
+	}
+	if s.limitPost(w, a) {
+		return
+	}
+
+	p := &moltbook.Post{
+		ID:        s.newID("post"),
+		Submolt:   body.Submolt,
+		Title:     body.Title,
+		Content:   body.Content,
+		URL:       body.URL,
+		Author:    a.Name,
+		CreatedAt: s.timestamp(),
+	}
+	s.posts = append(s.posts, p)
+	writeJSON(w, http.StatusCreated, p)
+}
+
+func (s *Server) getPost(w http.ResponseWriter, id string) {
+	p := s.post(id)
+	if p == nil {
+		writeError(w, http.StatusNotFound, "Post not found", "")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "post": p})
+}
+
+func (s *Server) listComments(w http.ResponseWriter, req *Request, postID string) {
+	if s.post(postID) == nil {
+		writeError(w, http.StatusNotFound, "Post not found", "")
+		return
+	}
+	comments := make([]moltbook.Comment, 0, len(s.comments[postID]))
+	for _, c := range s.comments[postID] {
+		comments = append(comments, *c)
+	}
+	order := req.Query.Get("sort")
+	sort.SliceStable(comments, func(i, j int) bool {
+		if order == moltbook.SortNew {
+			return comments[i].CreatedAt.After(comments[j].CreatedAt.Time)
+		}
+		return comments[i].Score > comments[j].Score
+	})
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "comments": comments})
+}
+
+func (s *Server) createComment(w http.ResponseWriter, req *Request, a *agent, postID string) {
+	p := s.post(postID)
+	if p == nil {
+		writeError(w, http.StatusNotFound, "Post not found", "")
+		return
+	}
+	var body moltbook.CreateCommentRequest
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body", "")
+		return
+	}
+	if strings.TrimSpace(body.Content) == "" {
+		writeError(w, http.StatusBadRequest, "Content is required", "")
+		return
+	}
+	if body.ParentID != "" {
+		if parent := s.comment(body.ParentID); parent == nil || parent.PostID != postID {
+			writeError(w, http.StatusNotFound, "Parent comment not found", "")
+			return
+		}
+	}
+	if s.limitComment(w, a) {
+		return
+	}
+
+	c := &moltbook.Comment{
+		ID:        s.newID("comment"),
+		PostID:    postID,
+		ParentID:  body.ParentID,
+		Content:   body.Content,
+		Author:    a.Name,
+		CreatedAt: s.timestamp(),
+	}
+	s.comments[postID] = append(s.comments[postID], c)
+	p.NumComments++
+	writeJSON(w, http.StatusCreated, c)
+}
+
+func (s *Server) vote(w http.ResponseWriter, req *Request, a *agent) {
+	var body moltbook.VoteRequest
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid JSON body", "")
+		return
+	}
+
+	delta := map[string]int{"up": 1, "down": -1}[body.Direction]
+	if delta == 0 {
+		writeError(w, http.StatusBadRequest, "Direction must be up or down", "")
+		return
+	}
+	var score *int
+	switch body.TargetType {
+	case "post":
+		if p := s.post(body.TargetID); p != nil {
+			score = &p.Score
+		}
+	case "comment":
+		if c := s.comment(body.TargetID); c != nil {
+			score = &c.Score
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "Target type must be post or comment", "")
+		return
+	}
+	if score == nil {
+		writeError(w, http.StatusNotFound, "Target not found", "")
+		return
+	}
+
+	// One vote per agent and target; voting again changes its direction
+	key := a.ID + "/" + body.TargetType + "/" + body.TargetID
+	if prev := s.votes[key]; prev != nil {
+		if prev.Direction == body.Direction {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "message": "Already voted"})
+			return
+		}
+		*score -= map[string]int{"up": 1, "down": -1}[prev.Direction]
+	}
+	*score += delta
+	s.votes[key] = &Vote{Agent: a.Name, TargetType: body.TargetType, TargetID: body.TargetID, Direction: body.Direction}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+// search ranks posts by how many query words they contain
+func (s *Server) search(w http.ResponseWriter, req *Request) {
+	words := strings.Fields(strings.ToLower(req.Query.Get("q")))
+	type hit struct {
+		post    moltbook.Post
+		matches int
+	}
+	var hits []hit
+	for _, p := range s.posts {
+		text := strings.ToLower(p.Title + " " + p.Content)
+		n := 0
+		for _, word := range words {
+			if strings.Contains(text, word) {
+				n++
+			}
+		}
+		if n > 0 {
+			hits = append(hits, hit{*p, n})
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool { return hits[i].matches > hits[j].matches })
+
+	results := make([]moltbook.Post, len(hits))
+	for i, h := range hits {
+		results[i] = h.post
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"success": true, "results": results})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message, hint string) {
+	body := map[string]interface{}{"success": false, "error": message}
+	if hint != "" {
+		body["hint"] = hint
+	}
+	writeJSON(w, status, body)
+}
+
+func atoi(s string, def int) int {
+	var n int
+	if _, err := fmt.Sscan(s, &n); err != nil || n < 0 {
+		return def
+	}
+	return n
+}
@@ -0,0 +1,418 @@
+package moltbooktest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/moltbook/moltbooktest"
+)
+
+// clock is a settable time source for the server
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newClock() *clock {
+	return &clock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// recorder is a testing.TB that records failures instead of failing
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func newPost(title string) *moltbook.CreatePostRequest {
+	return &moltbook.CreatePostRequest{Submolt: "general", Title: title, Content: "Body of " + title}
+}
+
+func TestPostsCommentsAndVotes(t *testing.T) {
+	clk := newClock()
+	srv := moltbooktest.NewServer(t, moltbooktest.WithClock(clk.Now))
+	client := srv.Client()
+
+	post, err := client.CreatePost(context.Background(), newPost("Hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if post.Author != moltbooktest.DefaultAgentName {
+		t.Errorf("author = %q, want %q", post.Author, moltbooktest.DefaultAgentName)
+	}
+	srv.AssertPosted(t, "Hello")
+
+	top, err := client.CreateComment(context.Background(), post.ID, "First!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk.Advance(time.Minute)
+	if _, err := client.CreateReply(context.Background(), post.ID, top.ID, "Welcome"); err != nil {
+		t.Fatal(err)
+	}
+	srv.AssertCommented(t, post.ID, "First!")
+	reply := srv.AssertCommented(t, post.ID, "Welcome")
+	if reply.ParentID != top.ID {
+		t.Errorf("reply parent = %q, want %q", reply.ParentID, top.ID)
+	}
+
+	if err := client.Vote(context.Background(), "post", post.ID, "up"); err != nil {
+		t.Fatal(err)
+	}
+	srv.AssertVoted(t, post.ID, "up")
+
+	got, err := client.GetPost(context.Background(), post.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Score != 1 || got.NumComments != 2 {
+		t.Errorf("post has score %d and %d comments, want 1 and 2", got.Score, got.NumComments)
+	}
+
+	srv.AssertRequested(t, "POST", "/posts")
+	srv.AssertRequestCount(t, "POST", "/posts/", 2)
+	srv.AssertNotRequested(t, "GET", "/search")
+}
+
+func TestVoteChangesDirection(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	client := srv.Client()
+	post := srv.AddPost(moltbook.Post{Submolt: "general", Title: "Seeded"})
+
+	for _, dir := range []string{"up", "up", "down"} {
+		if err := client.Vote(context.Background(), "post", post.ID, dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if score := srv.Posts()[0].Score; score != -1 {
+		t.Errorf("score = %d, want -1", score)
+	}
+	if votes := srv.Votes(); len(votes) != 1 || votes[0].Direction != "down" {
+		t.Errorf("votes = %+v, want a single downvote", votes)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+
+	res, err := moltbook.Register(context.Background(), "NewAgent", "A test agent", moltbook.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(res.APIKey, "moltbook_sk_newagent") {
+		t.Errorf("API key = %q", res.APIKey)
+	}
+
+	profile, err := srv.ClientFor(res.APIKey).GetProfile(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Name != "NewAgent" || profile.Description != "A test agent" {
+		t.Errorf("profile = %+v", profile)
+	}
+
+	_, err = moltbook.Register(context.Background(), "newagent", "", moltbook.WithBaseURL(srv.URL))
+	var apiErr *moltbook.APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusConflict {
+		t.Errorf("registering a taken name: err = %v, want a 409", err)
+	}
+}
+
+func TestUnknownAPIKey(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	_, err := srv.ClientFor("moltbook_sk_wrong").GetProfile(context.Background())
+	var apiErr *moltbook.APIError
+	if !errors.As(err, &apiErr) || apiErr.Status != http.StatusUnauthorized {
+		t.Errorf("err = %v, want a 401", err)
+	}
+	if req := srv.Requests()[0]; req.APIKey != "moltbook_sk_wrong" {
+		t.Errorf("recorded API key = %q", req.APIKey)
+	}
+}
+
+func TestBrowseSortsAndPages(t *testing.T) {
+	clk := newClock()
+	srv := moltbooktest.NewServer(t, moltbooktest.WithClock(clk.Now))
+	for i, score := range []int{5, 1, 3} {
+		srv.AddPost(moltbook.Post{Submolt: "general", Title: fmt.Sprintf("post %d", i), Score: score})
+		clk.Advance(time.Minute)
+	}
+	srv.AddPost(moltbook.Post{Submolt: "other", Title: "elsewhere"})
+	client := srv.Client()
+
+	tests := []struct {
+		req  moltbook.BrowsePostsRequest
+		want []string
+	}{
+		{moltbook.BrowsePostsRequest{Submolt: "general", Sort: moltbook.SortTop}, []string{"post 0", "post 2", "post 1"}},
+		{moltbook.BrowsePostsRequest{Submolt: "general", Sort: moltbook.SortNew}, []string{"post 2", "post 1", "post 0"}},
+		{moltbook.BrowsePostsRequest{Submolt: "general", Sort: moltbook.SortTop, Limit: 1, Offset: 1}, []string{"post 2"}},
+		{moltbook.BrowsePostsRequest{Submolt: "general", Offset: 10}, nil},
+		{moltbook.BrowsePostsRequest{Sort: moltbook.SortNew, Limit: 1}, []string{"elsewhere"}},
+	}
+	for _, tt := range tests {
+		posts, err := client.BrowsePosts(context.Background(), &tt.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var titles []string
+		for _, p := range posts {
+			titles = append(titles, p.Title)
+		}
+		if strings.Join(titles, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("BrowsePosts(%+v) = %q, want %q", tt.req, titles, tt.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	srv.AddPost(moltbook.Post{Submolt: "general", Title: "Go generics", Content: "type parameters"})
+	srv.AddPost(moltbook.Post{Submolt: "general", Title: "Go", Content: "gophers"})
+	srv.AddPost(moltbook.Post{Submolt: "general", Title: "Rust", Content: "crabs"})
+
+	results, err := srv.Client().Search(context.Background(), "go generics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Title != "Go generics" {
+		t.Errorf("results = %+v, want the generics post first and no Rust", results)
+	}
+	if q := srv.Requests()[0].Query.Get("q"); q != "go generics" {
+		t.Errorf("query = %q", q)
+	}
+}
+
+func TestRequestRateLimit(t *testing.T) {
+	clk := newClock()
+	srv := moltbooktest.NewServer(t,
+		moltbooktest.WithClock(clk.Now),
+		moltbooktest.WithLimits(moltbooktest.Limits{Requests: 2, Window: time.Minute}))
+	client := srv.Client()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.GetProfile(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := client.GetProfile(context.Background())
+	var rateErr *moltbook.RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("third request: err = %v, want a rate limit error", err)
+	}
+	if rateErr.RetryAfter != time.Minute {
+		t.Errorf("retry after %s, want 1m", rateErr.RetryAfter)
+	}
+
+	clk.Advance(time.Minute)
+	if _, err := client.GetProfile(context.Background()); err != nil {
+		t.Errorf("after the window: %v", err)
+	}
+
+	// Limits are per agent
+	other := srv.ClientFor(srv.AddAgent("Other"))
+	if _, err := other.GetProfile(context.Background()); err != nil {
+		t.Errorf("another agent: %v", err)
+	}
+}
+
+func TestContentRateLimits(t *testing.T) {
+	clk := newClock()
+	srv := moltbooktest.NewServer(t,
+		moltbooktest.WithClock(clk.Now),
+		moltbooktest.WithLimits(moltbooktest.Limits{PostInterval: 30 * time.Minute, CommentInterval: 20 * time.Second, CommentsPerDay: 2}))
+	client := srv.Client()
+
+	post, err := client.CreatePost(context.Background(), newPost("one"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	clk.Advance(10 * time.Minute)
+	_, err = client.CreatePost(context.Background(), newPost("two"))
+	var rateErr *moltbook.RateLimitError
+	if !errors.As(err, &rateErr) || rateErr.RetryAfter != 20*time.Minute {
+		t.Errorf("second post: err = %v, want a 20m rate limit", err)
+	}
+	clk.Advance(20 * time.Minute)
+	if _, err := client.CreatePost(context.Background(), newPost("two")); err != nil {
+		t.Errorf("post after the interval: %v", err)
+	}
+
+	if _, err := client.CreateComment(context.Background(), post.ID, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.CreateComment(context.Background(), post.ID, "b"); !errors.As(err, &rateErr) {
+		t.Errorf("comment within the interval: err = %v, want a rate limit error", err)
+	}
+	clk.Advance(20 * time.Second)
+	if _, err := client.CreateComment(context.Background(), post.ID, "b"); err != nil {
+		t.Fatal(err)
+	}
+	clk.Advance(20 * time.Second)
+	if _, err := client.CreateComment(context.Background(), post.ID, "c"); !errors.As(err, &rateErr) {
+		t.Errorf("comment over the daily cap: err = %v, want a rate limit error", err)
+	}
+	clk.Advance(24 * time.Hour)
+	if _, err := client.CreateComment(context.Background(), post.ID, "c"); err != nil {
+		t.Errorf("comment the next day: %v", err)
+	}
+}
+
+func TestScriptedFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		script func(srv *moltbooktest.Server)
+		check  func(t *testing.T, err error)
+	}{
+		{
+			name:   "server error",
+			script: func(srv *moltbooktest.Server) { srv.FailWith("GET", "/agents/me", http.StatusInternalServerError) },
+			check: func(t *testing.T, err error) {
+				var apiErr *moltbook.APIError
+				if !errors.As(err, &apiErr) || apiErr.Status != http.StatusInternalServerError {
+					t.Errorf("err = %v, want a 500", err)
+				}
+			},
+		},
+		{
+			name:   "rate limited",
+			script: func(srv *moltbooktest.Server) { srv.RateLimit("", "/agents/", 42*time.Second) },
+			check: func(t *testing.T, err error) {
+				var rateErr *moltbook.RateLimitError
+				if !errors.As(err, &rateErr) || rateErr.RetryAfter != 42*time.Second {
+					t.Errorf("err = %v, want a rate limit error with a 42s retry", err)
+				}
+			},
+		},
+		{
+			name:   "malformed JSON",
+			script: func(srv *moltbooktest.Server) { srv.Malformed("GET", "/agents/me") },
+			check: func(t *testing.T, err error) {
+				if err == nil || !strings.Contains(err.Error(), "failed to parse profile") {
+					t.Errorf("err = %v, want a parse error", err)
+				}
+			},
+		},
+		{
+			name: "custom body",
+			script: func(srv *moltbooktest.Server) {
+				srv.Fail(moltbooktest.Failure{Status: http.StatusOK, Body: `{"success": false, "error": "Agent suspended", "hint": "Contact support"}`})
+			},
+			check: func(t *testing.T, err error) {
+				if err == nil || err.Error() != "API error (status 200): Agent suspended - Contact support" {
+					t.Errorf("err = %v", err)
+				}
+			},
+		},
+		{
+			name:   "other requests unaffected",
+			script: func(srv *moltbooktest.Server) { srv.FailWith("POST", "/posts", http.StatusInternalServerError) },
+			check: func(t *testing.T, err error) {
+				if err != nil {
+					t.Errorf("err = %v, want none", err)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := moltbooktest.NewServer(t)
+			tt.script(srv)
+			client := srv.Client()
+
+			_, err := client.GetProfile(context.Background())
+			tt.check(t, err)
+
+			// Failures apply once by default
+			if _, err := client.GetProfile(context.Background()); err != nil {
+				t.Errorf("second request: %v", err)
+			}
+		})
+	}
+}
+
+func TestFailureTimes(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	srv.Fail(moltbooktest.Failure{Path: "/agents/me", Status: http.StatusBadGateway, Times: 2})
+	client := srv.Client()
+
+	var errs int
+	for i := 0; i < 4; i++ {
+		if _, err := client.GetProfile(context.Background()); err != nil {
+			errs++
+		}
+	}
+	if errs != 2 {
+		t.Errorf("%d requests failed, want 2", errs)
+	}
+	srv.AssertRequestCount(t, "GET", "/agents/me", 4)
+}
+
+func TestDelay(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	srv.Delay("GET", "/agents/me", 200*time.Millisecond)
+
+	slow := srv.Client(moltbook.WithHTTPClient(&http.Client{Timeout: 20 * time.Millisecond}))
+	if _, err := slow.GetProfile(context.Background()); err == nil {
+		t.Error("request outlived the client timeout")
+	}
+
+	start := time.Now()
+	if _, err := srv.Client().GetProfile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("response came after %s, want at least 200ms", elapsed)
+	}
+}
+
+func TestAssertionsReportFailures(t *testing.T) {
+	srv := moltbooktest.NewServer(t)
+	if _, err := srv.Client().CreatePost(context.Background(), newPost("Real")); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := &recorder{TB: t}
+	srv.AssertPosted(rec, "Missing")
+	srv.AssertCommented(rec, "post_1", "nothing")
+	srv.AssertVoted(rec, "post_1", "up")
+	srv.AssertRequested(rec, "GET", "/search")
+	srv.AssertNotRequested(rec, "POST", "/posts")
+	srv.AssertRequestCount(rec, "POST", "/posts", 2)
+
+	want := []string{
+		`expected a post titled "Missing"; posts are ["Real"]`,
+		`expected a comment "nothing" on post post_1; comments are []`,
+		`expected a upvote on post_1`,
+		`expected a GET /search request; got POST /posts`,
+		`expected no POST /posts request; got 1`,
+		`expected 2 POST /posts requests; got 1`,
+	}
+	if strings.Join(rec.errors, "\n") != strings.Join(want, "\n") {
+		t.Errorf("assertion failures:\n%s\nwant:\n%s", strings.Join(rec.errors, "\n"), strings.Join(want, "\n"))
+	}
+}
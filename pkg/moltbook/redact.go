@@ -0,0 +1,46 @@
+package moltbook
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// Redacted replaces secrets in logs and recordings
+const Redacted = "[REDACTED]"
+
+// secretHeaders are the headers that carry credentials
+var secretHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+var (
+	apiKeyPattern   = regexp.MustCompile(`moltbook_sk_[A-Za-z0-9_\-]+`)
+	apiKeyField     = regexp.MustCompile(`("api_key"\s*:\s*)"[^"]*"`)
+	verifyCodeField = regexp.MustCompile(`("verification_code"\s*:\s*)"[^"]*"`)
+)
+
+// Redact removes API keys and verification codes from text such as a
+// request or response body
+func Redact(s string) string {
+	s = apiKeyPattern.ReplaceAllString(s, Redacted)
+	s = apiKeyField.ReplaceAllString(s, `$1"`+Redacted+`"`)
+	return verifyCodeField.ReplaceAllString(s, `$1"`+Redacted+`"`)
+}
+
+// RedactHeader returns a copy of h with credential headers replaced and API
+// keys removed from the rest
+func RedactHeader(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	out := h.Clone()
+	for _, name := range secretHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, Redacted)
+		}
+	}
+	for _, values := range out {
+		for i := range values {
+			values[i] = Redact(values[i])
+		}
+	}
+	return out
+}
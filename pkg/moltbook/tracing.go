@@ -0,0 +1,25 @@
+package moltbook
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/moltgo/moltgo/pkg/tracing"
+)
+
+// startRequestSpan starts the span of one API request, named like
+// "GET /posts/{id}" after the endpoint
+func (c *Client) startRequestSpan(ctx context.Context, method, endpoint string) (context.Context, *tracing.Span) {
+	path, _, _ := strings.Cut(endpoint, "?")
+	route := routeOf(path, "")
+	ctx, span := tracing.Start(ctx, method+" "+route,
+		slog.String("http.request.method", method),
+		slog.String("http.route", route),
+		slog.String("url.full", c.baseURL+endpoint))
+	span.SetKind(tracing.KindClient)
+	if c.dryRun != nil && method != "GET" {
+		span.SetAttributes(slog.Bool("moltgo.dry_run", true))
+	}
+	return ctx, span
+}
@@ -0,0 +1,285 @@
+// Package policy evaluates declarative engagement rules against the feed to
+// decide what the agent does during a heartbeat.
+package policy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/moltgo/moltgo/pkg/injection"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+)
+
+// Action types
+const (
+	ActionUpvote   = "upvote"
+	ActionDownvote = "downvote"
+	ActionComment  = "comment"
+)
+
+// Policy is the engagement policy loaded from policy.toml
+type Policy struct {
+	// Agents whose posts match rules with from_following
+	Following []string `toml:"following"`
+
+	Feed Feed `toml:"feed"`
+
+	// Maximum actions of each type per heartbeat, keyed by action type.
+	// Action types without a budget are unlimited apart from rule limits.
+	Budgets map[string]int `toml:"budgets"`
+
+	Rules []Rule `toml:"rule"`
+}
+
+// Feed selects the posts the policy is evaluated against
+type Feed struct {
+	Sort     string   `toml:"sort"`
+	Limit    int      `toml:"limit"`
+	Submolts []string `toml:"submolts"`
+}
+
+// Rule matches posts and names the action to take on them. All conditions
+// that are set must hold.
+type Rule struct {
+	Name   string `toml:"name"`
+	Action string `toml:"action"`
+
+	Submolts       []string `toml:"submolts"`
+	Authors        []string `toml:"authors"`
+	ExcludeAuthors []string `toml:"exclude_authors"`
+	FromFollowing  bool     `toml:"from_following"`
+	Keywords       []string `toml:"keywords"`
+	MinScore       *int     `toml:"min_score"`
+	MaxScore       *int     `toml:"max_score"`
+	MinComments    *int     `toml:"min_comments"`
+	MaxComments    *int     `toml:"max_comments"`
+	MaxAge         string   `toml:"max_age"`
+
+	// Skip posts flagged as likely prompt injection
+	SkipFlagged bool `toml:"skip_flagged"`
+
+	// Maximum matches acted on per heartbeat; 0 means no rule limit
+	Max int `toml:"max"`
+
+	// Comment text for comment rules. {author}, {title} and {submolt} are
+	// replaced from the post. With Generate set the text is written by the
+	// configured language model instead.
+	Comment  string `toml:"comment"`
+	Generate bool   `toml:"generate"`
+
+	maxAge time.Duration
+}
+
+// Action is a planned action on a post
+type Action struct {
+	Rule string
+	Type string
+	Post moltbook.Post
+
+	// Comment text; empty with Generate set
+	Text     string
+	Generate bool
+}
+
+// Key identifies the action on its target, for remembering what was done
+func (a Action) Key() string {
+	return a.Type + ":" + a.Post.ID
+}
+
+// Load reads and validates a policy file
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+
+	var p Policy
+	md, err := toml.Decode(string(data), &p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("unknown policy setting %q", undecoded[0].String())
+	}
+
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks the policy for mistakes
+func (p *Policy) Validate() error {
+	for action := range p.Budgets {
+		if !validAction(action) {
+			return fmt.Errorf("budget for unknown action %q", action)
+		}
+	}
+
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+		if !validAction(r.Action) {
+			return fmt.Errorf("%s: unknown action %q (use upvote, downvote or comment)", r.Name, r.Action)
+		}
+		if r.Action == ActionComment && r.Comment == "" && !r.Generate {
+			return fmt.Errorf("%s: comment rules need comment text or generate = true", r.Name)
+		}
+		if r.MaxAge != "" {
+			d, err := time.ParseDuration(r.MaxAge)
+			if err != nil {
+				return fmt.Errorf("%s: invalid max_age: %w", r.Name, err)
+			}
+			r.maxAge = d
+		}
+	}
+	return nil
+}
+
+func validAction(action string) bool {
+	switch action {
+	case ActionUpvote, ActionDownvote, ActionComment:
+		return true
+	}
+	return false
+}
+
+// Env is the context a policy is evaluated in
+type Env struct {
+	// Self is our agent name; our own posts never match
+	Self string
+	Now  time.Time
+
+	// Done reports whether the action identified by key was already taken
+	// in an earlier run. It keeps a post from getting the same action twice,
+	// or a vote opposite to an earlier one.
+	Done func(key string) bool
+}
+
+// Evaluate returns the actions the policy takes on posts, honoring rule
+// limits and budgets. Rules are applied in order, and each post gets at most
+// one action of each type.
+func (p *Policy) Evaluate(posts []moltbook.Post, env Env) []Action {
+	var actions []Action
+	planned := make(map[string]bool)
+	spent := make(map[string]int)
+
+	for _, r := range p.Rules {
+		taken := 0
+		for _, post := range posts {
+			if r.Max > 0 && taken >= r.Max {
+				break
+			}
+			if budget, ok := p.Budgets[r.Action]; ok && spent[r.Action] >= budget {
+				break
+			}
+			// Agent names are case-insensitive
+			if strings.EqualFold(post.Author, env.Self) || !p.matches(&r, &post, env.Now) {
+				continue
+			}
+
+			a := Action{Rule: r.Name, Type: r.Action, Post: post, Generate: r.Generate}
+			if planned[a.Key()] || (env.Done != nil && env.Done(a.Key())) {
+				continue
+			}
+			// Never both upvote and downvote the same post, in this run or
+			// after an earlier one
+			if opposite := oppositeVote(r.Action); opposite != "" {
+				key := Action{Type: opposite, Post: post}.Key()
+				if planned[key] || (env.Done != nil && env.Done(key)) {
+					continue
+				}
+			}
+			if r.Action == ActionComment && !r.Generate {
+				a.Text = expand(r.Comment, &post)
+			}
+
+			actions = append(actions, a)
+			planned[a.Key()] = true
+			spent[r.Action]++
+			taken++
+		}
+	}
+	return actions
+}
+
+func (p *Policy) matches(r *Rule, post *moltbook.Post, now time.Time) bool {
+	if len(r.Submolts) > 0 && !containsFold(r.Submolts, post.Submolt) {
+		return false
+	}
+	if len(r.Authors) > 0 && !containsFold(r.Authors, post.Author) {
+		return false
+	}
+	if containsFold(r.ExcludeAuthors, post.Author) {
+		return false
+	}
+	if r.FromFollowing && !containsFold(p.Following, post.Author) {
+		return false
+	}
+	if len(r.Keywords) > 0 && !containsKeyword(post, r.Keywords) {
+		return false
+	}
+	if r.MinScore != nil && post.Score < *r.MinScore {
+		return false
+	}
+	if r.MaxScore != nil && post.Score > *r.MaxScore {
+		return false
+	}
+	if r.MinComments != nil && post.NumComments < *r.MinComments {
+		return false
+	}
+	if r.MaxComments != nil && post.NumComments > *r.MaxComments {
+		return false
+	}
+	if r.SkipFlagged && injection.AnalyzePost(post).Flagged() {
+		return false
+	}
+	if r.maxAge > 0 {
+		if post.CreatedAt.IsZero() || now.Sub(post.CreatedAt.Time) > r.maxAge {
+			return false
+		}
+	}
+	return true
+}
+
+func oppositeVote(action string) string {
+	switch action {
+	case ActionUpvote:
+		return ActionDownvote
+	case ActionDownvote:
+		return ActionUpvote
+	}
+	return ""
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimPrefix(item, "/"), s) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsKeyword(post *moltbook.Post, keywords []string) bool {
+	text := strings.ToLower(post.Title + " " + post.Content)
+	for _, k := range keywords {
+		if strings.Contains(text, strings.ToLower(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+func expand(template string, post *moltbook.Post) string {
+	return strings.NewReplacer(
+		"{author}", post.Author,
+		"{title}", post.Title,
+		"{submolt}", post.Submolt,
+	).Replace(template)
+}
@@ -0,0 +1,200 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// ANSI styles used by the renderer
+const (
+	Reset     = "\x1b[0m"
+	Bold      = "\x1b[1m"
+	Dim       = "\x1b[2m"
+	Italic    = "\x1b[3m"
+	Underline = "\x1b[4m"
+	Reverse   = "\x1b[7m"
+	Cyan      = "\x1b[36m"
+	Yellow    = "\x1b[33m"
+)
+
+// Renderer formats text for a terminal of a given width
+type Renderer struct {
+	Width int
+	Color bool
+}
+
+// New returns a Renderer configured for the terminal attached to w. Writers
+// that are not files get plain text at the default width.
+func New(w io.Writer) *Renderer {
+	f, ok := w.(*os.File)
+	if !ok {
+		return &Renderer{Width: columns()}
+	}
+	return &Renderer{
+		Width: TerminalWidth(f),
+		Color: ColorEnabled(f),
+	}
+}
+
+// Style wraps s in the given ANSI styles when color is enabled
+func (r *Renderer) Style(s string, styles ...string) string {
+	if !r.Color || len(styles) == 0 || s == "" {
+		return s
+	}
+	return strings.Join(styles, "") + s + Reset
+}
+
+var (
+	headingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	bulletRe   = regexp.MustCompile(`^(\s*)[-*+]\s+(.*)$`)
+	orderedRe  = regexp.MustCompile(`^(\s*)(\d+[.)])\s+(.*)$`)
+	ruleRe     = regexp.MustCompile(`^(?:(?:-\s*){3,}|(?:\*\s*){3,}|(?:_\s*){3,})$`)
+	fenceRe    = regexp.MustCompile("^\\s*(```|~~~)")
+	codeSpanRe = regexp.MustCompile("`([^`]+)`")
+	linkRe     = regexp.MustCompile(`!?\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	boldRe     = regexp.MustCompile(`\*\*([^*]+)\*\*|__([^_]+)__`)
+	italicRe   = regexp.MustCompile(`(^|[^*\w])\*([^*\s][^*]*)\*|(^|[^_\w])_([^_\s][^_]*)_`)
+)
+
+// Markdown renders Markdown source for the terminal, indenting every line
+// with indent. Headings, emphasis, inline code, code blocks, lists,
+// blockquotes, rules and links are supported; anything else is shown as
+// wrapped text. Control characters in src are removed first.
+func (r *Renderer) Markdown(src, indent string) string {
+	var out []string
+	var para []string
+
+	flushPara := func() {
+		if len(para) == 0 {
+			return
+		}
+		text := r.inline(strings.Join(para, " "))
+		out = append(out, Wrap(text, r.Width, indent)...)
+		para = nil
+	}
+
+	lines := strings.Split(Sanitize(strings.ReplaceAll(src, "\r\n", "\n")), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if m := fenceRe.FindStringSubmatch(line); m != nil {
+			flushPara()
+			fence := m[1]
+			for i++; i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), fence); i++ {
+				code := strings.ReplaceAll(lines[i], "\t", "    ")
+				out = append(out, indent+"    "+r.Style(Truncate(code, r.Width-Width(indent)-4), Cyan))
+			}
+			continue
+		}
+
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "":
+			flushPara()
+			if len(out) > 0 && out[len(out)-1] != indent {
+				out = append(out, indent)
+			}
+
+		case headingRe.MatchString(trimmed):
+			flushPara()
+			m := headingRe.FindStringSubmatch(trimmed)
+			styles := []string{Bold}
+			if len(m[1]) == 1 {
+				styles = append(styles, Underline)
+			}
+			for _, l := range Wrap(r.inline(m[2]), r.Width, "") {
+				out = append(out, indent+r.Style(l, styles...))
+			}
+
+		case ruleRe.MatchString(trimmed):
+			flushPara()
+			n := r.Width - Width(indent)
+			if n > 40 {
+				n = 40
+			}
+			out = append(out, indent+r.Style(strings.Repeat("─", n), Dim))
+
+		case strings.HasPrefix(trimmed, ">"):
+			flushPara()
+			quote := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+			out = append(out, Wrap(r.inline(quote), r.Width, indent+r.Style("│", Dim)+" ")...)
+
+		case bulletRe.MatchString(line):
+			flushPara()
+			m := bulletRe.FindStringSubmatch(line)
+			out = append(out, r.listItem(indent+listIndent(m[1]), "• ", m[2])...)
+
+		case orderedRe.MatchString(line):
+			flushPara()
+			m := orderedRe.FindStringSubmatch(line)
+			out = append(out, r.listItem(indent+listIndent(m[1]), m[2]+" ", m[3])...)
+
+		case strings.HasPrefix(line, "    ") || strings.HasPrefix(line, "\t"):
+			// Indented code block
+			flushPara()
+			code := strings.ReplaceAll(line, "\t", "    ")
+			out = append(out, indent+r.Style(Truncate(code, r.Width-Width(indent)), Cyan))
+
+		default:
+			para = append(para, trimmed)
+		}
+	}
+	flushPara()
+
+	// Drop trailing blank lines
+	for len(out) > 0 && strings.TrimSpace(out[len(out)-1]) == "" {
+		out = out[:len(out)-1]
+	}
+	return strings.Join(out, "\n")
+}
+
+func listIndent(leading string) string {
+	return strings.Repeat(" ", len(strings.ReplaceAll(leading, "\t", "    ")))
+}
+
+// listItem wraps a list item with a hanging indent under its marker
+func (r *Renderer) listItem(indent, marker, text string) []string {
+	hang := indent + strings.Repeat(" ", Width(marker))
+	lines := Wrap(r.inline(text), r.Width, hang)
+	if len(lines) > 0 {
+		lines[0] = indent + marker + strings.TrimPrefix(lines[0], hang)
+	}
+	return lines
+}
+
+// inline renders emphasis, inline code and links within a line of text
+func (r *Renderer) inline(s string) string {
+	// Protect code spans from further formatting
+	var spans []string
+	s = codeSpanRe.ReplaceAllStringFunc(s, func(m string) string {
+		spans = append(spans, codeSpanRe.FindStringSubmatch(m)[1])
+		return fmt.Sprintf("\x00%d\x00", len(spans)-1)
+	})
+
+	s = linkRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := linkRe.FindStringSubmatch(m)
+		text, url := sub[1], sub[2]
+		if text == "" || text == url {
+			return r.Style(url, Underline)
+		}
+		return r.Style(text, Underline) + " " + r.Style("("+url+")", Dim)
+	})
+
+	s = boldRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := boldRe.FindStringSubmatch(m)
+		return r.Style(sub[1]+sub[2], Bold)
+	})
+
+	s = italicRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := italicRe.FindStringSubmatch(m)
+		return sub[1] + sub[3] + r.Style(sub[2]+sub[4], Italic)
+	})
+
+	for i, span := range spans {
+		s = strings.Replace(s, fmt.Sprintf("\x00%d\x00", i), r.Style(span, Cyan), 1)
+	}
+	return s
+}
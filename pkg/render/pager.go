@@ -0,0 +1,47 @@
+package render
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Page shows content through $PAGER (default "less") when out is a
+// terminal, and writes it to out directly otherwise or if the pager cannot
+// be started
+func Page(content string, out io.Writer) error {
+	if f, ok := out.(*os.File); !ok || !IsTerminal(f) {
+		_, err := fmt.Fprint(out, content)
+		return err
+	}
+
+	pager := strings.TrimSpace(os.Getenv("PAGER"))
+	if pager == "" {
+		pager = "less"
+	}
+
+	cmd := exec.Command("sh", "-c", pager)
+	cmd.Stdin = strings.NewReader(content)
+	cmd.Stdout = out
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+	if os.Getenv("LESS") == "" {
+		// Keep colors, and exit immediately if the content fits on screen
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+
+	err := cmd.Run()
+	if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() != 127 {
+		// The pager ran; a non-zero exit is how some pagers report being
+		// quit early
+		return nil
+	}
+	if err != nil {
+		// The pager could not be found or started
+		_, err := fmt.Fprint(out, content)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+// Package render formats post content for the terminal: width-aware
+// wrapping and truncation, Markdown rendering with ANSI styling, and paging.
+package render
+
+import (
+	"os"
+	"strconv"
+
+	"golang.org/x/term"
+)
+
+// DefaultWidth is used when the terminal width cannot be determined
+const DefaultWidth = 80
+
+// IsTerminal reports whether f is an interactive terminal
+func IsTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// TerminalWidth returns the width of the terminal attached to f, falling
+// back to $COLUMNS and then DefaultWidth
+func TerminalWidth(f *os.File) int {
+	if width, _, err := term.GetSize(int(f.Fd())); err == nil && width > 0 {
+		return width
+	}
+	return columns()
+}
+
+// columns returns $COLUMNS, or DefaultWidth when it is not set
+func columns() int {
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	return DefaultWidth
+}
+
+// ColorEnabled reports whether ANSI styling should be written to f. Color is
+// disabled when NO_COLOR is set, TERM is "dumb", or f is not a terminal.
+func ColorEnabled(f *os.File) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	if os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(f)
+}
@@ -0,0 +1,404 @@
+package render
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const ellipsis = "…"
+
+const (
+	zeroWidthJoiner = '‍'
+	keycapCombiner  = '⃣'
+)
+
+// Graphemes splits s into user-perceived characters. A cluster is a base
+// rune followed by any combining marks, variation selectors, emoji modifiers
+// and zero-width-joiner sequences. Regional indicator pairs (flags) and ANSI
+// escape sequences are kept together as a single cluster.
+func Graphemes(s string) []string {
+	var clusters []string
+	for len(s) > 0 {
+		n := nextCluster(s)
+		clusters = append(clusters, s[:n])
+		s = s[n:]
+	}
+	return clusters
+}
+
+// nextCluster returns the byte length of the first grapheme cluster in s
+func nextCluster(s string) int {
+	if n := escapeLen(s); n > 0 {
+		return n
+	}
+
+	r, size := utf8.DecodeRuneInString(s)
+	i := size
+
+	if r == '\r' && i < len(s) && s[i] == '\n' {
+		return i + 1
+	}
+
+	if isRegionalIndicator(r) {
+		if next, n := utf8.DecodeRuneInString(s[i:]); isRegionalIndicator(next) {
+			return i + n
+		}
+		return i
+	}
+
+	for i < len(s) {
+		next, n := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case isExtender(next):
+			i += n
+		case next == zeroWidthJoiner:
+			i += n
+			if i < len(s) {
+				_, n = utf8.DecodeRuneInString(s[i:])
+				i += n
+			}
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1f1e6 && r <= 0x1f1ff
+}
+
+// isExtender reports whether r attaches to the preceding rune
+func isExtender(r rune) bool {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true
+	case r >= 0xfe00 && r <= 0xfe0f: // variation selectors
+		return true
+	case r >= 0x1f3fb && r <= 0x1f3ff: // emoji skin tone modifiers
+		return true
+	case r >= 0xe0020 && r <= 0xe007f: // emoji tag sequences
+		return true
+	case r == keycapCombiner:
+		return true
+	}
+	return false
+}
+
+// escapeLen returns the length of an ANSI escape sequence at the start of
+// s, or 0 if s does not start with one
+func escapeLen(s string) int {
+	if len(s) < 2 || s[0] != 0x1b {
+		return 0
+	}
+	switch s[1] {
+	case '[':
+		for i := 2; i < len(s); i++ {
+			if s[i] >= 0x40 && s[i] <= 0x7e {
+				return i + 1
+			}
+		}
+	case ']':
+		// OSC sequences end with BEL or ST
+		for i := 2; i < len(s); i++ {
+			if s[i] == 0x07 {
+				return i + 1
+			}
+			if s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\' {
+				return i + 2
+			}
+		}
+	}
+	return 0
+}
+
+// clusterWidth returns the number of terminal columns a cluster occupies
+func clusterWidth(c string) int {
+	if escapeLen(c) > 0 {
+		return 0
+	}
+	r, _ := utf8.DecodeRuneInString(c)
+	switch {
+	case r == '\t':
+		return 4
+	case r < 0x20 || r == 0x7f:
+		return 0
+	case isExtender(r) || r == zeroWidthJoiner:
+		return 0
+	case isRegionalIndicator(r), isWide(r):
+		return 2
+	case strings.ContainsRune(c, '️'):
+		// Emoji presentation selector
+		return 2
+	}
+	return 1
+}
+
+// isWide reports whether r is rendered two columns wide (East Asian wide
+// and fullwidth characters, and emoji)
+func isWide(r rune) bool {
+	switch {
+	case r < 0x1100:
+		return false
+	case r <= 0x115f, // Hangul Jamo
+		r >= 0x2e80 && r <= 0x303e, // CJK radicals, punctuation
+		r >= 0x3041 && r <= 0x33ff, // Kana, CJK symbols
+		r >= 0x3400 && r <= 0x4dbf, // CJK extension A
+		r >= 0x4e00 && r <= 0x9fff, // CJK unified ideographs
+		r >= 0xa000 && r <= 0xa4cf, // Yi
+		r >= 0xac00 && r <= 0xd7a3, // Hangul syllables
+		r >= 0xf900 && r <= 0xfaff, // CJK compatibility ideographs
+		r >= 0xfe30 && r <= 0xfe4f, // CJK compatibility forms
+		r >= 0xff00 && r <= 0xff60, // Fullwidth forms
+		r >= 0xffe0 && r <= 0xffe6,
+		r >= 0x1f300 && r <= 0x1f64f, // Emoji and pictographs
+		r >= 0x1f900 && r <= 0x1f9ff,
+		r >= 0x1fa70 && r <= 0x1faff,
+		r >= 0x20000 && r <= 0x3fffd: // CJK extensions B and beyond
+		return true
+	}
+	return false
+}
+
+// Width returns the number of terminal columns s occupies. ANSI escape
+// sequences take no space.
+func Width(s string) int {
+	w := 0
+	for len(s) > 0 {
+		n := nextCluster(s)
+		w += clusterWidth(s[:n])
+		s = s[n:]
+	}
+	return w
+}
+
+// Truncate shortens s to at most width columns, cutting only on grapheme
+// boundaries and marking the cut with an ellipsis. Escape sequences past
+// the cut are kept so styling is still reset.
+func Truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if Width(s) <= width {
+		return s
+	}
+
+	var b strings.Builder
+	used := 0
+	limit := width - 1 // room for the ellipsis
+	cut := false
+	for len(s) > 0 {
+		n := nextCluster(s)
+		c := s[:n]
+		s = s[n:]
+		if escapeLen(c) > 0 {
+			b.WriteString(c)
+			continue
+		}
+		if cut {
+			continue
+		}
+		w := clusterWidth(c)
+		if used+w > limit {
+			b.WriteString(ellipsis)
+			cut = true
+			continue
+		}
+		b.WriteString(c)
+		used += w
+	}
+	return b.String()
+}
+
+// Pad truncates or right-pads s with spaces to exactly width columns
+func Pad(s string, width int) string {
+	s = Truncate(s, width)
+	if w := Width(s); w < width {
+		s += strings.Repeat(" ", width-w)
+	}
+	return s
+}
+
+// Wrap breaks text into lines of at most width columns, prefixing each
+// line. Words longer than a line are split on grapheme boundaries. Blank
+// input lines are preserved.
+func Wrap(text string, width int, prefix string) []string {
+	avail := width - Width(prefix)
+	if avail < 10 {
+		avail = 10
+	}
+
+	var lines []string
+	for _, para := range strings.Split(text, "\n") {
+		words := strings.Fields(para)
+		if len(words) == 0 {
+			lines = append(lines, prefix)
+			continue
+		}
+
+		line, lineWidth := "", 0
+		flush := func() {
+			lines = append(lines, prefix+line)
+			line, lineWidth = "", 0
+		}
+		for _, word := range words {
+			ww := Width(word)
+			for ww > avail {
+				if line != "" {
+					flush()
+				}
+				head, rest := splitWidth(word, avail)
+				lines = append(lines, prefix+head)
+				word, ww = rest, Width(rest)
+			}
+			switch {
+			case line == "":
+				line, lineWidth = word, ww
+			case lineWidth+1+ww <= avail:
+				line += " " + word
+				lineWidth += 1 + ww
+			default:
+				flush()
+				line, lineWidth = word, ww
+			}
+		}
+		if line != "" {
+			flush()
+		}
+	}
+	return lines
+}
+
+// splitWidth splits s after at most width columns on a grapheme boundary
+func splitWidth(s string, width int) (string, string) {
+	used, i := 0, 0
+	for i < len(s) {
+		n := nextCluster(s[i:])
+		w := clusterWidth(s[i : i+n])
+		if used+w > width && used > 0 {
+			break
+		}
+		used += w
+		i += n
+	}
+	return s[:i], s[i:]
+}
+
+// Preview collapses whitespace in s and truncates it to width columns,
+// for one-line summaries of post content
+func Preview(s string, width int) string {
+	return Truncate(strings.Join(strings.Fields(Sanitize(s)), " "), width)
+}
+
+// Sanitize removes escape sequences and control characters from text that
+// came from Moltbook, so remote text cannot move the cursor, retitle the
+// window or restyle the terminal, and the only escape sequences written are
+// those the renderer adds itself. Whole CSI sequences (ESC [ ... final
+// byte) and string sequences such as OSC (ESC ] ... BEL or ST) are removed,
+// in both their 7-bit and 8-bit C1 forms, along with C0 controls other than
+// newline and tab, DEL, other C1 controls and the bidirectional overrides
+// that can make text display in a different order than it reads. Other
+// invalid UTF-8 becomes U+FFFD.
+func Sanitize(s string) string {
+	if utf8.ValidString(s) && !strings.ContainsFunc(s, isControl) {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, n := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && n == 1 && s[i] >= 0x80 && s[i] <= 0x9f {
+			// A raw C1 byte, as 8-bit terminals read it
+			r = rune(s[i])
+		}
+		switch {
+		case r == 0x1b:
+			i += n + sequenceLen(s[i+n:])
+		case r == 0x9b:
+			i += n + csiLen(s[i+n:])
+		case r == 0x90, r == 0x98, r == 0x9d, r == 0x9e, r == 0x9f:
+			i += n + stringLen(s[i+n:])
+		case isControl(r):
+			i += n
+		default:
+			b.WriteRune(r)
+			i += n
+		}
+	}
+	return b.String()
+}
+
+// sequenceLen returns the length of the escape sequence following an ESC
+// at the start of s
+func sequenceLen(s string) int {
+	if s == "" {
+		return 0
+	}
+	switch s[0] {
+	case '[':
+		return 1 + csiLen(s[1:])
+	case ']', 'P', 'X', '^', '_':
+		return 1 + stringLen(s[1:])
+	}
+	// Other sequences are intermediate bytes and a final byte, such as
+	// ESC ( B or ESC c
+	i := 0
+	for i < len(s) && s[i] >= 0x20 && s[i] <= 0x2f {
+		i++
+	}
+	if i < len(s) && s[i] >= 0x30 && s[i] <= 0x7e {
+		i++
+	}
+	return i
+}
+
+// csiLen returns the length of a control sequence's parameter and
+// intermediate bytes and final byte at the start of s. A sequence cut short
+// by any other byte ends before it.
+func csiLen(s string) int {
+	i := 0
+	for i < len(s) && s[i] >= 0x20 && s[i] <= 0x3f {
+		i++
+	}
+	if i < len(s) && s[i] >= 0x40 && s[i] <= 0x7e {
+		i++
+	}
+	return i
+}
+
+// stringLen returns the length of a control string at the start of s, up
+// to and including the BEL or string terminator (ESC \ or C1 ST) that ends
+// it. An unterminated string runs to the end of s, as a terminal would
+// swallow it.
+func stringLen(s string) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == 0x07:
+			return i + 1
+		case s[i] == 0x1b && i+1 < len(s) && s[i+1] == '\\':
+			return i + 2
+		case s[i] == 0x9c:
+			return i + 1
+		case s[i] == 0xc2 && i+1 < len(s) && s[i+1] == 0x9c:
+			return i + 2
+		}
+	}
+	return len(s)
+}
+
+// isControl reports whether r is a control character or bidirectional
+// override Sanitize removes
+func isControl(r rune) bool {
+	switch {
+	case r == '\n', r == '\t':
+		return false
+	case r < 0x20, r == 0x7f:
+		return true
+	case r >= 0x80 && r <= 0x9f:
+		return true
+	case r >= 0x202a && r <= 0x202e, r >= 0x2066 && r <= 0x2069:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package render
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"plain", "Hello, world", "Hello, world"},
+		{"newlines and tabs", "one\n\ttwo", "one\n\ttwo"},
+		{"unicode", "héllo 👋🏽 日本", "héllo 👋🏽 日本"},
+
+		{"CSI clear screen", "evil\x1b[2J", "evil"},
+		{"CSI colour", "\x1b[1;31mred\x1b[0m text", "red text"},
+		{"CSI private mode", "a\x1b[?25lb", "ab"},
+		{"CSI cut short", "a\x1b[31\nb", "a\nb"},
+		{"8-bit CSI byte", "Body\x9b31m", "Body"},
+		{"8-bit CSI rune", "Body\u009b31mtext", "Bodytext"},
+
+		{"OSC title with BEL", "Clean\x1b]0;owned\x07 title", "Clean title"},
+		{"OSC title with ST", "Clean\x1b]2;owned\x1b\\ title", "Clean title"},
+		{"OSC hyperlink", "\x1b]8;;https://evil.example\x07click\x1b]8;;\x07", "click"},
+		{"OSC unterminated", "safe\x1b]0;rest of text", "safe"},
+		{"8-bit OSC with C1 ST", "a\u009d0;owned\u009cb", "ab"},
+		{"DCS", "a\x1bPq#0;2;0;0;0\x1b\\b", "ab"},
+
+		{"charset designation", "a\x1b(Bb", "ab"},
+		{"reset", "a\x1bcb", "ab"},
+		{"lone ESC", "trailing\x1b", "trailing"},
+
+		{"C0 controls", "bell\x07 back\x08space\rreturn", "bell backspacereturn"},
+		{"DEL", "del\x7fete", "delete"},
+		{"C1 controls", "next\u0085line", "nextline"},
+		{"raw C1 byte", "a\x85b", "ab"},
+		{"invalid UTF-8", "a\xffb", "a�b"},
+
+		{"bidi override", "access\u202e}]'resu'[ = level", "access}]'resu'[ = level"},
+		{"bidi isolates", "\u2067admin\u2069 ok", "admin ok"},
+		{"bidi marks kept", "abc\u200fdef", "abc\u200fdef"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sanitize(tt.in); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviewSanitizes(t *testing.T) {
+	if got := Preview("first\x1b[2J line\n\nsecond   line", 80); got != "first line second line" {
+		t.Errorf("Preview = %q", got)
+	}
+}
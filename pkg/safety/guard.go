@@ -0,0 +1,74 @@
+package safety
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Guard applies a Filter to outbound content: it reports warnings to Out,
+// redacts, and refuses content with blocking findings. With Force set,
+// nothing is blocked or redacted and every finding is only reported. Guard
+// implements moltbook.ContentFilter.
+type Guard struct {
+	Filter *Filter
+	Force  bool
+	Out    io.Writer
+}
+
+// BlockedError reports content the filter refused to send
+type BlockedError struct {
+	Field    string
+	Findings []Finding
+}
+
+func (e *BlockedError) Error() string {
+	var found []string
+	for _, f := range e.Findings {
+		found = append(found, fmt.Sprintf("%s %s", f.Description(), f.Masked()))
+	}
+	return fmt.Sprintf("%s blocked by the safety filter: %s (use --force to send anyway)", e.Field, strings.Join(found, ", "))
+}
+
+// FilterContent checks one field of outbound content and returns the text
+// to send
+func (g *Guard) FilterContent(field, text string) (string, error) {
+	findings := g.Filter.Scan(text)
+	if len(findings) == 0 {
+		return text, nil
+	}
+
+	if g.Force {
+		for _, f := range findings {
+			g.report(field, f, "sending anyway (--force)")
+		}
+		return text, nil
+	}
+
+	var blocked []Finding
+	for _, f := range findings {
+		switch f.Mode {
+		case ModeBlock:
+			blocked = append(blocked, f)
+		case ModeRedact:
+			g.report(field, f, "redacted")
+		case ModeWarn:
+			g.report(field, f, "")
+		}
+	}
+	if len(blocked) > 0 {
+		return "", &BlockedError{Field: field, Findings: blocked}
+	}
+	return Redact(text, findings), nil
+}
+
+func (g *Guard) report(field string, f Finding, action string) {
+	if g.Out == nil {
+		return
+	}
+	msg := fmt.Sprintf("Warning: %s contains %s %s", field, f.Description(), f.Masked())
+	if action != "" {
+		msg += "; " + action
+	}
+	fmt.Fprintln(g.Out, msg)
+}
@@ -0,0 +1,284 @@
+// Package safety scans outbound content for secrets, personal data and
+// denylisted terms before it is published.
+package safety
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Modes decide what happens to content a rule matches
+const (
+	ModeBlock  = "block"
+	ModeRedact = "redact"
+	ModeWarn   = "warn"
+	ModeOff    = "off"
+)
+
+// Rule names. The denylist rule matches the configured terms; the others are
+// built-in detectors.
+const (
+	RuleMoltbookKey = "moltbook_key"
+	RuleAPIKey      = "api_key"
+	RulePrivateKey  = "private_key"
+	RuleHighEntropy = "high_entropy"
+	RuleEmail       = "email"
+	RuleIP          = "ip"
+	RuleDenylist    = "denylist"
+)
+
+// DefaultModes are the modes used for rules that are not configured
+var DefaultModes = map[string]string{
+	RuleMoltbookKey: ModeBlock,
+	RuleAPIKey:      ModeBlock,
+	RulePrivateKey:  ModeBlock,
+	RuleHighEntropy: ModeWarn,
+	RuleEmail:       ModeRedact,
+	RuleIP:          ModeWarn,
+	RuleDenylist:    ModeBlock,
+}
+
+// Descriptions of what each rule detects, for messages
+var descriptions = map[string]string{
+	RuleMoltbookKey: "Moltbook API key",
+	RuleAPIKey:      "API key or token",
+	RulePrivateKey:  "private key",
+	RuleHighEntropy: "possible secret (high-entropy string)",
+	RuleEmail:       "email address",
+	RuleIP:          "IP address",
+	RuleDenylist:    "denylisted term",
+}
+
+// Entropy threshold for the high-entropy detector, in bits per character
+const (
+	minEntropyLength = 24
+	minEntropy       = 4.0
+)
+
+var (
+	moltbookKeyRe = regexp.MustCompile(`moltbook_sk_[A-Za-z0-9_\-]{8,}`)
+	apiKeyRe      = regexp.MustCompile(`\b(?:` + strings.Join([]string{
+		`sk-(?:proj-|ant-)?[A-Za-z0-9_\-]{20,}`, // OpenAI, Anthropic
+		`gh[pousr]_[A-Za-z0-9]{36,}`,            // GitHub
+		`github_pat_[A-Za-z0-9_]{22,}`,
+		`glpat-[A-Za-z0-9_\-]{20,}`,                                        // GitLab
+		`xox[abprs]-[A-Za-z0-9\-]{10,}`,                                    // Slack
+		`AKIA[0-9A-Z]{16}`,                                                 // AWS access key
+		`AIza[0-9A-Za-z_\-]{35}`,                                           // Google
+		`eyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}`, // JWT
+	}, "|") + `)`)
+	privateKeyRe = regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----(?s:.*?)(?:-----END [A-Z ]*PRIVATE KEY-----|\z)`)
+	tokenRe      = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{24,}`)
+	emailRe      = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9\-]+(?:\.[A-Za-z0-9\-]+)*\.[A-Za-z]{2,}`)
+	ipv4Re       = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	ipv6Re       = regexp.MustCompile(`(?i)\b(?:[0-9a-f]{0,4}:){2,7}[0-9a-f]{0,4}\b`)
+)
+
+// Finding is a match of a rule in scanned text
+type Finding struct {
+	Rule  string
+	Mode  string
+	Match string
+
+	start, end int
+}
+
+// Description says what the finding is
+func (f Finding) Description() string {
+	return descriptions[f.Rule]
+}
+
+// Masked stands in for the match in messages: it names the rule and the
+// match's length but shows none of the match, since even a prefix of a
+// secret can help to guess it
+func (f Finding) Masked() string {
+	return fmt.Sprintf("[%s, %d characters]", f.Rule, utf8.RuneCountInString(f.Match))
+}
+
+type detector struct {
+	rule string
+	mode string
+	find func(text string) [][]int
+}
+
+// Filter scans text with the built-in detectors and the denylist
+type Filter struct {
+	detectors []detector
+}
+
+// New creates a filter. modes overrides DefaultModes by rule name, and
+// denylist lists terms matched case-insensitively.
+func New(modes map[string]string, denylist []string) (*Filter, error) {
+	for rule, mode := range modes {
+		if _, ok := DefaultModes[rule]; !ok {
+			return nil, fmt.Errorf("unknown safety rule %q", rule)
+		}
+		switch mode {
+		case ModeBlock, ModeRedact, ModeWarn, ModeOff:
+		default:
+			return nil, fmt.Errorf("invalid mode %q for safety rule %s (use block, redact, warn or off)", mode, rule)
+		}
+	}
+
+	f := &Filter{}
+	add := func(rule string, find func(string) [][]int) {
+		mode := DefaultModes[rule]
+		if m, ok := modes[rule]; ok {
+			mode = m
+		}
+		if mode != ModeOff {
+			f.detectors = append(f.detectors, detector{rule: rule, mode: mode, find: find})
+		}
+	}
+
+	// More specific detectors come first so they claim their matches
+	if re := denylistRegexp(denylist); re != nil {
+		add(RuleDenylist, findAll(re))
+	}
+	add(RulePrivateKey, findAll(privateKeyRe))
+	add(RuleMoltbookKey, findAll(moltbookKeyRe))
+	add(RuleAPIKey, findAll(apiKeyRe))
+	add(RuleEmail, findAll(emailRe))
+	add(RuleIP, findIPs)
+	add(RuleHighEntropy, findHighEntropy)
+	return f, nil
+}
+
+func findAll(re *regexp.Regexp) func(string) [][]int {
+	return func(text string) [][]int {
+		return re.FindAllStringIndex(text, -1)
+	}
+}
+
+// denylistRegexp matches any of the terms, on word boundaries where the
+// term begins or ends with a word character
+func denylistRegexp(terms []string) *regexp.Regexp {
+	var alts []string
+	for _, term := range terms {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		alt := regexp.QuoteMeta(term)
+		if isWordByte(term[0]) {
+			alt = `\b` + alt
+		}
+		if isWordByte(term[len(term)-1]) {
+			alt += `\b`
+		}
+		alts = append(alts, alt)
+	}
+	if len(alts) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)(?:` + strings.Join(alts, "|") + `)`)
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+func findIPs(text string) [][]int {
+	var found [][]int
+	for _, re := range []*regexp.Regexp{ipv4Re, ipv6Re} {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			if net.ParseIP(text[loc[0]:loc[1]]) != nil {
+				found = append(found, loc)
+			}
+		}
+	}
+	return found
+}
+
+func findHighEntropy(text string) [][]int {
+	var found [][]int
+	for _, loc := range tokenRe.FindAllStringIndex(text, -1) {
+		token := text[loc[0]:loc[1]]
+		if hasLetterAndDigit(token) && entropy(token) >= minEntropy {
+			found = append(found, loc)
+		}
+	}
+	return found
+}
+
+func hasLetterAndDigit(s string) bool {
+	var letter, digit bool
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digit = true
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			letter = true
+		}
+	}
+	return letter && digit
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := make(map[rune]int)
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var h float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// Scan returns the findings in text in order of appearance. Where matches
+// overlap, the more specific detector wins.
+func (f *Filter) Scan(text string) []Finding {
+	var findings []Finding
+	overlaps := func(start, end int) bool {
+		for _, prev := range findings {
+			if start < prev.end && end > prev.start {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, d := range f.detectors {
+		for _, loc := range d.find(text) {
+			if overlaps(loc[0], loc[1]) {
+				continue
+			}
+			findings = append(findings, Finding{
+				Rule:  d.rule,
+				Mode:  d.mode,
+				Match: text[loc[0]:loc[1]],
+				start: loc[0],
+				end:   loc[1],
+			})
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool { return findings[i].start < findings[j].start })
+	return findings
+}
+
+// Redact replaces the matches of findings in redact mode in text
+func Redact(text string, findings []Finding) string {
+	var b strings.Builder
+	last := 0
+	for _, f := range findings {
+		if f.Mode != ModeRedact || f.start < last {
+			continue
+		}
+		b.WriteString(text[last:f.start])
+		b.WriteString("[redacted " + strings.ReplaceAll(f.Rule, "_", " ") + "]")
+		last = f.end
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
@@ -0,0 +1,147 @@
+// Package scheduler runs periodic jobs with jitter for the agent daemon.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Job is a task run on an interval
+type Job struct {
+	Name string
+
+	// Interval between runs. Each wait is randomly lengthened or shortened
+	// by up to Jitter.
+	Interval time.Duration
+	Jitter   time.Duration
+
+	// Delay before the first run
+	Delay time.Duration
+
+	Run func(ctx context.Context) error
+}
+
+// Scheduler runs jobs until it is stopped. Jobs never run concurrently
+// with each other, so they can share a client and state files.
+type Scheduler struct {
+	jobs   []Job
+	logger *slog.Logger
+
+	runMu  sync.Mutex
+	randMu sync.Mutex
+	rand   *rand.Rand
+
+	nextMu   sync.Mutex
+	nextRuns map[string]time.Time
+}
+
+// New creates a Scheduler that reports job outcomes to logger
+func New(logger *slog.Logger) *Scheduler {
+	return &Scheduler{
+		logger:   logger,
+		rand:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		nextRuns: make(map[string]time.Time),
+	}
+}
+
+// NextRun returns when the named job is due to run next. It stays
+// available after Run returns, so a scheduler replacing this one can carry
+// the jobs' cadence over.
+func (s *Scheduler) NextRun(name string) (time.Time, bool) {
+	s.nextMu.Lock()
+	defer s.nextMu.Unlock()
+	t, ok := s.nextRuns[name]
+	return t, ok
+}
+
+func (s *Scheduler) setNextRun(name string, t time.Time) {
+	s.nextMu.Lock()
+	defer s.nextMu.Unlock()
+	s.nextRuns[name] = t
+}
+
+// Add registers a job. Jobs must be added before Run is called.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Run starts all jobs and blocks until stop is cancelled and any running
+// job has returned. Cancelling stop only ends the scheduling of new runs;
+// jobs run with jobCtx, which aborts a running job when it is cancelled.
+func (s *Scheduler) Run(stop, jobCtx context.Context) {
+	var wg sync.WaitGroup
+	for _, job := range s.jobs {
+		wg.Add(1)
+		go func(job Job) {
+			defer wg.Done()
+			s.loop(stop, jobCtx, job)
+		}(job)
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(stop, jobCtx context.Context, job Job) {
+	wait := job.Delay
+	s.setNextRun(job.Name, time.Now().Add(wait))
+	for {
+		if wait > 0 {
+			s.logger.Info("job scheduled", "job", job.Name, "next_run", time.Now().Add(wait).Format("2006-01-02 15:04:05"))
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-stop.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		// The next run is recorded even when stopping, so a job that has
+		// just run is not due again at once in a replacing scheduler
+		if !s.run(stop, jobCtx, job) {
+			return
+		}
+		wait = s.next(job)
+		s.setNextRun(job.Name, time.Now().Add(wait))
+		if stop.Err() != nil {
+			return
+		}
+	}
+}
+
+// run runs the job and reports whether it was started
+func (s *Scheduler) run(stop, jobCtx context.Context, job Job) bool {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	// Shutdown may have been requested while waiting for another job
+	if stop.Err() != nil || jobCtx.Err() != nil {
+		return false
+	}
+
+	start := time.Now()
+	s.logger.Info("job started", "job", job.Name)
+	if err := job.Run(jobCtx); err != nil {
+		s.logger.Error("job failed", "job", job.Name, "duration", time.Since(start).Round(time.Millisecond), "error", err)
+		return true
+	}
+	s.logger.Info("job finished", "job", job.Name, "duration", time.Since(start).Round(time.Millisecond))
+	return true
+}
+
+// next returns the wait before the job's next run
+func (s *Scheduler) next(job Job) time.Duration {
+	wait := job.Interval
+	if job.Jitter > 0 {
+		s.randMu.Lock()
+		offset := time.Duration(s.rand.Int63n(int64(2*job.Jitter))) - job.Jitter
+		s.randMu.Unlock()
+		wait += offset
+	}
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return wait
+}
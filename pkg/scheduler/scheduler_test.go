@@ -0,0 +1,59 @@
+package scheduler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestNextRunOutlivesRun(t *testing.T) {
+	s := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	ran := make(chan struct{}, 1)
+	s.Add(Job{Name: "now", Interval: time.Hour, Run: func(context.Context) error {
+		ran <- struct{}{}
+		return nil
+	}})
+	s.Add(Job{Name: "later", Interval: time.Hour, Delay: 30 * time.Minute, Run: func(context.Context) error {
+		t.Error("delayed job ran")
+		return nil
+	}})
+
+	stop, stopScheduling := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(stop, context.Background())
+		close(done)
+	}()
+	<-ran
+	// Let the job's next run be recorded
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(time.Millisecond) {
+		if next, _ := s.NextRun("now"); time.Until(next) > time.Minute {
+			break
+		}
+	}
+	stopScheduling()
+	<-done
+
+	tests := []struct {
+		name string
+		want time.Duration
+	}{
+		{"now", time.Hour},
+		{"later", 30 * time.Minute},
+	}
+	for _, tt := range tests {
+		next, ok := s.NextRun(tt.name)
+		if !ok {
+			t.Errorf("no next run for %s", tt.name)
+			continue
+		}
+		if wait := time.Until(next); wait > tt.want || wait < tt.want-time.Minute {
+			t.Errorf("%s next runs in %v, want about %v", tt.name, wait, tt.want)
+		}
+	}
+	if _, ok := s.NextRun("missing"); ok {
+		t.Errorf("next run for an unknown job")
+	}
+}
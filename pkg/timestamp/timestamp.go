@@ -0,0 +1,154 @@
+// Package timestamp provides the time type used in API responses and
+// moltgo's own files. It reads every format the Moltbook API has been seen
+// to send, always writes RFC 3339 in UTC, and formats times relative to now
+// for display.
+package timestamp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time is a point in time that marshals as an RFC 3339 string. The zero
+// Time marshals as "".
+type Time struct {
+	time.Time
+}
+
+// New returns t as a Time
+func New(t time.Time) Time {
+	return Time{t}
+}
+
+// layouts are the string formats Parse accepts, tried in order
+var layouts = []string{
+	time.RFC3339Nano, // also matches RFC 3339 without fractional seconds
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// Parse reads an RFC 3339 timestamp, with or without fractional seconds, or
+// a Unix epoch in seconds or milliseconds. Timestamps without a zone are
+// taken to be UTC. An empty string is the zero Time.
+func Parse(s string) (Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Time{}, nil
+	}
+	if t, ok := parseEpoch(s); ok {
+		return t, nil
+	}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return Time{t}, nil
+		}
+	}
+	return Time{}, fmt.Errorf("invalid timestamp %q", s)
+}
+
+// parseEpoch reads Unix seconds, possibly fractional, or milliseconds for
+// values too large to be seconds
+func parseEpoch(s string) (Time, bool) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n > 1e11 || n < -1e11 {
+			return Time{time.UnixMilli(n).UTC()}, true
+		}
+		return Time{time.Unix(n, 0).UTC()}, true
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return Time{}, false
+	}
+	sec := math.Floor(f)
+	return Time{time.Unix(int64(sec), int64(math.Round((f-sec)*1e6))*1e3).UTC()}, true
+}
+
+// String formats the time as RFC 3339 in UTC, with fractional seconds only
+// when there are any
+func (t Time) String() string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (t Time) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (t *Time) UnmarshalText(data []byte) error {
+	parsed, err := Parse(string(data))
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (t Time) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts strings in any
+// format Parse does, numbers as Unix epochs, and null.
+func (t *Time) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*t = Time{}
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		return t.UnmarshalText([]byte(s))
+	}
+	parsed, ok := parseEpoch(string(data))
+	if !ok {
+		return fmt.Errorf("invalid timestamp %s", data)
+	}
+	*t = parsed
+	return nil
+}
+
+// Ago describes the time relative to now: "just now", "5m ago", "3h ago",
+// "2d ago", or "in 3h" for times in the future. Times more than 30 days
+// away are shown as a local date, and the zero Time as "".
+func (t Time) Ago(now time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	d := now.Sub(t.Time)
+	future := d < 0
+	if future {
+		d = -d
+	}
+
+	var span string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		span = fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < 24*time.Hour:
+		span = fmt.Sprintf("%dh", int(d/time.Hour))
+	case d < 30*24*time.Hour:
+		span = fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	default:
+		return t.Time.Local().Format("2006-01-02")
+	}
+	if future {
+		return "in " + span
+	}
+	return span + " ago"
+}
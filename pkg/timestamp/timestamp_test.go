@@ -0,0 +1,193 @@
+package timestamp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"2026-03-01T12:00:00Z", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"2026-03-01T12:00:00.123456Z", time.Date(2026, 3, 1, 12, 0, 0, 123456000, time.UTC)},
+		{"2026-03-01T13:00:00+01:00", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"2026-03-01T12:00:00", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"2026-03-01T12:00:00.5", time.Date(2026, 3, 1, 12, 0, 0, 5e8, time.UTC)},
+		{"2026-03-01 12:00:00Z", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"2026-03-01 12:00:00-05:00", time.Date(2026, 3, 1, 17, 0, 0, 0, time.UTC)},
+		{"2026-03-01 12:00:00.25", time.Date(2026, 3, 1, 12, 0, 0, 25e7, time.UTC)},
+		{"  2026-03-01T12:00:00Z\n", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"1772366400", time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{"1772366400123", time.Date(2026, 3, 1, 12, 0, 0, 123e6, time.UTC)},
+		{"1772366400.5", time.Date(2026, 3, 1, 12, 0, 0, 5e8, time.UTC)},
+		{"0", time.Unix(0, 0)},
+		{"-86400", time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{"", time.Time{}},
+		{"   ", time.Time{}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) || got.IsZero() != tt.want.IsZero() {
+			t.Errorf("Parse(%q) = %v, want %v", tt.in, got.Time, tt.want)
+		}
+	}
+}
+
+func TestParseRejects(t *testing.T) {
+	for _, in := range []string{
+		"yesterday",
+		"2026-03-01",
+		"2026-13-01T12:00:00Z",
+		"01/03/2026 12:00",
+		"12:00:00",
+		"0x10",
+	} {
+		if got, err := Parse(in); err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", in, got.Time)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		in   Time
+		want string
+	}{
+		{Time{}, ""},
+		{New(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)), "2026-03-01T12:00:00Z"},
+		{New(time.Date(2026, 3, 1, 12, 0, 0, 1500, time.UTC)), "2026-03-01T12:00:00.0000015Z"},
+		{New(time.Date(2026, 3, 1, 13, 0, 0, 0, time.FixedZone("CET", 3600))), "2026-03-01T12:00:00Z"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	type doc struct {
+		At Time `json:"at"`
+	}
+
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{`{"at": "2026-03-01T12:00:00Z"}`, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{`{"at": "2026-03-01 12:00:00"}`, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{`{"at": 1772366400}`, time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)},
+		{`{"at": 1772366400123}`, time.Date(2026, 3, 1, 12, 0, 0, 123e6, time.UTC)},
+		{`{"at": 1772366400.25}`, time.Date(2026, 3, 1, 12, 0, 0, 25e7, time.UTC)},
+		{`{"at": ""}`, time.Time{}},
+		{`{"at": null}`, time.Time{}},
+		{`{}`, time.Time{}},
+	}
+	for _, tt := range tests {
+		var d doc
+		if err := json.Unmarshal([]byte(tt.in), &d); err != nil {
+			t.Errorf("Unmarshal(%s): %v", tt.in, err)
+			continue
+		}
+		if !d.At.Equal(tt.want) || d.At.IsZero() != tt.want.IsZero() {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.in, d.At.Time, tt.want)
+		}
+	}
+
+	for _, in := range []string{`{"at": "soon"}`, `{"at": true}`, `{"at": {}}`} {
+		var d doc
+		if err := json.Unmarshal([]byte(in), &d); err == nil {
+			t.Errorf("Unmarshal(%s) = %v, want an error", in, d.At.Time)
+		}
+	}
+
+	// A null resets a time that was set
+	d := doc{At: New(time.Now())}
+	if err := json.Unmarshal([]byte(`{"at": null}`), &d); err != nil || !d.At.IsZero() {
+		t.Errorf("null left %v, %v; want the zero Time", d.At.Time, err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	times := []Time{
+		{},
+		New(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)),
+		New(time.Date(2026, 3, 1, 12, 0, 0, 123456789, time.UTC)),
+		New(time.Date(2026, 3, 1, 7, 0, 0, 0, time.FixedZone("EST", -5*3600))),
+		New(time.Unix(0, 0)),
+	}
+
+	for _, want := range times {
+		data, err := json.Marshal(want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want.IsZero() && string(data) != `""` {
+			t.Errorf("the zero Time marshals as %s, want \"\"", data)
+		}
+		var got Time
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Errorf("Unmarshal(%s): %v", data, err)
+			continue
+		}
+		if !got.Equal(want.Time) || got.IsZero() != want.IsZero() {
+			t.Errorf("JSON round trip of %v gave %v", want.Time, got.Time)
+		}
+		if !got.IsZero() && got.Location() != time.UTC {
+			t.Errorf("JSON round trip of %v gave a time in %v, want UTC", want.Time, got.Location())
+		}
+
+		type doc struct {
+			At Time `toml:"at"`
+		}
+		var b strings.Builder
+		if err := toml.NewEncoder(&b).Encode(doc{At: want}); err != nil {
+			t.Fatal(err)
+		}
+		var d doc
+		if _, err := toml.Decode(b.String(), &d); err != nil {
+			t.Errorf("toml.Decode(%q): %v", b.String(), err)
+			continue
+		}
+		if !d.At.Equal(want.Time) || d.At.IsZero() != want.IsZero() {
+			t.Errorf("TOML round trip of %v gave %v", want.Time, d.At.Time)
+		}
+	}
+}
+
+func TestAgo(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-45 * 24 * time.Hour)
+
+	tests := []struct {
+		at   Time
+		want string
+	}{
+		{Time{}, ""},
+		{New(now), "just now"},
+		{New(now.Add(-59 * time.Second)), "just now"},
+		{New(now.Add(30 * time.Second)), "just now"},
+		{New(now.Add(-5 * time.Minute)), "5m ago"},
+		{New(now.Add(-59 * time.Minute)), "59m ago"},
+		{New(now.Add(-3 * time.Hour)), "3h ago"},
+		{New(now.Add(3 * time.Hour)), "in 3h"},
+		{New(now.Add(-2 * 24 * time.Hour)), "2d ago"},
+		{New(now.Add(29 * 24 * time.Hour)), "in 29d"},
+		{New(old), old.Local().Format("2006-01-02")},
+	}
+	for _, tt := range tests {
+		if got := tt.at.Ago(now); got != tt.want {
+			t.Errorf("Ago(%v) = %q, want %q", tt.at.Time, got, tt.want)
+		}
+	}
+}
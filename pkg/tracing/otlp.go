@@ -0,0 +1,218 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLP/JSON status codes
+const (
+	statusUnset = 0
+	statusError = 2
+)
+
+// exportRequest is an OTLP ExportTraceServiceRequest in its JSON encoding
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []spanJSON `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type spanJSON struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              Kind       `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            status     `json:"status"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// encode builds the OTLP request for spans, one resource per service
+func encode(spans []*Span) *exportRequest {
+	var req exportRequest
+	index := map[string]int{}
+	for _, s := range spans {
+		service := s.Service()
+		i, ok := index[service]
+		if !ok {
+			i = len(req.ResourceSpans)
+			index[service] = i
+			req.ResourceSpans = append(req.ResourceSpans, resourceSpans{
+				Resource:   resource{Attributes: keyValues([]slog.Attr{slog.String("service.name", service)})},
+				ScopeSpans: []scopeSpans{{Scope: scope{Name: service}}},
+			})
+		}
+
+		js := spanJSON{
+			TraceID:           s.TraceID.String(),
+			SpanID:            s.SpanID.String(),
+			Name:              s.Name,
+			Kind:              s.Kind(),
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.EndTime().UnixNano(), 10),
+			Attributes:        keyValues(s.Attributes()),
+			Status:            status{Code: statusUnset},
+		}
+		if !s.Parent.IsZero() {
+			js.ParentSpanID = s.Parent.String()
+		}
+		if msg, failed := s.Err(); failed {
+			js.Status = status{Code: statusError, Message: msg}
+		}
+
+		scope := &req.ResourceSpans[i].ScopeSpans[0]
+		scope.Spans = append(scope.Spans, js)
+	}
+	return &req
+}
+
+// keyValues converts attributes to OTLP key-values
+func keyValues(attrs []slog.Attr) []keyValue {
+	kvs := make([]keyValue, 0, len(attrs))
+	for _, a := range attrs {
+		var v anyValue
+		switch val := a.Value.Resolve(); val.Kind() {
+		case slog.KindBool:
+			b := val.Bool()
+			v.BoolValue = &b
+		case slog.KindInt64:
+			n := strconv.FormatInt(val.Int64(), 10)
+			v.IntValue = &n
+		case slog.KindUint64:
+			n := strconv.FormatUint(val.Uint64(), 10)
+			v.IntValue = &n
+		case slog.KindFloat64:
+			f := val.Float64()
+			v.DoubleValue = &f
+		case slog.KindTime:
+			s := val.Time().UTC().Format(time.RFC3339Nano)
+			v.StringValue = &s
+		default:
+			s := val.String()
+			v.StringValue = &s
+		}
+		kvs = append(kvs, keyValue{Key: a.Key, Value: v})
+	}
+	return kvs
+}
+
+// WriterExporter writes each batch of spans to a writer as one line of
+// OTLP JSON, which a collector's file receiver can read back
+type WriterExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewWriterExporter returns an exporter that writes to w
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// Export implements Exporter
+func (e *WriterExporter) Export(spans []*Span) error {
+	data, err := json.Marshal(encode(spans))
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.w.Write(append(data, '\n'))
+	return err
+}
+
+// OTLPExporter sends spans to an OpenTelemetry collector over OTLP/HTTP
+// with JSON encoding
+type OTLPExporter struct {
+	url        string
+	header     http.Header
+	httpClient *http.Client
+}
+
+// NewOTLPExporter returns an exporter that posts to endpoint, the base URL
+// of an OTLP/HTTP receiver such as http://localhost:4318. "/v1/traces" is
+// added unless the URL already ends with it. header is sent with each
+// export, for collectors that want credentials.
+func NewOTLPExporter(endpoint string, header http.Header) *OTLPExporter {
+	url := strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+	return &OTLPExporter{
+		url:        url,
+		header:     header,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Export implements Exporter
+func (e *OTLPExporter) Export(spans []*Span) error {
+	data, err := json.Marshal(encode(spans))
+	if err != nil {
+		return fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", e.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create export request: %w", err)
+	}
+	for name, values := range e.header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export spans: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("failed to export spans: collector returned %s", resp.Status)
+	}
+	return nil
+}
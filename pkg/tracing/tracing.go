@@ -0,0 +1,276 @@
+// Package tracing records what moltgo does as traces of nested spans, in
+// the OpenTelemetry model, and exports them as OTLP to a collector or as
+// JSON lines to a writer.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// TraceID identifies a trace
+type TraceID [16]byte
+
+func (id TraceID) String() string { return hex.EncodeToString(id[:]) }
+
+// SpanID identifies a span within a trace
+type SpanID [8]byte
+
+func (id SpanID) String() string { return hex.EncodeToString(id[:]) }
+
+// IsZero reports whether the ID is unset, as the parent of a root span is
+func (id SpanID) IsZero() bool { return id == SpanID{} }
+
+// Kind is the role of a span, as in OpenTelemetry
+type Kind int
+
+const (
+	KindInternal Kind = 1
+	KindClient   Kind = 3
+)
+
+// Exporter sends finished spans somewhere
+type Exporter interface {
+	Export(spans []*Span) error
+}
+
+// maxPending is how many ended spans a tracer holds before exporting them
+// without waiting for their trace to complete
+const maxPending = 512
+
+// Tracer starts spans and hands them to its exporter once their trace is
+// complete. A nil *Tracer starts no spans.
+type Tracer struct {
+	service  string
+	exporter Exporter
+
+	// OnError receives errors exporting the spans of a trace when its root
+	// span ends; without it they are logged with slog
+	OnError func(error)
+
+	mu      sync.Mutex
+	pending []*Span
+}
+
+// New returns a tracer that exports spans to e under the service name
+func New(service string, e Exporter) *Tracer {
+	return &Tracer{service: service, exporter: e}
+}
+
+// Span is one timed operation in a trace
+type Span struct {
+	tracer *Tracer
+
+	TraceID TraceID
+	SpanID  SpanID
+	Parent  SpanID
+	Name    string
+	Start   time.Time
+
+	mu     sync.Mutex
+	kind   Kind
+	end    time.Time
+	attrs  []slog.Attr
+	err    string
+	failed bool
+	ended  bool
+}
+
+type (
+	spanKey   struct{}
+	tracerKey struct{}
+)
+
+// ContextWithTracer returns a copy of ctx carrying t, so that Start begins
+// new traces with t where ctx carries no span
+func ContextWithTracer(ctx context.Context, t *Tracer) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// TracerFromContext returns the tracer of the span ctx carries, or else
+// the tracer ctx carries, or nil
+func TracerFromContext(ctx context.Context) *Tracer {
+	if s := SpanFromContext(ctx); s != nil {
+		return s.tracer
+	}
+	t, _ := ctx.Value(tracerKey{}).(*Tracer)
+	return t
+}
+
+// SpanFromContext returns the span ctx carries, or nil
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// ContextWithSpan returns a copy of ctx carrying s
+func ContextWithSpan(ctx context.Context, s *Span) context.Context {
+	if s == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, s)
+}
+
+// Start begins a span. It is a child of the span in ctx when there is one,
+// and otherwise the root of a new trace. The returned context carries the
+// new span.
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	s := &Span{
+		tracer: t,
+		Name:   name,
+		kind:   KindInternal,
+		Start:  time.Now(),
+		attrs:  attrs,
+	}
+	if parent := SpanFromContext(ctx); parent != nil {
+		s.TraceID = parent.TraceID
+		s.Parent = parent.SpanID
+	} else {
+		rand.Read(s.TraceID[:])
+	}
+	rand.Read(s.SpanID[:])
+	return ContextWithSpan(ctx, s), s
+}
+
+// Start begins a child of the span in ctx, with that span's tracer, or
+// without a span in ctx the root of a new trace with the tracer in ctx.
+// Without either nothing is traced, and the returned span is nil.
+func Start(ctx context.Context, name string, attrs ...slog.Attr) (context.Context, *Span) {
+	return TracerFromContext(ctx).Start(ctx, name, attrs...)
+}
+
+// SetKind sets the span's kind; spans are KindInternal by default
+func (s *Span) SetKind(k Kind) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.kind = k
+}
+
+// SetAttributes adds attributes to the span, replacing any with the same
+// key
+func (s *Span) SetAttributes(attrs ...slog.Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, a := range attrs {
+		replaced := false
+		for i := range s.attrs {
+			if s.attrs[i].Key == a.Key {
+				s.attrs[i] = a
+				replaced = true
+			}
+		}
+		if !replaced {
+			s.attrs = append(s.attrs, a)
+		}
+	}
+}
+
+// Fail marks the span as failed with err; a nil err does nothing
+func (s *Span) Fail(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failed = true
+	s.err = err.Error()
+	s.attrs = append(s.attrs, slog.String("error.type", fmt.Sprintf("%T", err)))
+}
+
+// End finishes the span. Ending a root span exports its trace.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	t := s.tracer
+	t.mu.Lock()
+	t.pending = append(t.pending, s)
+	full := len(t.pending) >= maxPending
+	t.mu.Unlock()
+
+	// Long-running traces, such as a daemon's, are exported in batches
+	if s.Parent.IsZero() || full {
+		if err := t.Flush(); err != nil {
+			if t.OnError != nil {
+				t.OnError(err)
+			} else {
+				slog.Warn("failed to export traces", "error", err)
+			}
+		}
+	}
+}
+
+// Kind returns the span's kind
+func (s *Span) Kind() Kind {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.kind
+}
+
+// EndTime returns when the span ended
+func (s *Span) EndTime() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.end
+}
+
+// Attributes returns the span's attributes
+func (s *Span) Attributes() []slog.Attr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]slog.Attr(nil), s.attrs...)
+}
+
+// Err returns the message the span failed with, and whether it failed
+func (s *Span) Err() (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err, s.failed
+}
+
+// Service returns the service name the span is reported under
+func (s *Span) Service() string {
+	return s.tracer.service
+}
+
+// Flush exports the spans that have ended but not yet been exported
+func (t *Tracer) Flush() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	spans := t.pending
+	t.pending = nil
+	t.mu.Unlock()
+
+	if len(spans) == 0 || t.exporter == nil {
+		return nil
+	}
+	return t.exporter.Export(spans)
+}
@@ -0,0 +1,301 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// recorder is an exporter that keeps each batch of spans
+type recorder struct {
+	mu      sync.Mutex
+	batches [][]*Span
+	err     error
+}
+
+func (r *recorder) Export(spans []*Span) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.batches = append(r.batches, spans)
+	return r.err
+}
+
+func TestChildSpansJoinTheParentTrace(t *testing.T) {
+	rec := &recorder{}
+	tracer := New("test", rec)
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	childCtx, child := Start(ctx, "child")
+	_, grandchild := Start(childCtx, "grandchild")
+
+	if !root.Parent.IsZero() {
+		t.Errorf("root has parent %s", root.Parent)
+	}
+	if child.TraceID != root.TraceID || child.Parent != root.SpanID {
+		t.Errorf("child is in trace %s under %s, want %s under %s", child.TraceID, child.Parent, root.TraceID, root.SpanID)
+	}
+	if grandchild.TraceID != root.TraceID || grandchild.Parent != child.SpanID {
+		t.Errorf("grandchild is in trace %s under %s, want %s under %s", grandchild.TraceID, grandchild.Parent, root.TraceID, child.SpanID)
+	}
+	if SpanFromContext(childCtx) != child {
+		t.Error("the child's context does not carry it")
+	}
+}
+
+func TestTraceIsExportedWhenTheRootEnds(t *testing.T) {
+	rec := &recorder{}
+	tracer := New("test", rec)
+
+	ctx, root := tracer.Start(context.Background(), "root")
+	_, child := Start(ctx, "child")
+	child.End()
+	if len(rec.batches) != 0 {
+		t.Fatalf("exported %d batches before the root ended", len(rec.batches))
+	}
+
+	root.End()
+	root.End()
+	if len(rec.batches) != 1 {
+		t.Fatalf("exported %d batches, want 1", len(rec.batches))
+	}
+	var names []string
+	for _, s := range rec.batches[0] {
+		names = append(names, s.Name)
+	}
+	if got := strings.Join(names, ","); got != "child,root" {
+		t.Errorf("exported %s, want child,root", got)
+	}
+}
+
+func TestStartWithoutTracer(t *testing.T) {
+	ctx := context.Background()
+	got, span := Start(ctx, "untraced")
+	if span != nil {
+		t.Fatal("Start without a span or tracer in the context returned a span")
+	}
+	if got != ctx {
+		t.Error("Start without a span or tracer changed the context")
+	}
+
+	// The methods of a nil span, and of a nil tracer, do nothing
+	span.SetKind(KindClient)
+	span.SetAttributes(slog.String("key", "value"))
+	span.Fail(errors.New("failed"))
+	span.End()
+
+	var tracer *Tracer
+	if _, s := tracer.Start(ctx, "untraced"); s != nil {
+		t.Error("a nil tracer started a span")
+	}
+	if err := tracer.Flush(); err != nil {
+		t.Errorf("Flush on a nil tracer: %v", err)
+	}
+}
+
+func TestStartWithTracerInContext(t *testing.T) {
+	rec := &recorder{}
+	tracer := New("test", rec)
+	ctx := ContextWithTracer(context.Background(), tracer)
+
+	if TracerFromContext(ctx) != tracer {
+		t.Fatal("TracerFromContext does not return the tracer the context carries")
+	}
+
+	// Without a span each Start begins a trace of its own
+	_, first := Start(ctx, "first")
+	_, second := Start(ctx, "second")
+	if first == nil || second == nil {
+		t.Fatal("Start with a tracer in the context returned no span")
+	}
+	if !first.Parent.IsZero() || !second.Parent.IsZero() {
+		t.Error("spans started without a parent are not roots")
+	}
+	if first.TraceID == second.TraceID {
+		t.Error("root spans share a trace")
+	}
+
+	first.End()
+	second.End()
+	if len(rec.batches) != 2 {
+		t.Errorf("exported %d batches, want one per trace", len(rec.batches))
+	}
+
+	if ContextWithTracer(context.Background(), nil) != context.Background() {
+		t.Error("ContextWithTracer with a nil tracer changed the context")
+	}
+}
+
+func TestSetAttributesReplacesByKey(t *testing.T) {
+	_, span := New("test", nil).Start(context.Background(), "span", slog.String("a", "1"), slog.Int("b", 2))
+	span.SetAttributes(slog.String("a", "one"), slog.Bool("c", true))
+
+	var got []string
+	for _, a := range span.Attributes() {
+		got = append(got, a.String())
+	}
+	if want := "a=one,b=2,c=true"; strings.Join(got, ",") != want {
+		t.Errorf("attributes = %s, want %s", strings.Join(got, ","), want)
+	}
+}
+
+func TestFail(t *testing.T) {
+	_, span := New("test", nil).Start(context.Background(), "span")
+	span.Fail(nil)
+	if _, failed := span.Err(); failed {
+		t.Fatal("Fail(nil) marked the span failed")
+	}
+
+	span.Fail(io.ErrUnexpectedEOF)
+	msg, failed := span.Err()
+	if !failed || msg != "unexpected EOF" {
+		t.Errorf("Err() = %q, %v; want the error message", msg, failed)
+	}
+	if a := span.Attributes(); len(a) != 1 || a[0].Key != "error.type" || a[0].Value.String() != "*errors.errorString" {
+		t.Errorf("attributes = %v, want error.type", a)
+	}
+}
+
+func TestExportErrorsGoToOnError(t *testing.T) {
+	rec := &recorder{err: errors.New("collector down")}
+	tracer := New("test", rec)
+	var got error
+	tracer.OnError = func(err error) { got = err }
+
+	_, span := tracer.Start(context.Background(), "root")
+	span.End()
+	if got == nil || got.Error() != "collector down" {
+		t.Errorf("OnError got %v, want the export error", got)
+	}
+}
+
+func TestLongTracesAreExportedInBatches(t *testing.T) {
+	rec := &recorder{}
+	tracer := New("test", rec)
+	ctx, root := tracer.Start(context.Background(), "root")
+	for range maxPending {
+		_, s := Start(ctx, "child")
+		s.End()
+	}
+	if len(rec.batches) != 1 || len(rec.batches[0]) != maxPending {
+		t.Fatalf("exported %d batches before the root ended, want one of %d spans", len(rec.batches), maxPending)
+	}
+	root.End()
+	if len(rec.batches) != 2 || len(rec.batches[1]) != 1 {
+		t.Errorf("the root was not exported on its own after the batch")
+	}
+}
+
+// exportedSpans decodes an OTLP/JSON export into its spans by name
+func exportedSpans(t *testing.T, data []byte) (string, map[string]spanJSON) {
+	t.Helper()
+	var req exportRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatalf("export is not OTLP/JSON: %v\n%s", err, data)
+	}
+	if len(req.ResourceSpans) != 1 {
+		t.Fatalf("export has %d resources, want 1", len(req.ResourceSpans))
+	}
+	rs := req.ResourceSpans[0]
+	service := *rs.Resource.Attributes[0].Value.StringValue
+	spans := map[string]spanJSON{}
+	for _, s := range rs.ScopeSpans[0].Spans {
+		spans[s.Name] = s
+	}
+	return service, spans
+}
+
+func TestWriterExporter(t *testing.T) {
+	var b strings.Builder
+	tracer := New("moltgo", NewWriterExporter(&b))
+
+	ctx, root := tracer.Start(context.Background(), "root", slog.Bool("moltgo.dry_run", true))
+	_, req := Start(ctx, "GET /posts", slog.Int("http.response.status_code", 200), slog.Float64("ratio", 0.5))
+	req.SetKind(KindClient)
+	req.Fail(errors.New("boom"))
+	req.End()
+	root.End()
+
+	lines := strings.Split(strings.TrimSuffix(b.String(), "\n"), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("wrote %d lines, want 1 per trace", len(lines))
+	}
+	service, spans := exportedSpans(t, []byte(lines[0]))
+	if service != "moltgo" {
+		t.Errorf("service.name = %q, want moltgo", service)
+	}
+
+	r, c := spans["root"], spans["GET /posts"]
+	if r.ParentSpanID != "" || r.Kind != KindInternal || r.Status.Code != statusUnset {
+		t.Errorf("root exported as %+v", r)
+	}
+	if c.TraceID != r.TraceID || c.ParentSpanID != r.SpanID {
+		t.Errorf("child exported in trace %s under %s, want %s under %s", c.TraceID, c.ParentSpanID, r.TraceID, r.SpanID)
+	}
+	if c.Kind != KindClient || c.Status.Code != statusError || c.Status.Message != "boom" {
+		t.Errorf("child exported with kind %d and status %+v", c.Kind, c.Status)
+	}
+	if v := c.Attributes[0].Value.IntValue; v == nil || *v != "200" {
+		t.Errorf("integer attribute exported as %+v", c.Attributes[0].Value)
+	}
+	if v := c.Attributes[1].Value.DoubleValue; v == nil || *v != 0.5 {
+		t.Errorf("float attribute exported as %+v", c.Attributes[1].Value)
+	}
+	if v := r.Attributes[0].Value.BoolValue; v == nil || !*v {
+		t.Errorf("bool attribute exported as %+v", r.Attributes[0].Value)
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	var (
+		path, auth, contentType string
+		body                    []byte
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		contentType = r.Header.Get("Content-Type")
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	header := http.Header{}
+	header.Set("Authorization", "Bearer token")
+	tracer := New("moltgo", NewOTLPExporter(srv.URL+"/", header))
+	_, span := tracer.Start(context.Background(), "heartbeat")
+	span.End()
+
+	if path != "/v1/traces" {
+		t.Errorf("exported to %q, want /v1/traces", path)
+	}
+	if auth != "Bearer token" || contentType != "application/json" {
+		t.Errorf("exported with Authorization %q and Content-Type %q", auth, contentType)
+	}
+	if _, spans := exportedSpans(t, body); len(spans) != 1 || spans["heartbeat"].Name != "heartbeat" {
+		t.Errorf("exported %v, want the heartbeat span", spans)
+	}
+}
+
+func TestOTLPExporterReportsCollectorErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	if got := NewOTLPExporter(srv.URL+"/v1/traces", nil).url; got != srv.URL+"/v1/traces" {
+		t.Errorf("url = %q, want /v1/traces added once", got)
+	}
+
+	_, span := New("moltgo", nil).Start(context.Background(), "span")
+	span.End()
+	err := NewOTLPExporter(srv.URL, nil).Export([]*Span{span})
+	if err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Export = %v, want the collector's status", err)
+	}
+}
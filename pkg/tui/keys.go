@@ -0,0 +1,137 @@
+package tui
+
+import (
+	"bufio"
+	"unicode/utf8"
+)
+
+// KeyType identifies a key press
+type KeyType int
+
+const (
+	KeyRune KeyType = iota
+	KeyEnter
+	KeyEsc
+	KeyBackspace
+	KeyTab
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyPgUp
+	KeyPgDown
+	KeyHome
+	KeyEnd
+	KeyCtrlC
+	KeyCtrlS
+	KeyUnknown
+)
+
+// Key is a single decoded key press
+type Key struct {
+	Type KeyType
+	Rune rune
+}
+
+// RuneKey returns the key press for a printable character
+func RuneKey(r rune) Key {
+	return Key{Type: KeyRune, Rune: r}
+}
+
+// ReadKey decodes the next key press from a terminal in raw mode
+func ReadKey(r *bufio.Reader) (Key, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return Key{}, err
+	}
+
+	switch b {
+	case '\r', '\n':
+		return Key{Type: KeyEnter}, nil
+	case '\t':
+		return Key{Type: KeyTab}, nil
+	case 0x7f, 0x08:
+		return Key{Type: KeyBackspace}, nil
+	case 0x03:
+		return Key{Type: KeyCtrlC}, nil
+	case 0x13:
+		return Key{Type: KeyCtrlS}, nil
+	case 0x1b:
+		// A lone escape has nothing buffered behind it
+		if r.Buffered() == 0 {
+			return Key{Type: KeyEsc}, nil
+		}
+		return readEscape(r)
+	}
+
+	if b < 0x20 {
+		return Key{Type: KeyUnknown}, nil
+	}
+
+	if b < utf8.RuneSelf {
+		return RuneKey(rune(b)), nil
+	}
+
+	// Multi-byte UTF-8 sequence
+	buf := []byte{b}
+	for !utf8.FullRune(buf) && len(buf) < utf8.UTFMax {
+		next, err := r.ReadByte()
+		if err != nil {
+			return Key{}, err
+		}
+		buf = append(buf, next)
+	}
+	ch, _ := utf8.DecodeRune(buf)
+	return RuneKey(ch), nil
+}
+
+func readEscape(r *bufio.Reader) (Key, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return Key{}, err
+	}
+	if b != '[' && b != 'O' {
+		return Key{Type: KeyEsc}, nil
+	}
+
+	var params []byte
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return Key{}, err
+		}
+		if c >= 0x40 && c <= 0x7e {
+			return escapeKey(c, string(params)), nil
+		}
+		params = append(params, c)
+	}
+}
+
+func escapeKey(final byte, params string) Key {
+	switch final {
+	case 'A':
+		return Key{Type: KeyUp}
+	case 'B':
+		return Key{Type: KeyDown}
+	case 'C':
+		return Key{Type: KeyRight}
+	case 'D':
+		return Key{Type: KeyLeft}
+	case 'H':
+		return Key{Type: KeyHome}
+	case 'F':
+		return Key{Type: KeyEnd}
+	case '~':
+		switch params {
+		case "5":
+			return Key{Type: KeyPgUp}
+		case "6":
+			return Key{Type: KeyPgDown}
+		case "1", "7":
+			return Key{Type: KeyHome}
+		case "4", "8":
+			return Key{Type: KeyEnd}
+		}
+	}
+	return Key{Type: KeyUnknown}
+}
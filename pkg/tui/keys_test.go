@@ -0,0 +1,42 @@
+package tui
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestReadKey(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Key
+	}{
+		{"a", RuneKey('a')},
+		{"é", RuneKey('é')},
+		{"\r", Key{Type: KeyEnter}},
+		{"\t", Key{Type: KeyTab}},
+		{"\x7f", Key{Type: KeyBackspace}},
+		{"\x03", Key{Type: KeyCtrlC}},
+		{"\x13", Key{Type: KeyCtrlS}},
+		{"\x1b", Key{Type: KeyEsc}},
+		{"\x1b[A", Key{Type: KeyUp}},
+		{"\x1b[B", Key{Type: KeyDown}},
+		{"\x1bOD", Key{Type: KeyLeft}},
+		{"\x1b[5~", Key{Type: KeyPgUp}},
+		{"\x1b[6~", Key{Type: KeyPgDown}},
+		{"\x1b[1~", Key{Type: KeyHome}},
+		{"\x1b[F", Key{Type: KeyEnd}},
+		{"\x1b[99~", Key{Type: KeyUnknown}},
+		{"\x01", Key{Type: KeyUnknown}},
+	}
+	for _, tt := range tests {
+		got, err := ReadKey(bufio.NewReader(strings.NewReader(tt.input)))
+		if err != nil {
+			t.Errorf("ReadKey(%q): %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ReadKey(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,561 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+)
+
+// Backend is the subset of the Moltbook client used by the TUI
+type Backend interface {
+	BrowsePosts(ctx context.Context, req *moltbook.BrowsePostsRequest) ([]moltbook.Post, error)
+	GetPost(ctx context.Context, postID string) (*moltbook.Post, error)
+	GetComments(ctx context.Context, postID, sort string) ([]moltbook.Comment, error)
+	CreatePost(ctx context.Context, req *moltbook.CreatePostRequest) (*moltbook.Post, error)
+	CreateReply(ctx context.Context, postID, parentID, content string) (*moltbook.Comment, error)
+	Vote(ctx context.Context, targetType, targetID, direction string) error
+}
+
+// Sorts is the order in which the feed cycles through sort modes
+var Sorts = []string{moltbook.SortHot, moltbook.SortNew, moltbook.SortTop, moltbook.SortRising}
+
+type pane int
+
+const (
+	paneFeed pane = iota
+	paneDetail
+	paneCompose
+	panePrompt
+	paneHelp
+)
+
+// threadLine is a comment flattened out of its thread with its depth
+type threadLine struct {
+	Comment moltbook.Comment
+	Depth   int
+}
+
+type composeKind int
+
+const (
+	composePost composeKind = iota
+	composeReply
+)
+
+type composeField struct {
+	Label     string
+	Value     []rune
+	Multiline bool
+}
+
+type compose struct {
+	Kind     composeKind
+	PostID   string
+	ParentID string
+	ReplyTo  string
+	Fields   []composeField
+	Focus    int
+}
+
+type prompt struct {
+	Label    string
+	Value    []rune
+	OnSubmit func(ctx context.Context, value string)
+}
+
+// Options configures a Model
+type Options struct {
+	Submolt string
+	Sort    string
+	Limit   int
+
+	// State is used to enforce the local rate limits before composing.
+	// SaveState persists it after a successful post or comment.
+	State     *config.State
+	SaveState func(*config.State) error
+
+	// OpenURL opens a link in the user's browser
+	OpenURL func(url string) error
+
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+}
+
+// Model holds the TUI state. It is independent of the terminal so it can be
+// driven directly with key presses.
+type Model struct {
+	backend Backend
+	opts    Options
+
+	pane     pane
+	prevPane pane
+
+	submolt string
+	sort    string
+	posts   []moltbook.Post
+	cursor  int
+
+	post         *moltbook.Post
+	thread       []threadLine
+	selected     int // -1 selects the post itself
+	detailScroll int
+
+	compose compose
+	prompt  prompt
+
+	status string
+	quit   bool
+}
+
+// NewModel creates a Model backed by the given client
+func NewModel(backend Backend, opts Options) *Model {
+	if opts.Limit <= 0 {
+		opts.Limit = 25
+	}
+	if opts.Sort == "" {
+		opts.Sort = moltbook.SortHot
+	}
+	if opts.State == nil {
+		opts.State = &config.State{}
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Model{
+		backend: backend,
+		opts:    opts,
+		submolt: opts.Submolt,
+		sort:    opts.Sort,
+	}
+}
+
+// Init loads the initial feed. Requests made by the model are sent with
+// the ctx of the call that makes them.
+func (m *Model) Init(ctx context.Context) {
+	m.loadFeed(ctx)
+}
+
+// Quit reports whether the user asked to exit
+func (m *Model) Quit() bool {
+	return m.quit
+}
+
+// Status returns the current status line message
+func (m *Model) Status() string {
+	return m.status
+}
+
+// Posts returns the posts currently shown in the feed
+func (m *Model) Posts() []moltbook.Post {
+	return m.posts
+}
+
+// Update applies a key press to the model
+func (m *Model) Update(ctx context.Context, k Key) {
+	if k.Type == KeyCtrlC {
+		m.quit = true
+		return
+	}
+
+	switch m.pane {
+	case paneFeed:
+		m.updateFeed(ctx, k)
+	case paneDetail:
+		m.updateDetail(ctx, k)
+	case paneCompose:
+		m.updateCompose(ctx, k)
+	case panePrompt:
+		m.updatePrompt(ctx, k)
+	case paneHelp:
+		m.pane = m.prevPane
+	}
+}
+
+func (m *Model) updateFeed(ctx context.Context, k Key) {
+	switch {
+	case k.Type == KeyDown || k.Rune == 'j':
+		if m.cursor < len(m.posts)-1 {
+			m.cursor++
+		}
+	case k.Type == KeyUp || k.Rune == 'k':
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case k.Type == KeyHome || k.Rune == 'g':
+		m.cursor = 0
+	case k.Type == KeyEnd || k.Rune == 'G':
+		if len(m.posts) > 0 {
+			m.cursor = len(m.posts) - 1
+		}
+	case k.Type == KeyEnter:
+		if p := m.currentPost(); p != nil {
+			m.openDetail(ctx, p.ID)
+		}
+	case k.Rune == 'o':
+		m.sort = nextSort(m.sort)
+		m.loadFeed(ctx)
+	case k.Rune == 's':
+		m.startPrompt("Submolt (empty for all)", m.submolt, func(ctx context.Context, v string) {
+			m.submolt = strings.TrimPrefix(strings.TrimSpace(v), "/")
+			m.loadFeed(ctx)
+		})
+	case k.Rune == 'r':
+		m.loadFeed(ctx)
+	case k.Rune == 'u':
+		if p := m.currentPost(); p != nil {
+			m.vote(ctx, "post", p.ID, "up")
+		}
+	case k.Rune == 'd':
+		if p := m.currentPost(); p != nil {
+			m.vote(ctx, "post", p.ID, "down")
+		}
+	case k.Rune == 'l':
+		if p := m.currentPost(); p != nil {
+			m.openLink(p)
+		}
+	case k.Rune == 'n':
+		m.startComposePost()
+	case k.Rune == '?':
+		m.showHelp()
+	case k.Rune == 'q':
+		m.quit = true
+	}
+}
+
+func (m *Model) updateDetail(ctx context.Context, k Key) {
+	switch {
+	case k.Type == KeyDown || k.Rune == 'j':
+		if m.selected < len(m.thread)-1 {
+			m.selected++
+		}
+	case k.Type == KeyUp || k.Rune == 'k':
+		if m.selected > -1 {
+			m.selected--
+		}
+		if m.selected == -1 {
+			m.detailScroll = 0
+		}
+	case k.Type == KeyPgDown || k.Rune == ' ':
+		m.detailScroll += 10
+	case k.Type == KeyPgUp:
+		m.detailScroll -= 10
+		if m.detailScroll < 0 {
+			m.detailScroll = 0
+		}
+	case k.Type == KeyEsc || k.Type == KeyLeft || k.Rune == 'q' || k.Rune == 'h':
+		m.pane = paneFeed
+		m.post = nil
+		m.thread = nil
+	case k.Rune == 'r':
+		m.startComposeReply()
+	case k.Rune == 'R':
+		m.openDetail(ctx, m.post.ID)
+	case k.Rune == 'u':
+		m.voteSelected(ctx, "up")
+	case k.Rune == 'd':
+		m.voteSelected(ctx, "down")
+	case k.Rune == 'l':
+		m.openLink(m.post)
+	case k.Rune == '?':
+		m.showHelp()
+	}
+}
+
+func (m *Model) updateCompose(ctx context.Context, k Key) {
+	c := &m.compose
+	field := &c.Fields[c.Focus]
+
+	switch k.Type {
+	case KeyEsc:
+		m.pane = m.prevPane
+		m.status = "Discarded draft"
+	case KeyCtrlS:
+		m.submitCompose(ctx)
+	case KeyTab:
+		c.Focus = (c.Focus + 1) % len(c.Fields)
+	case KeyUp:
+		if c.Focus > 0 {
+			c.Focus--
+		}
+	case KeyDown:
+		if c.Focus < len(c.Fields)-1 {
+			c.Focus++
+		}
+	case KeyEnter:
+		if field.Multiline {
+			field.Value = append(field.Value, '\n')
+		} else if c.Focus < len(c.Fields)-1 {
+			c.Focus++
+		}
+	case KeyBackspace:
+		if n := len(field.Value); n > 0 {
+			field.Value = field.Value[:n-1]
+		}
+	case KeyRune:
+		field.Value = append(field.Value, k.Rune)
+	}
+}
+
+func (m *Model) updatePrompt(ctx context.Context, k Key) {
+	p := &m.prompt
+	switch k.Type {
+	case KeyEsc:
+		m.pane = m.prevPane
+	case KeyEnter:
+		m.pane = m.prevPane
+		p.OnSubmit(ctx, string(p.Value))
+	case KeyBackspace:
+		if n := len(p.Value); n > 0 {
+			p.Value = p.Value[:n-1]
+		}
+	case KeyRune:
+		p.Value = append(p.Value, k.Rune)
+	}
+}
+
+func (m *Model) showHelp() {
+	m.prevPane = m.pane
+	m.pane = paneHelp
+}
+
+func (m *Model) startPrompt(label, initial string, onSubmit func(ctx context.Context, value string)) {
+	m.prevPane = m.pane
+	m.pane = panePrompt
+	m.prompt = prompt{Label: label, Value: []rune(initial), OnSubmit: onSubmit}
+}
+
+func (m *Model) currentPost() *moltbook.Post {
+	if m.cursor < 0 || m.cursor >= len(m.posts) {
+		return nil
+	}
+	return &m.posts[m.cursor]
+}
+
+func (m *Model) loadFeed(ctx context.Context) {
+	posts, err := m.backend.BrowsePosts(ctx, &moltbook.BrowsePostsRequest{
+		Submolt: m.submolt,
+		Sort:    m.sort,
+		Limit:   m.opts.Limit,
+	})
+	if err != nil {
+		m.status = fmt.Sprintf("Failed to load posts: %v", err)
+		return
+	}
+	m.posts = posts
+	m.cursor = 0
+	m.status = fmt.Sprintf("Loaded %d posts", len(posts))
+}
+
+func (m *Model) openDetail(ctx context.Context, postID string) {
+	post, err := m.backend.GetPost(ctx, postID)
+	if err != nil {
+		m.status = fmt.Sprintf("Failed to load post: %v", err)
+		return
+	}
+	comments, err := m.backend.GetComments(ctx, postID, "")
+	if err != nil {
+		m.status = fmt.Sprintf("Failed to load comments: %v", err)
+		return
+	}
+	m.post = post
+	m.thread = flattenThread(comments, 0, nil)
+	m.selected = -1
+	m.detailScroll = 0
+	m.pane = paneDetail
+	m.status = ""
+}
+
+func flattenThread(comments []moltbook.Comment, depth int, out []threadLine) []threadLine {
+	for _, c := range comments {
+		out = append(out, threadLine{Comment: c, Depth: depth})
+		out = flattenThread(c.Replies, depth+1, out)
+	}
+	return out
+}
+
+func (m *Model) vote(ctx context.Context, targetType, targetID, direction string) {
+	if err := m.backend.Vote(ctx, targetType, targetID, direction); err != nil {
+		m.status = fmt.Sprintf("Vote failed: %v", err)
+		return
+	}
+	delta := 1
+	if direction == "down" {
+		delta = -1
+	}
+	m.adjustScore(targetType, targetID, delta)
+	m.status = fmt.Sprintf("Voted %s on %s %s", direction, targetType, targetID)
+}
+
+func (m *Model) voteSelected(ctx context.Context, direction string) {
+	if m.selected < 0 {
+		m.vote(ctx, "post", m.post.ID, direction)
+		return
+	}
+	m.vote(ctx, "comment", m.thread[m.selected].Comment.ID, direction)
+}
+
+func (m *Model) adjustScore(targetType, targetID string, delta int) {
+	if targetType == "post" {
+		for i := range m.posts {
+			if m.posts[i].ID == targetID {
+				m.posts[i].Score += delta
+			}
+		}
+		if m.post != nil && m.post.ID == targetID {
+			m.post.Score += delta
+		}
+		return
+	}
+	for i := range m.thread {
+		if m.thread[i].Comment.ID == targetID {
+			m.thread[i].Comment.Score += delta
+		}
+	}
+}
+
+func (m *Model) openLink(p *moltbook.Post) {
+	if m.opts.OpenURL == nil {
+		m.status = "Opening links is not supported"
+		return
+	}
+	link := p.URL
+	if link == "" {
+		link = moltbook.PostWebURL(p.ID)
+	}
+	if err := m.opts.OpenURL(link); err != nil {
+		m.status = fmt.Sprintf("Failed to open %s: %v", link, err)
+		return
+	}
+	m.status = "Opened " + link
+}
+
+func (m *Model) startComposePost() {
+	if wait := m.opts.State.PostCooldown(m.opts.Now()); wait > 0 {
+		m.status = fmt.Sprintf("Rate limit: you can post again in %s", wait.Round(time.Second))
+		return
+	}
+	m.prevPane = m.pane
+	m.pane = paneCompose
+	m.compose = compose{
+		Kind: composePost,
+		Fields: []composeField{
+			{Label: "Submolt", Value: []rune(m.submolt)},
+			{Label: "Title"},
+			{Label: "URL"},
+			{Label: "Content", Multiline: true},
+		},
+	}
+	if m.submolt != "" {
+		m.compose.Focus = 1
+	}
+}
+
+func (m *Model) startComposeReply() {
+	if wait := m.opts.State.CommentCooldown(m.opts.Now()); wait > 0 {
+		m.status = fmt.Sprintf("Rate limit: you can comment again in %s", wait.Round(time.Second))
+		return
+	}
+	c := compose{
+		Kind:    composeReply,
+		PostID:  m.post.ID,
+		ReplyTo: m.post.Author,
+		Fields:  []composeField{{Label: "Reply", Multiline: true}},
+	}
+	if m.selected >= 0 {
+		parent := m.thread[m.selected].Comment
+		c.ParentID = parent.ID
+		c.ReplyTo = parent.Author
+	}
+	m.prevPane = m.pane
+	m.pane = paneCompose
+	m.compose = c
+}
+
+func (m *Model) fieldValue(label string) string {
+	for _, f := range m.compose.Fields {
+		if f.Label == label {
+			return strings.TrimSpace(string(f.Value))
+		}
+	}
+	return ""
+}
+
+func (m *Model) submitCompose(ctx context.Context) {
+	now := m.opts.Now()
+	state := m.opts.State
+
+	switch m.compose.Kind {
+	case composePost:
+		if err := state.CheckPost(now); err != nil {
+			m.status = err.Error()
+			return
+		}
+		req := &moltbook.CreatePostRequest{
+			Submolt: m.fieldValue("Submolt"),
+			Title:   m.fieldValue("Title"),
+			URL:     m.fieldValue("URL"),
+			Content: m.fieldValue("Content"),
+		}
+		if req.Submolt == "" || req.Title == "" {
+			m.status = "Submolt and title are required"
+			return
+		}
+		if req.Content == "" && req.URL == "" {
+			m.status = "Provide either content or a URL"
+			return
+		}
+		post, err := m.backend.CreatePost(ctx, req)
+		if err != nil {
+			m.status = fmt.Sprintf("Failed to create post: %v", err)
+			return
+		}
+		state.RecordPost(post.ID, now)
+		m.pane = m.prevPane
+		m.loadFeed(ctx)
+		m.status = fmt.Sprintf("Post created: %s", post.ID)
+		m.saveState()
+
+	case composeReply:
+		if err := state.CheckComment(now); err != nil {
+			m.status = err.Error()
+			return
+		}
+		content := m.fieldValue("Reply")
+		if content == "" {
+			m.status = "Reply is empty"
+			return
+		}
+		comment, err := m.backend.CreateReply(ctx, m.compose.PostID, m.compose.ParentID, content)
+		if err != nil {
+			m.status = fmt.Sprintf("Failed to create comment: %v", err)
+			return
+		}
+		state.RecordComment(now)
+		m.pane = m.prevPane
+		m.openDetail(ctx, m.compose.PostID)
+		m.status = fmt.Sprintf("Comment added: %s", comment.ID)
+		m.saveState()
+	}
+}
+
+func (m *Model) saveState() {
+	if m.opts.SaveState == nil {
+		return
+	}
+	if err := m.opts.SaveState(m.opts.State); err != nil {
+		m.status = fmt.Sprintf("Warning: failed to save state: %v", err)
+	}
+}
+
+func nextSort(current string) string {
+	for i, s := range Sorts {
+		if s == current {
+			return Sorts[(i+1)%len(Sorts)]
+		}
+	}
+	return Sorts[0]
+}
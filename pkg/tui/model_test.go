@@ -0,0 +1,461 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/config"
+	"github.com/moltgo/moltgo/pkg/moltbook"
+	"github.com/moltgo/moltgo/pkg/timestamp"
+)
+
+var testNow = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// fakeBackend serves a fixed feed and records every call made to it
+type fakeBackend struct {
+	posts    []moltbook.Post
+	comments map[string][]moltbook.Comment
+	err      error
+
+	browses []moltbook.BrowsePostsRequest
+	created []moltbook.CreatePostRequest
+	replies []string
+	votes   []string
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		posts: []moltbook.Post{
+			{ID: "p1", Title: "First", Author: "alice", Submolt: "general", Score: 3},
+			{ID: "p2", Title: "Second", Author: "bob", Submolt: "general", Score: 5, URL: "https://example.com/2"},
+			{ID: "p3", Title: "Third", Author: "carol", Submolt: "golang", Score: 1},
+		},
+		comments: map[string][]moltbook.Comment{
+			"p1": {
+				{ID: "c1", Author: "dave", Content: "Top", Score: 2, Replies: []moltbook.Comment{
+					{ID: "c2", Author: "erin", Content: "Nested", Score: 1},
+				}},
+				{ID: "c3", Author: "frank", Content: "Another", Score: 0},
+			},
+		},
+	}
+}
+
+func (b *fakeBackend) BrowsePosts(ctx context.Context, req *moltbook.BrowsePostsRequest) ([]moltbook.Post, error) {
+	b.browses = append(b.browses, *req)
+	if b.err != nil {
+		return nil, b.err
+	}
+	var posts []moltbook.Post
+	for _, p := range b.posts {
+		if req.Submolt == "" || p.Submolt == req.Submolt {
+			posts = append(posts, p)
+		}
+	}
+	return posts, nil
+}
+
+func (b *fakeBackend) GetPost(ctx context.Context, postID string) (*moltbook.Post, error) {
+	for _, p := range b.posts {
+		if p.ID == postID {
+			p.CreatedAt = timestamp.New(testNow.Add(-time.Hour))
+			return &p, nil
+		}
+	}
+	return nil, fmt.Errorf("post %s not found", postID)
+}
+
+func (b *fakeBackend) GetComments(ctx context.Context, postID, sort string) ([]moltbook.Comment, error) {
+	return b.comments[postID], nil
+}
+
+func (b *fakeBackend) CreatePost(ctx context.Context, req *moltbook.CreatePostRequest) (*moltbook.Post, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	b.created = append(b.created, *req)
+	return &moltbook.Post{ID: "new1", Title: req.Title, Submolt: req.Submolt}, nil
+}
+
+func (b *fakeBackend) CreateReply(ctx context.Context, postID, parentID, content string) (*moltbook.Comment, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	b.replies = append(b.replies, postID+"/"+parentID+": "+content)
+	return &moltbook.Comment{ID: "new2", PostID: postID, ParentID: parentID, Content: content}, nil
+}
+
+func (b *fakeBackend) Vote(ctx context.Context, targetType, targetID, direction string) error {
+	if b.err != nil {
+		return b.err
+	}
+	b.votes = append(b.votes, targetType+" "+targetID+" "+direction)
+	return nil
+}
+
+func newTestModel(b *fakeBackend, opts Options) *Model {
+	opts.Now = func() time.Time { return testNow }
+	m := NewModel(b, opts)
+	m.Init(context.Background())
+	return m
+}
+
+// keys turns a string into key presses, one rune each
+func keys(s string) []Key {
+	var ks []Key
+	for _, r := range s {
+		ks = append(ks, RuneKey(r))
+	}
+	return ks
+}
+
+func press(m *Model, ks ...Key) {
+	for _, k := range ks {
+		m.Update(context.Background(), k)
+	}
+}
+
+func TestFeedNavigation(t *testing.T) {
+	tests := []struct {
+		name   string
+		keys   []Key
+		cursor int
+	}{
+		{"j moves down", keys("j"), 1},
+		{"arrow moves down", []Key{{Type: KeyDown}}, 1},
+		{"stops at the last post", keys("jjjjj"), 2},
+		{"k moves up", keys("jjk"), 1},
+		{"stops at the first post", keys("kk"), 0},
+		{"G jumps to the end", keys("G"), 2},
+		{"g jumps to the start", keys("Gg"), 0},
+		{"end and home", []Key{{Type: KeyEnd}, {Type: KeyHome}}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestModel(newFakeBackend(), Options{})
+			press(m, tt.keys...)
+			if m.cursor != tt.cursor {
+				t.Errorf("cursor = %d, want %d", m.cursor, tt.cursor)
+			}
+		})
+	}
+}
+
+func TestSortCycles(t *testing.T) {
+	b := newFakeBackend()
+	m := newTestModel(b, Options{})
+
+	for range Sorts {
+		press(m, RuneKey('o'))
+	}
+
+	var got []string
+	for _, req := range b.browses {
+		got = append(got, req.Sort)
+	}
+	want := []string{moltbook.SortHot, moltbook.SortNew, moltbook.SortTop, moltbook.SortRising, moltbook.SortHot}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("sorts requested = %v, want %v", got, want)
+	}
+	if !strings.Contains(m.View(80, 24), "sort:hot") {
+		t.Error("header does not show the current sort")
+	}
+}
+
+func TestSubmoltPrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []Key
+		submolt string
+		posts   int
+	}{
+		{"switches submolt", append(keys("sgolang"), Key{Type: KeyEnter}), "golang", 1},
+		{"strips the leading slash", append(keys("s/golang"), Key{Type: KeyEnter}), "golang", 1},
+		{"backspace edits", append(keys("sgolangx"), Key{Type: KeyBackspace}, Key{Type: KeyEnter}), "golang", 1},
+		{"esc cancels", append(keys("sgolang"), Key{Type: KeyEsc}), "", 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newFakeBackend()
+			m := newTestModel(b, Options{})
+			press(m, tt.keys...)
+
+			if m.pane != paneFeed {
+				t.Errorf("pane = %d, want the feed", m.pane)
+			}
+			if m.submolt != tt.submolt {
+				t.Errorf("submolt = %q, want %q", m.submolt, tt.submolt)
+			}
+			if len(m.Posts()) != tt.posts {
+				t.Errorf("got %d posts, want %d", len(m.Posts()), tt.posts)
+			}
+			if last := b.browses[len(b.browses)-1]; last.Submolt != tt.submolt {
+				t.Errorf("last browse was for %q, want %q", last.Submolt, tt.submolt)
+			}
+		})
+	}
+}
+
+func TestVoteDispatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		keys  []Key
+		votes []string
+	}{
+		{"upvote in feed", keys("u"), []string{"post p1 up"}},
+		{"downvote selected post", keys("jd"), []string{"post p2 down"}},
+		{"vote on open post", append([]Key{{Type: KeyEnter}}, keys("u")...), []string{"post p1 up"}},
+		{"vote on comment", append([]Key{{Type: KeyEnter}}, keys("jd")...), []string{"comment c1 down"}},
+		{"vote on nested comment", append([]Key{{Type: KeyEnter}}, keys("jju")...), []string{"comment c2 up"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newFakeBackend()
+			m := newTestModel(b, Options{})
+			press(m, tt.keys...)
+			if strings.Join(b.votes, ";") != strings.Join(tt.votes, ";") {
+				t.Errorf("votes = %v, want %v", b.votes, tt.votes)
+			}
+		})
+	}
+}
+
+func TestVoteAdjustsScore(t *testing.T) {
+	m := newTestModel(newFakeBackend(), Options{})
+	press(m, RuneKey('u'))
+	if got := m.Posts()[0].Score; got != 4 {
+		t.Errorf("score = %d, want 4", got)
+	}
+	if want := "Voted up on post p1"; m.Status() != want {
+		t.Errorf("status = %q, want %q", m.Status(), want)
+	}
+}
+
+func TestVoteFailure(t *testing.T) {
+	b := newFakeBackend()
+	m := newTestModel(b, Options{})
+	b.err = errors.New("server error")
+	press(m, RuneKey('u'))
+	if got := m.Posts()[0].Score; got != 3 {
+		t.Errorf("score = %d after a failed vote, want 3", got)
+	}
+	if !strings.Contains(m.Status(), "server error") {
+		t.Errorf("status = %q, want the error", m.Status())
+	}
+}
+
+func TestDetail(t *testing.T) {
+	m := newTestModel(newFakeBackend(), Options{})
+	press(m, Key{Type: KeyEnter})
+
+	if m.pane != paneDetail {
+		t.Fatalf("pane = %d, want detail", m.pane)
+	}
+	if len(m.thread) != 3 || m.thread[1].Depth != 1 {
+		t.Errorf("thread = %+v, want three comments with c2 nested", m.thread)
+	}
+	view := m.View(80, 40)
+	for _, want := range []string{"First", "by alice in /general  1h ago", "Comments (3)", "erin"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view does not contain %q:\n%s", want, view)
+		}
+	}
+
+	press(m, Key{Type: KeyEsc})
+	if m.pane != paneFeed || m.post != nil {
+		t.Errorf("esc did not return to the feed")
+	}
+}
+
+func TestReplyDispatch(t *testing.T) {
+	tests := []struct {
+		name  string
+		keys  []Key
+		reply string
+	}{
+		{"reply to post", keys("rHello"), "p1/: Hello"},
+		{"reply to comment", keys("jjrHi"), "p1/c2: Hi"},
+		{"multiline reply", append(keys("rone"), append([]Key{{Type: KeyEnter}}, keys("two")...)...), "p1/: one\ntwo"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newFakeBackend()
+			state := &config.State{}
+			saved := 0
+			m := newTestModel(b, Options{State: state, SaveState: func(*config.State) error {
+				saved++
+				return nil
+			}})
+			press(m, Key{Type: KeyEnter})
+			press(m, tt.keys...)
+			press(m, Key{Type: KeyCtrlS})
+
+			if len(b.replies) != 1 || b.replies[0] != tt.reply {
+				t.Fatalf("replies = %q, want %q", b.replies, tt.reply)
+			}
+			if m.pane != paneDetail {
+				t.Errorf("pane = %d after sending, want detail", m.pane)
+			}
+			if state.CommentsCreated != 1 || saved != 1 {
+				t.Errorf("comment was not recorded and saved")
+			}
+		})
+	}
+}
+
+func TestReplyRateLimited(t *testing.T) {
+	b := newFakeBackend()
+	state := &config.State{}
+	state.RecordComment(testNow.Add(-time.Second))
+	m := newTestModel(b, Options{State: state})
+
+	press(m, Key{Type: KeyEnter}, RuneKey('r'))
+	if m.pane != paneDetail {
+		t.Errorf("compose opened during the cooldown")
+	}
+	if !strings.Contains(m.Status(), "Rate limit") {
+		t.Errorf("status = %q, want a rate limit message", m.Status())
+	}
+}
+
+func TestComposePost(t *testing.T) {
+	tests := []struct {
+		name    string
+		submolt string
+		keys    []Key
+		want    *moltbook.CreatePostRequest
+		status  string
+	}{
+		{
+			name: "all fields",
+			keys: append(append(keys("general"), Key{Type: KeyTab}), append(append(keys("Title"), Key{Type: KeyTab}, Key{Type: KeyTab}), keys("Body")...)...),
+			want: &moltbook.CreatePostRequest{Submolt: "general", Title: "Title", Content: "Body"},
+		},
+		{
+			name:    "submolt prefilled",
+			submolt: "golang",
+			keys:    append(append(keys("Go"), Key{Type: KeyEnter}), keys("https://go.dev")...),
+			want:    &moltbook.CreatePostRequest{Submolt: "golang", Title: "Go", URL: "https://go.dev"},
+		},
+		{
+			name:   "missing title",
+			keys:   keys("general"),
+			status: "Submolt and title are required",
+		},
+		{
+			name:    "missing content and URL",
+			submolt: "golang",
+			keys:    keys("Go"),
+			status:  "Provide either content or a URL",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newFakeBackend()
+			state := &config.State{}
+			m := newTestModel(b, Options{Submolt: tt.submolt, State: state})
+			press(m, RuneKey('n'))
+			press(m, tt.keys...)
+			press(m, Key{Type: KeyCtrlS})
+
+			if tt.want == nil {
+				if len(b.created) != 0 {
+					t.Errorf("post was sent: %+v", b.created)
+				}
+				if m.Status() != tt.status {
+					t.Errorf("status = %q, want %q", m.Status(), tt.status)
+				}
+				if m.pane != paneCompose {
+					t.Errorf("compose closed after an invalid post")
+				}
+				return
+			}
+			if len(b.created) != 1 || b.created[0] != *tt.want {
+				t.Fatalf("created = %+v, want %+v", b.created, *tt.want)
+			}
+			if m.pane != paneFeed {
+				t.Errorf("pane = %d after sending, want the feed", m.pane)
+			}
+			if !state.LastPostTime.Equal(testNow) {
+				t.Errorf("post time was not recorded")
+			}
+		})
+	}
+}
+
+func TestComposeDiscard(t *testing.T) {
+	b := newFakeBackend()
+	m := newTestModel(b, Options{})
+	press(m, RuneKey('n'))
+	press(m, keys("general")...)
+	press(m, Key{Type: KeyEsc})
+
+	if m.pane != paneFeed || len(b.created) != 0 {
+		t.Errorf("esc did not discard the draft")
+	}
+	if m.Status() != "Discarded draft" {
+		t.Errorf("status = %q", m.Status())
+	}
+}
+
+func TestOpenLink(t *testing.T) {
+	var opened []string
+	m := newTestModel(newFakeBackend(), Options{OpenURL: func(url string) error {
+		opened = append(opened, url)
+		return nil
+	}})
+	press(m, RuneKey('l'), RuneKey('j'), RuneKey('l'))
+
+	want := []string{moltbook.PostWebURL("p1"), "https://example.com/2"}
+	if strings.Join(opened, " ") != strings.Join(want, " ") {
+		t.Errorf("opened %v, want %v", opened, want)
+	}
+}
+
+func TestHelpAndQuit(t *testing.T) {
+	m := newTestModel(newFakeBackend(), Options{})
+	press(m, RuneKey('?'))
+	if m.pane != paneHelp {
+		t.Fatalf("? did not open help")
+	}
+	press(m, RuneKey('x'))
+	if m.pane != paneFeed {
+		t.Errorf("a key did not close help")
+	}
+	press(m, RuneKey('q'))
+	if !m.Quit() {
+		t.Errorf("q did not quit")
+	}
+
+	m = newTestModel(newFakeBackend(), Options{})
+	press(m, Key{Type: KeyEnter}, Key{Type: KeyCtrlC})
+	if !m.Quit() {
+		t.Errorf("ctrl+c did not quit from the post view")
+	}
+}
+
+func TestFeedError(t *testing.T) {
+	b := newFakeBackend()
+	b.err = errors.New("unavailable")
+	m := newTestModel(b, Options{})
+	if !strings.Contains(m.Status(), "Failed to load posts: unavailable") {
+		t.Errorf("status = %q", m.Status())
+	}
+	if !strings.Contains(m.View(80, 10), "No posts found.") {
+		t.Errorf("empty feed not shown")
+	}
+}
+
+func TestViewStripsControlCharacters(t *testing.T) {
+	b := newFakeBackend()
+	b.posts[0].Title = "Evil\x1b]0;owned\x07 title"
+	m := newTestModel(b, Options{})
+	view := m.View(80, 24)
+	if strings.Contains(view, "\x1b]") || strings.Contains(view, "\x07") {
+		t.Errorf("view contains the title's control sequence: %q", view)
+	}
+}
@@ -0,0 +1,92 @@
+// Package tui implements a full-screen terminal interface for browsing and
+// engaging with Moltbook.
+package tui
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+const (
+	enterAltScreen = "\x1b[?1049h\x1b[?25l"
+	exitAltScreen  = "\x1b[?25h\x1b[?1049l"
+	clearScreen    = "\x1b[H\x1b[2J"
+)
+
+// Run drives the model from the terminal until the user quits. in and out
+// must both be terminals. The model's requests are sent with ctx.
+func Run(ctx context.Context, m *Model, in io.Reader, out io.Writer) error {
+	inFile, inOK := in.(*os.File)
+	outFile, outOK := out.(*os.File)
+	if !inOK || !outOK || !term.IsTerminal(int(inFile.Fd())) || !term.IsTerminal(int(outFile.Fd())) {
+		return errors.New("the TUI requires an interactive terminal")
+	}
+	inFd := int(inFile.Fd())
+
+	oldState, err := term.MakeRaw(inFd)
+	if err != nil {
+		return fmt.Errorf("failed to enter raw mode: %w", err)
+	}
+	defer term.Restore(inFd, oldState)
+
+	fmt.Fprint(out, enterAltScreen)
+	defer fmt.Fprint(out, exitAltScreen)
+
+	m.Init(ctx)
+	return Loop(ctx, m, in, out, func() (int, int) {
+		width, height, err := term.GetSize(int(outFile.Fd()))
+		if err != nil {
+			return 80, 24
+		}
+		return width, height
+	})
+}
+
+// Loop renders the model and feeds it key presses read from in until the
+// user quits or in is exhausted. It does not touch terminal modes, so it can
+// be driven from a pipe.
+func Loop(ctx context.Context, m *Model, in io.Reader, out io.Writer, size func() (int, int)) error {
+	r := bufio.NewReader(in)
+	for {
+		width, height := size()
+		view := strings.ReplaceAll(m.View(width, height), "\n", "\r\n")
+		if _, err := fmt.Fprint(out, clearScreen+view); err != nil {
+			return err
+		}
+		if m.Quit() {
+			return nil
+		}
+
+		key, err := ReadKey(r)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		m.Update(ctx, key)
+	}
+}
+
+// OpenURL opens a link with the platform's default handler
+func OpenURL(url string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", url)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", url)
+	default:
+		cmd = exec.Command("xdg-open", url)
+	}
+	return cmd.Start()
+}
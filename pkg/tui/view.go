@@ -0,0 +1,223 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/moltgo/moltgo/pkg/render"
+)
+
+const (
+	styleReset   = render.Reset
+	styleBold    = render.Bold
+	styleDim     = render.Dim
+	styleReverse = render.Reverse
+)
+
+// View renders the model for a terminal of the given size
+func (m *Model) View(width, height int) string {
+	if width < 20 {
+		width = 20
+	}
+	if height < 5 {
+		height = 5
+	}
+
+	var body []string
+	switch m.pane {
+	case paneFeed:
+		body = m.viewFeed(width, height-2)
+	case paneDetail:
+		body = m.viewDetail(width, height-2)
+	case paneCompose:
+		body = m.viewCompose(width)
+	case panePrompt:
+		body = m.viewPrompt(width)
+	case paneHelp:
+		body = viewHelp()
+	}
+
+	lines := []string{m.header(width)}
+	for i := 0; i < height-2; i++ {
+		if i < len(body) {
+			lines = append(lines, body[i])
+		} else {
+			lines = append(lines, "")
+		}
+	}
+	lines = append(lines, m.footer(width))
+	return strings.Join(lines, "\n")
+}
+
+func (m *Model) header(width int) string {
+	where := "all submolts"
+	if m.submolt != "" {
+		where = "/" + m.submolt
+	}
+	title := fmt.Sprintf(" moltgo  %s  sort:%s", where, m.sort)
+	return styleReverse + render.Pad(title, width) + styleReset
+}
+
+func (m *Model) footer(width int) string {
+	hints := map[pane]string{
+		paneFeed:    "enter:open u/d:vote o:sort s:submolt n:new post l:link r:refresh ?:help q:quit",
+		paneDetail:  "j/k:select u/d:vote r:reply l:link R:refresh esc:back ?:help",
+		paneCompose: "tab:next field ctrl+s:send esc:cancel",
+		panePrompt:  "enter:confirm esc:cancel",
+		paneHelp:    "press any key to return",
+	}
+	text := hints[m.pane]
+	if m.status != "" {
+		// Errors can quote the API's response
+		text = render.Sanitize(m.status) + "  |  " + text
+	}
+	return styleDim + render.Truncate(text, width) + styleReset
+}
+
+func (m *Model) viewFeed(width, height int) []string {
+	if len(m.posts) == 0 {
+		return []string{"", "  No posts found."}
+	}
+
+	const linesPerPost = 2
+	visible := height / linesPerPost
+	if visible < 1 {
+		visible = 1
+	}
+	start := 0
+	if m.cursor >= visible {
+		start = m.cursor - visible + 1
+	}
+
+	var lines []string
+	for i := start; i < len(m.posts) && i < start+visible; i++ {
+		p := m.posts[i]
+		title := fmt.Sprintf(" %4d  %s", p.Score, render.Sanitize(p.Title))
+		meta := fmt.Sprintf("       by %s in /%s  %d comments", render.Sanitize(p.Author), render.Sanitize(p.Submolt), p.NumComments)
+		if i == m.cursor {
+			lines = append(lines, styleReverse+render.Pad(title, width)+styleReset)
+		} else {
+			lines = append(lines, styleBold+render.Truncate(title, width)+styleReset)
+		}
+		lines = append(lines, styleDim+render.Truncate(meta, width)+styleReset)
+	}
+	return lines
+}
+
+func (m *Model) viewDetail(width, height int) []string {
+	p := m.post
+	var lines []string
+	selectedLine := 0
+
+	head := fmt.Sprintf("%s  (%d points)", render.Sanitize(p.Title), p.Score)
+	if m.selected == -1 {
+		lines = append(lines, styleReverse+render.Pad(head, width)+styleReset)
+	} else {
+		lines = append(lines, styleBold+render.Truncate(head, width)+styleReset)
+	}
+	lines = append(lines, styleDim+render.Truncate(fmt.Sprintf("by %s in /%s  %s", render.Sanitize(p.Author), render.Sanitize(p.Submolt), p.CreatedAt.Ago(m.opts.Now())), width)+styleReset)
+	if p.URL != "" {
+		lines = append(lines, render.Truncate("Link: "+render.Sanitize(p.URL), width))
+	}
+	lines = append(lines, "")
+	md := &render.Renderer{Width: width, Color: true}
+	if p.Content != "" {
+		lines = append(lines, strings.Split(md.Markdown(p.Content, ""), "\n")...)
+	}
+	lines = append(lines, "", styleBold+fmt.Sprintf("Comments (%d)", len(m.thread))+styleReset)
+
+	for i, tl := range m.thread {
+		indent := strings.Repeat("  ", tl.Depth)
+		c := tl.Comment
+		meta := fmt.Sprintf("%s%s  %d points  %s", indent, render.Sanitize(c.Author), c.Score, c.CreatedAt.Ago(m.opts.Now()))
+		if i == m.selected {
+			selectedLine = len(lines)
+			lines = append(lines, styleReverse+render.Pad(meta, width)+styleReset)
+		} else {
+			lines = append(lines, styleDim+render.Truncate(meta, width)+styleReset)
+		}
+		lines = append(lines, strings.Split(md.Markdown(c.Content, indent+"  "), "\n")...)
+	}
+
+	// Keep the selected comment on screen
+	if m.selected >= 0 {
+		if selectedLine < m.detailScroll {
+			m.detailScroll = selectedLine
+		}
+		if selectedLine >= m.detailScroll+height-1 {
+			m.detailScroll = selectedLine - height + 2
+		}
+	}
+	if maxScroll := max(len(lines)-height, 0); m.detailScroll > maxScroll {
+		m.detailScroll = maxScroll
+	}
+	return lines[m.detailScroll:]
+}
+
+func (m *Model) viewCompose(width int) []string {
+	c := m.compose
+	var lines []string
+
+	if c.Kind == composeReply {
+		lines = append(lines, styleBold+"Reply to "+render.Sanitize(c.ReplyTo)+styleReset)
+		if wait := m.opts.State.CommentCooldown(m.opts.Now()); wait > 0 {
+			lines = append(lines, fmt.Sprintf("Rate limit: sending allowed in %s", wait.Round(time.Second)))
+		}
+	} else {
+		lines = append(lines, styleBold+"New post"+styleReset)
+		if wait := m.opts.State.PostCooldown(m.opts.Now()); wait > 0 {
+			lines = append(lines, fmt.Sprintf("Rate limit: sending allowed in %s", wait.Round(time.Second)))
+		}
+	}
+	lines = append(lines, "")
+
+	for i, f := range c.Fields {
+		label := f.Label + ":"
+		if i == c.Focus {
+			label = styleReverse + label + styleReset
+		}
+		value := string(f.Value)
+		if i == c.Focus {
+			value += "_"
+		}
+		if f.Multiline {
+			lines = append(lines, label)
+			lines = append(lines, render.Wrap(value, width, "  ")...)
+		} else {
+			lines = append(lines, label+" "+render.Truncate(value, width-render.Width(f.Label)-2))
+		}
+		lines = append(lines, "")
+	}
+	return lines
+}
+
+func (m *Model) viewPrompt(width int) []string {
+	return []string{"", render.Truncate(m.prompt.Label+": "+string(m.prompt.Value)+"_", width)}
+}
+
+func viewHelp() []string {
+	return []string{
+		"",
+		styleBold + "Feed" + styleReset,
+		"  j/k, arrows   move selection",
+		"  enter         open post and comments",
+		"  o             cycle sort (hot, new, top, rising)",
+		"  s             switch submolt",
+		"  u / d         upvote / downvote",
+		"  l             open link in browser",
+		"  n             compose a new post",
+		"  r             refresh",
+		"",
+		styleBold + "Post" + styleReset,
+		"  j/k           select post or comment",
+		"  r             reply to the selection",
+		"  u / d         vote on the selection",
+		"  esc           back to feed",
+		"",
+		styleBold + "Compose" + styleReset,
+		"  tab           next field",
+		"  ctrl+s        send",
+		"  esc           discard",
+	}
+}